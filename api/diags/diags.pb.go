@@ -8,6 +8,7 @@ import (
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
+	_ "github.com/gogo/protobuf/types"
 	admin "github.com/onosproject/onos-config/api/admin"
 	device "github.com/onosproject/onos-config/api/types/change/device"
 	github_com_onosproject_onos_config_api_types_change_network "github.com/onosproject/onos-config/api/types/change/network"
@@ -17,12 +18,14 @@ import (
 	codes "google.golang.org/grpc/codes"
 	status "google.golang.org/grpc/status"
 	math "math"
+	time "time"
 )
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
+var _ = time.Kitchen
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the proto package it is being compiled against.
@@ -402,6 +405,423 @@ func (m *ListDeviceChangeResponse) GetType() Type {
 	return Type_NONE
 }
 
+// ConfigDiffRequest requests the differences in the configuration of devices between two network changes
+type ConfigDiffRequest struct {
+	// from_change is the ID or the index of the network change to compare from
+	FromChange string `protobuf:"bytes,1,opt,name=from_change,json=fromChange,proto3" json:"from_change,omitempty"`
+	// to_change is the ID or the index of the network change to compare to
+	ToChange string `protobuf:"bytes,2,opt,name=to_change,json=toChange,proto3" json:"to_change,omitempty"`
+	// device_id is an optional device to restrict the differences to
+	DeviceID             github_com_onosproject_onos_config_api_types_device.ID `protobuf:"bytes,3,opt,name=device_id,json=deviceId,proto3,casttype=github.com/onosproject/onos-config/api/types/device.ID" json:"device_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                                               `json:"-"`
+	XXX_unrecognized     []byte                                                 `json:"-"`
+	XXX_sizecache        int32                                                  `json:"-"`
+}
+
+func (m *ConfigDiffRequest) Reset()         { *m = ConfigDiffRequest{} }
+func (m *ConfigDiffRequest) String() string { return proto.CompactTextString(m) }
+func (*ConfigDiffRequest) ProtoMessage()    {}
+func (*ConfigDiffRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf204ae8da722ebe, []int{6}
+}
+func (m *ConfigDiffRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConfigDiffRequest.Unmarshal(m, b)
+}
+func (m *ConfigDiffRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConfigDiffRequest.Marshal(b, m, deterministic)
+}
+func (m *ConfigDiffRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConfigDiffRequest.Merge(m, src)
+}
+func (m *ConfigDiffRequest) XXX_Size() int {
+	return xxx_messageInfo_ConfigDiffRequest.Size(m)
+}
+func (m *ConfigDiffRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConfigDiffRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConfigDiffRequest proto.InternalMessageInfo
+
+func (m *ConfigDiffRequest) GetFromChange() string {
+	if m != nil {
+		return m.FromChange
+	}
+	return ""
+}
+
+func (m *ConfigDiffRequest) GetToChange() string {
+	if m != nil {
+		return m.ToChange
+	}
+	return ""
+}
+
+func (m *ConfigDiffRequest) GetDeviceID() github_com_onosproject_onos_config_api_types_device.ID {
+	if m != nil {
+		return m.DeviceID
+	}
+	return ""
+}
+
+// PathDiff is the difference in the value of a single path
+type PathDiff struct {
+	// path is the path of the leaf that differs
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// type is ADDED, UPDATED or REMOVED for a leaf that was added, modified or removed
+	Type Type `protobuf:"varint,2,opt,name=type,proto3,enum=onos.config.diags.Type" json:"type,omitempty"`
+	// old_value is the value in the configuration compared from - not given if the leaf was added
+	OldValue *device.TypedValue `protobuf:"bytes,3,opt,name=old_value,json=oldValue,proto3" json:"old_value,omitempty"`
+	// new_value is the value in the configuration compared to - not given if the leaf was removed
+	NewValue             *device.TypedValue `protobuf:"bytes,4,opt,name=new_value,json=newValue,proto3" json:"new_value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *PathDiff) Reset()         { *m = PathDiff{} }
+func (m *PathDiff) String() string { return proto.CompactTextString(m) }
+func (*PathDiff) ProtoMessage()    {}
+func (*PathDiff) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf204ae8da722ebe, []int{7}
+}
+func (m *PathDiff) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PathDiff.Unmarshal(m, b)
+}
+func (m *PathDiff) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PathDiff.Marshal(b, m, deterministic)
+}
+func (m *PathDiff) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PathDiff.Merge(m, src)
+}
+func (m *PathDiff) XXX_Size() int {
+	return xxx_messageInfo_PathDiff.Size(m)
+}
+func (m *PathDiff) XXX_DiscardUnknown() {
+	xxx_messageInfo_PathDiff.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PathDiff proto.InternalMessageInfo
+
+func (m *PathDiff) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *PathDiff) GetType() Type {
+	if m != nil {
+		return m.Type
+	}
+	return Type_NONE
+}
+
+func (m *PathDiff) GetOldValue() *device.TypedValue {
+	if m != nil {
+		return m.OldValue
+	}
+	return nil
+}
+
+func (m *PathDiff) GetNewValue() *device.TypedValue {
+	if m != nil {
+		return m.NewValue
+	}
+	return nil
+}
+
+// DeviceConfigDiff carries the differences in the configuration of a single device
+type DeviceConfigDiff struct {
+	// device_id is the device whose configuration differs
+	DeviceID github_com_onosproject_onos_config_api_types_device.ID `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3,casttype=github.com/onosproject/onos-config/api/types/device.ID" json:"device_id,omitempty"`
+	// device_version is the version of the device
+	DeviceVersion github_com_onosproject_onos_config_api_types_device.Version `protobuf:"bytes,2,opt,name=device_version,json=deviceVersion,proto3,casttype=github.com/onosproject/onos-config/api/types/device.Version" json:"device_version,omitempty"`
+	// diffs are the differences ordered by path
+	Diffs                []*PathDiff `protobuf:"bytes,3,rep,name=diffs,proto3" json:"diffs,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *DeviceConfigDiff) Reset()         { *m = DeviceConfigDiff{} }
+func (m *DeviceConfigDiff) String() string { return proto.CompactTextString(m) }
+func (*DeviceConfigDiff) ProtoMessage()    {}
+func (*DeviceConfigDiff) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf204ae8da722ebe, []int{8}
+}
+func (m *DeviceConfigDiff) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeviceConfigDiff.Unmarshal(m, b)
+}
+func (m *DeviceConfigDiff) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeviceConfigDiff.Marshal(b, m, deterministic)
+}
+func (m *DeviceConfigDiff) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeviceConfigDiff.Merge(m, src)
+}
+func (m *DeviceConfigDiff) XXX_Size() int {
+	return xxx_messageInfo_DeviceConfigDiff.Size(m)
+}
+func (m *DeviceConfigDiff) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeviceConfigDiff.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeviceConfigDiff proto.InternalMessageInfo
+
+func (m *DeviceConfigDiff) GetDeviceID() github_com_onosproject_onos_config_api_types_device.ID {
+	if m != nil {
+		return m.DeviceID
+	}
+	return ""
+}
+
+func (m *DeviceConfigDiff) GetDeviceVersion() github_com_onosproject_onos_config_api_types_device.Version {
+	if m != nil {
+		return m.DeviceVersion
+	}
+	return ""
+}
+
+func (m *DeviceConfigDiff) GetDiffs() []*PathDiff {
+	if m != nil {
+		return m.Diffs
+	}
+	return nil
+}
+
+// ConfigDiffResponse carries the differences in configuration between two network changes
+type ConfigDiffResponse struct {
+	// devices are the differences of each device changed between the two network changes
+	Devices              []*DeviceConfigDiff `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *ConfigDiffResponse) Reset()         { *m = ConfigDiffResponse{} }
+func (m *ConfigDiffResponse) String() string { return proto.CompactTextString(m) }
+func (*ConfigDiffResponse) ProtoMessage()    {}
+func (*ConfigDiffResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf204ae8da722ebe, []int{9}
+}
+func (m *ConfigDiffResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConfigDiffResponse.Unmarshal(m, b)
+}
+func (m *ConfigDiffResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConfigDiffResponse.Marshal(b, m, deterministic)
+}
+func (m *ConfigDiffResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConfigDiffResponse.Merge(m, src)
+}
+func (m *ConfigDiffResponse) XXX_Size() int {
+	return xxx_messageInfo_ConfigDiffResponse.Size(m)
+}
+func (m *ConfigDiffResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConfigDiffResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConfigDiffResponse proto.InternalMessageInfo
+
+func (m *ConfigDiffResponse) GetDevices() []*DeviceConfigDiff {
+	if m != nil {
+		return m.Devices
+	}
+	return nil
+}
+
+// DriftRequest requests the configuration drift of a device - the paths whose value on the device
+// differs from the configuration intended for it by onos-config
+type DriftRequest struct {
+	// device_id is the device whose configuration is audited
+	DeviceID github_com_onosproject_onos_config_api_types_device.ID `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3,casttype=github.com/onosproject/onos-config/api/types/device.ID" json:"device_id,omitempty"`
+	// audit indicates whether to audit the device now rather than give the outcome of its latest audit
+	Audit bool `protobuf:"varint,2,opt,name=audit,proto3" json:"audit,omitempty"`
+	// subscribe indicates whether to keep the stream open for the outcome of the audits that follow
+	Subscribe            bool     `protobuf:"varint,3,opt,name=subscribe,proto3" json:"subscribe,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DriftRequest) Reset()         { *m = DriftRequest{} }
+func (m *DriftRequest) String() string { return proto.CompactTextString(m) }
+func (*DriftRequest) ProtoMessage()    {}
+func (*DriftRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf204ae8da722ebe, []int{10}
+}
+func (m *DriftRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DriftRequest.Unmarshal(m, b)
+}
+func (m *DriftRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DriftRequest.Marshal(b, m, deterministic)
+}
+func (m *DriftRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DriftRequest.Merge(m, src)
+}
+func (m *DriftRequest) XXX_Size() int {
+	return xxx_messageInfo_DriftRequest.Size(m)
+}
+func (m *DriftRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DriftRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DriftRequest proto.InternalMessageInfo
+
+func (m *DriftRequest) GetDeviceID() github_com_onosproject_onos_config_api_types_device.ID {
+	if m != nil {
+		return m.DeviceID
+	}
+	return ""
+}
+
+func (m *DriftRequest) GetAudit() bool {
+	if m != nil {
+		return m.Audit
+	}
+	return false
+}
+
+func (m *DriftRequest) GetSubscribe() bool {
+	if m != nil {
+		return m.Subscribe
+	}
+	return false
+}
+
+// PathDrift is a path whose value on the device differs from its intended value
+type PathDrift struct {
+	// path is the path of the leaf that differs
+	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	// intended_value is the value intended for the leaf
+	IntendedValue *device.TypedValue `protobuf:"bytes,2,opt,name=intended_value,json=intendedValue,proto3" json:"intended_value,omitempty"`
+	// actual_value is the value of the leaf on the device - not given if the leaf is missing from the device
+	ActualValue          *device.TypedValue `protobuf:"bytes,3,opt,name=actual_value,json=actualValue,proto3" json:"actual_value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *PathDrift) Reset()         { *m = PathDrift{} }
+func (m *PathDrift) String() string { return proto.CompactTextString(m) }
+func (*PathDrift) ProtoMessage()    {}
+func (*PathDrift) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf204ae8da722ebe, []int{11}
+}
+func (m *PathDrift) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PathDrift.Unmarshal(m, b)
+}
+func (m *PathDrift) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PathDrift.Marshal(b, m, deterministic)
+}
+func (m *PathDrift) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PathDrift.Merge(m, src)
+}
+func (m *PathDrift) XXX_Size() int {
+	return xxx_messageInfo_PathDrift.Size(m)
+}
+func (m *PathDrift) XXX_DiscardUnknown() {
+	xxx_messageInfo_PathDrift.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PathDrift proto.InternalMessageInfo
+
+func (m *PathDrift) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *PathDrift) GetIntendedValue() *device.TypedValue {
+	if m != nil {
+		return m.IntendedValue
+	}
+	return nil
+}
+
+func (m *PathDrift) GetActualValue() *device.TypedValue {
+	if m != nil {
+		return m.ActualValue
+	}
+	return nil
+}
+
+// DriftResponse carries the outcome of a single audit of the configuration of a device
+type DriftResponse struct {
+	// device_id is the device that was audited
+	DeviceID github_com_onosproject_onos_config_api_types_device.ID `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3,casttype=github.com/onosproject/onos-config/api/types/device.ID" json:"device_id,omitempty"`
+	// device_version is the version of the device
+	DeviceVersion github_com_onosproject_onos_config_api_types_device.Version `protobuf:"bytes,2,opt,name=device_version,json=deviceVersion,proto3,casttype=github.com/onosproject/onos-config/api/types/device.Version" json:"device_version,omitempty"`
+	// audited is the time of the audit
+	Audited time.Time `protobuf:"bytes,3,opt,name=audited,proto3,stdtime" json:"audited"`
+	// drifts are the paths that differ ordered by path - empty if the device has not drifted
+	Drifts []*PathDrift `protobuf:"bytes,4,rep,name=drifts,proto3" json:"drifts,omitempty"`
+	// unmanaged are the leaves on the device that onos-config does not hold, ordered by path. They are
+	// not drift, as they may have been configured by other tools
+	Unmanaged            []*device.PathValue `protobuf:"bytes,5,rep,name=unmanaged,proto3" json:"unmanaged,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *DriftResponse) Reset()         { *m = DriftResponse{} }
+func (m *DriftResponse) String() string { return proto.CompactTextString(m) }
+func (*DriftResponse) ProtoMessage()    {}
+func (*DriftResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_bf204ae8da722ebe, []int{12}
+}
+func (m *DriftResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DriftResponse.Unmarshal(m, b)
+}
+func (m *DriftResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DriftResponse.Marshal(b, m, deterministic)
+}
+func (m *DriftResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DriftResponse.Merge(m, src)
+}
+func (m *DriftResponse) XXX_Size() int {
+	return xxx_messageInfo_DriftResponse.Size(m)
+}
+func (m *DriftResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DriftResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DriftResponse proto.InternalMessageInfo
+
+func (m *DriftResponse) GetDeviceID() github_com_onosproject_onos_config_api_types_device.ID {
+	if m != nil {
+		return m.DeviceID
+	}
+	return ""
+}
+
+func (m *DriftResponse) GetDeviceVersion() github_com_onosproject_onos_config_api_types_device.Version {
+	if m != nil {
+		return m.DeviceVersion
+	}
+	return ""
+}
+
+func (m *DriftResponse) GetAudited() time.Time {
+	if m != nil {
+		return m.Audited
+	}
+	return time.Time{}
+}
+
+func (m *DriftResponse) GetDrifts() []*PathDrift {
+	if m != nil {
+		return m.Drifts
+	}
+	return nil
+}
+
+func (m *DriftResponse) GetUnmanaged() []*device.PathValue {
+	if m != nil {
+		return m.Unmanaged
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterEnum("onos.config.diags.Type", Type_name, Type_value)
 	proto.RegisterType((*OpStateRequest)(nil), "onos.config.diags.OpStateRequest")
@@ -410,51 +830,83 @@ func init() {
 	proto.RegisterType((*ListNetworkChangeResponse)(nil), "onos.config.diags.ListNetworkChangeResponse")
 	proto.RegisterType((*ListDeviceChangeRequest)(nil), "onos.config.diags.ListDeviceChangeRequest")
 	proto.RegisterType((*ListDeviceChangeResponse)(nil), "onos.config.diags.ListDeviceChangeResponse")
+	proto.RegisterType((*ConfigDiffRequest)(nil), "onos.config.diags.ConfigDiffRequest")
+	proto.RegisterType((*PathDiff)(nil), "onos.config.diags.PathDiff")
+	proto.RegisterType((*DeviceConfigDiff)(nil), "onos.config.diags.DeviceConfigDiff")
+	proto.RegisterType((*ConfigDiffResponse)(nil), "onos.config.diags.ConfigDiffResponse")
+	proto.RegisterType((*DriftRequest)(nil), "onos.config.diags.DriftRequest")
+	proto.RegisterType((*PathDrift)(nil), "onos.config.diags.PathDrift")
+	proto.RegisterType((*DriftResponse)(nil), "onos.config.diags.DriftResponse")
 }
 
 func init() { proto.RegisterFile("api/diags/diags.proto", fileDescriptor_bf204ae8da722ebe) }
 
 var fileDescriptor_bf204ae8da722ebe = []byte{
-	// 623 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xc1, 0x6e, 0xd3, 0x4c,
-	0x10, 0xae, 0xd3, 0xfc, 0xfd, 0xe3, 0x29, 0x2d, 0xe9, 0x0a, 0xd4, 0x60, 0x21, 0xb5, 0x58, 0x95,
-	0x28, 0x0d, 0xd8, 0x55, 0x10, 0x08, 0x09, 0x89, 0x2a, 0xc1, 0x69, 0x15, 0x04, 0x49, 0xe5, 0xb6,
-	0xb9, 0x22, 0xc7, 0xd9, 0x3a, 0x2e, 0xad, 0xd7, 0x78, 0x37, 0xa9, 0x72, 0xe5, 0x84, 0xc4, 0x9b,
-	0xf0, 0x28, 0x3c, 0x44, 0x0e, 0x3c, 0x04, 0x87, 0x9e, 0xd0, 0xee, 0xba, 0x4e, 0x9d, 0xba, 0x55,
-	0x1a, 0x2e, 0x96, 0x67, 0x3d, 0xf3, 0x7d, 0x33, 0xdf, 0x37, 0x5e, 0x78, 0xe8, 0x84, 0xbe, 0xd9,
-	0xf5, 0x1d, 0x8f, 0xca, 0xa7, 0x11, 0x46, 0x84, 0x11, 0xb4, 0x42, 0x02, 0x42, 0x0d, 0x97, 0x04,
-	0xc7, 0xbe, 0x67, 0x88, 0x0f, 0xda, 0x03, 0x8f, 0x78, 0x44, 0x7c, 0x35, 0xf9, 0x9b, 0x4c, 0xd4,
-	0xde, 0x78, 0x3e, 0xeb, 0xf5, 0x3b, 0x86, 0x4b, 0xce, 0x4c, 0x5e, 0x13, 0x46, 0xe4, 0x04, 0xbb,
-	0x4c, 0xbc, 0xbf, 0x90, 0xf5, 0x26, 0xa7, 0x70, 0xba, 0x67, 0x7e, 0x20, 0x9f, 0x71, 0xe5, 0xee,
-	0x94, 0x95, 0x6c, 0x18, 0x62, 0x6a, 0xba, 0x3d, 0x27, 0xf0, 0xb0, 0xd9, 0xc5, 0x03, 0xdf, 0xc5,
-	0xf2, 0x2c, 0xc6, 0xd9, 0x9b, 0x05, 0x27, 0xc0, 0xec, 0x9c, 0x44, 0x5f, 0xae, 0x02, 0xe9, 0x1f,
-	0x60, 0xb9, 0x15, 0x1e, 0x30, 0x87, 0x61, 0x1b, 0x7f, 0xed, 0x63, 0xca, 0x90, 0x06, 0x05, 0x49,
-	0xd8, 0xe8, 0x96, 0x94, 0x75, 0x65, 0x53, 0xb5, 0x93, 0x18, 0x3d, 0x06, 0x95, 0xf6, 0x3b, 0xd4,
-	0x8d, 0xfc, 0x0e, 0x2e, 0xe5, 0xd6, 0x95, 0xcd, 0x82, 0x3d, 0x3e, 0xd0, 0xbf, 0x29, 0x70, 0x3f,
-	0x01, 0xa3, 0x21, 0x09, 0x28, 0x46, 0x65, 0xc8, 0x73, 0x3a, 0x81, 0xb4, 0x5c, 0x59, 0x35, 0xae,
-	0x4a, 0x2c, 0x85, 0x39, 0x1c, 0x86, 0xd8, 0x16, 0x49, 0xa8, 0x06, 0x6a, 0xe8, 0xb0, 0xde, 0xc0,
-	0x39, 0xed, 0x4b, 0xf8, 0xc5, 0xca, 0x46, 0xaa, 0x42, 0x0e, 0x62, 0xc8, 0x7e, 0x8c, 0x7d, 0x87,
-	0xf5, 0xda, 0x3c, 0xd7, 0x1e, 0x97, 0xe9, 0xbf, 0x14, 0x28, 0x7d, 0xf4, 0x29, 0x6b, 0xca, 0x61,
-	0xdf, 0x8b, 0x8a, 0xcb, 0xd9, 0x52, 0xfd, 0x2b, 0x13, 0xfd, 0xa3, 0x13, 0x28, 0x48, 0x02, 0xbf,
-	0x2b, 0xd8, 0xd5, 0x5a, 0xf3, 0xf7, 0x68, 0xad, 0x20, 0x21, 0x1a, 0xd6, 0xc5, 0x68, 0xed, 0xdd,
-	0x3f, 0xc8, 0x6e, 0x34, 0x2c, 0x3b, 0xc1, 0x47, 0x1b, 0xb0, 0x74, 0xee, 0xb3, 0x1e, 0xe9, 0x33,
-	0x1b, 0x87, 0xa7, 0xce, 0xb0, 0x34, 0x2f, 0xba, 0x49, 0x1f, 0xea, 0x3f, 0x14, 0x78, 0x94, 0x31,
-	0x4c, 0xac, 0x6d, 0x15, 0x16, 0x24, 0x9e, 0x18, 0x65, 0xb1, 0xf2, 0x2c, 0x4b, 0xab, 0x4b, 0xf6,
-	0x34, 0x44, 0x5c, 0x98, 0xd8, 0x93, 0xcb, 0xb0, 0x47, 0xfe, 0x1a, 0x63, 0x7b, 0xf4, 0x9f, 0x39,
-	0x58, 0xe5, 0xdd, 0x58, 0x42, 0xfe, 0xbb, 0x28, 0xeb, 0x82, 0x2a, 0x3d, 0xfb, 0x9c, 0x48, 0xbb,
-	0xcb, 0xa5, 0x95, 0x48, 0x42, 0xda, 0xd7, 0x77, 0x92, 0x36, 0xde, 0x00, 0x2e, 0x69, 0xb2, 0x9c,
-	0xc7, 0xb0, 0x1c, 0x93, 0x0c, 0x70, 0x44, 0x7d, 0x12, 0x08, 0x4d, 0xd5, 0xda, 0xce, 0xc5, 0x68,
-	0xed, 0xed, 0x2c, 0xe8, 0x6d, 0x09, 0x63, 0x2f, 0xc9, 0x38, 0x0e, 0xaf, 0x5b, 0x97, 0xcf, 0xb2,
-	0xee, 0x7b, 0xbc, 0x87, 0x69, 0xb1, 0x62, 0xe7, 0x76, 0x26, 0x9c, 0x7b, 0x7a, 0xcb, 0x96, 0xa7,
-	0x00, 0x66, 0xf1, 0x6d, 0xeb, 0x15, 0xe4, 0x79, 0x84, 0x0a, 0x90, 0x6f, 0xb6, 0x9a, 0xf5, 0xe2,
-	0x1c, 0x52, 0xe1, 0xbf, 0xaa, 0x65, 0xd5, 0xad, 0xa2, 0x82, 0x16, 0xe1, 0xff, 0xa3, 0x7d, 0xab,
-	0x7a, 0x58, 0xb7, 0x8a, 0x39, 0x1e, 0xd8, 0xf5, 0x4f, 0xad, 0x76, 0xdd, 0x2a, 0xce, 0x57, 0xfe,
-	0x28, 0xb0, 0x24, 0x69, 0x0f, 0x70, 0xc4, 0x7b, 0x40, 0x14, 0xd0, 0xb5, 0x6d, 0xa4, 0xa8, 0x9c,
-	0xc1, 0x7e, 0xd3, 0x1f, 0xa8, 0x3d, 0x9f, 0x2e, 0x59, 0xea, 0xa4, 0xcf, 0x6d, 0x2b, 0x28, 0x84,
-	0x95, 0x49, 0x1d, 0x29, 0xda, 0xba, 0x01, 0x26, 0x63, 0x35, 0xb5, 0xf2, 0x54, 0xb9, 0x63, 0xc6,
-	0x0a, 0x86, 0x7b, 0xf1, 0x35, 0x66, 0xf1, 0x64, 0x74, 0x04, 0xb0, 0x87, 0x59, 0x7c, 0x84, 0x9e,
-	0x64, 0xc0, 0xa5, 0xaf, 0x50, 0x4d, 0xbf, 0x2d, 0x45, 0x12, 0x6d, 0x2b, 0x9d, 0x05, 0x71, 0x03,
-	0xbf, 0xfc, 0x1b, 0x00, 0x00, 0xff, 0xff, 0xf8, 0x6c, 0x55, 0x2b, 0x8e, 0x06, 0x00, 0x00,
+	// 1013 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xdc, 0x56, 0xcd, 0x6e, 0xe3, 0x54,
+	0x14, 0xae, 0xe3, 0xb4, 0xb5, 0x4f, 0x9a, 0x92, 0x5e, 0x0d, 0x9a, 0xe0, 0x19, 0x29, 0xc1, 0x74,
+	0x44, 0x99, 0x82, 0x33, 0x84, 0x1f, 0x21, 0x21, 0x66, 0xd4, 0x8e, 0x33, 0xa5, 0x68, 0x68, 0x07,
+	0xb7, 0xd3, 0x25, 0x95, 0x13, 0x5f, 0x3b, 0x1e, 0x12, 0x5f, 0x13, 0x5f, 0xb7, 0xea, 0x96, 0x15,
+	0x12, 0x1b, 0xc4, 0x82, 0x57, 0x40, 0xf0, 0x00, 0xf0, 0x0a, 0x88, 0x2d, 0xfb, 0x22, 0xf1, 0x18,
+	0xb3, 0x42, 0xf7, 0xc7, 0x69, 0x9c, 0xba, 0xa5, 0x29, 0x9a, 0x59, 0xb0, 0xb1, 0xee, 0xcf, 0x39,
+	0xdf, 0x39, 0xf7, 0xfb, 0xce, 0x3d, 0xbe, 0xf0, 0xaa, 0x1b, 0x87, 0x2d, 0x2f, 0x74, 0x83, 0x44,
+	0x7c, 0xad, 0x78, 0x44, 0x28, 0x41, 0x2b, 0x24, 0x22, 0x89, 0xd5, 0x23, 0x91, 0x1f, 0x06, 0x16,
+	0xdf, 0x30, 0x6e, 0x04, 0x24, 0x20, 0x7c, 0xb7, 0xc5, 0x46, 0xc2, 0xd0, 0x68, 0x04, 0x84, 0x04,
+	0x03, 0xdc, 0xe2, 0xb3, 0x6e, 0xea, 0xb7, 0x68, 0x38, 0xc4, 0x09, 0x75, 0x87, 0xb1, 0x34, 0xf8,
+	0x28, 0x08, 0x69, 0x3f, 0xed, 0x5a, 0x3d, 0x32, 0x6c, 0x31, 0xd0, 0x78, 0x44, 0x9e, 0xe1, 0x1e,
+	0xe5, 0xe3, 0x77, 0x44, 0x80, 0x16, 0xcb, 0xc1, 0xf5, 0x86, 0x61, 0x24, 0xbe, 0xd2, 0xf3, 0xd1,
+	0x15, 0x3d, 0xe9, 0x49, 0x8c, 0x93, 0x56, 0xaf, 0xef, 0x46, 0x01, 0x6e, 0x79, 0xf8, 0x28, 0xec,
+	0x61, 0xb1, 0x26, 0x71, 0xb6, 0xae, 0x83, 0x13, 0x61, 0x7a, 0x4c, 0x46, 0x5f, 0x4d, 0x02, 0x99,
+	0x9f, 0xc1, 0xf2, 0x6e, 0xbc, 0x47, 0x5d, 0x8a, 0x1d, 0xfc, 0x75, 0x8a, 0x13, 0x8a, 0x0c, 0xd0,
+	0x44, 0xc0, 0x6d, 0xaf, 0xae, 0x34, 0x95, 0x35, 0xdd, 0x19, 0xcf, 0xd1, 0x6d, 0xd0, 0x93, 0xb4,
+	0x9b, 0xf4, 0x46, 0x61, 0x17, 0xd7, 0x4b, 0x4d, 0x65, 0x4d, 0x73, 0xce, 0x16, 0xcc, 0x6f, 0x14,
+	0x78, 0x65, 0x0c, 0x96, 0xc4, 0x24, 0x4a, 0x30, 0x5a, 0x87, 0x32, 0x0b, 0xc7, 0x91, 0x96, 0xdb,
+	0x37, 0xad, 0x49, 0x0d, 0x04, 0x31, 0xfb, 0x27, 0x31, 0x76, 0xb8, 0x11, 0xda, 0x04, 0x3d, 0x76,
+	0x69, 0xff, 0xc8, 0x1d, 0xa4, 0x02, 0xbe, 0xd2, 0x5e, 0xcd, 0x79, 0x88, 0x83, 0x58, 0x22, 0x1f,
+	0xeb, 0x89, 0x4b, 0xfb, 0x07, 0xcc, 0xd6, 0x39, 0x73, 0x33, 0xff, 0x50, 0xa0, 0xfe, 0x38, 0x4c,
+	0xe8, 0x8e, 0x38, 0xec, 0x43, 0xee, 0x91, 0x9d, 0x2d, 0x97, 0xbf, 0x32, 0x95, 0x3f, 0x7a, 0x06,
+	0x9a, 0x08, 0x10, 0x7a, 0x3c, 0xba, 0xbe, 0xb9, 0xf3, 0xf7, 0x69, 0x43, 0x13, 0x10, 0xdb, 0xf6,
+	0xf3, 0xd3, 0xc6, 0xfd, 0xff, 0x40, 0xbb, 0xb5, 0x6d, 0x3b, 0x63, 0x7c, 0xb4, 0x0a, 0xd5, 0xe3,
+	0x90, 0xf6, 0x49, 0x4a, 0x1d, 0x1c, 0x0f, 0xdc, 0x93, 0xba, 0xca, 0xb3, 0xc9, 0x2f, 0x9a, 0xdf,
+	0x29, 0xf0, 0x5a, 0xc1, 0x61, 0x24, 0xb7, 0x1b, 0xb0, 0x20, 0xf0, 0xf8, 0x51, 0x2a, 0xed, 0xb7,
+	0x8a, 0xb8, 0xca, 0xa2, 0xe7, 0x21, 0xa4, 0xe3, 0x58, 0x9e, 0x52, 0x81, 0x3c, 0xe2, 0xee, 0x9c,
+	0xc9, 0x63, 0xfe, 0x52, 0x82, 0x9b, 0x2c, 0x1b, 0x9b, 0xd3, 0x3f, 0x0b, 0xb3, 0x3d, 0xd0, 0x85,
+	0x66, 0x87, 0x63, 0x6a, 0x1f, 0x31, 0x6a, 0x05, 0x12, 0xa7, 0xf6, 0xc3, 0x99, 0xa8, 0x95, 0x15,
+	0xc0, 0x28, 0x1d, 0x17, 0xa7, 0x0f, 0xcb, 0x32, 0xc8, 0x11, 0x1e, 0x25, 0x21, 0x89, 0x38, 0xa7,
+	0xfa, 0xe6, 0x83, 0xe7, 0xa7, 0x8d, 0x8f, 0xaf, 0x83, 0x7e, 0x20, 0x60, 0x9c, 0xaa, 0x98, 0xcb,
+	0xe9, 0x79, 0xe9, 0xca, 0x45, 0xd2, 0x7d, 0x2b, 0xeb, 0x30, 0x4f, 0x96, 0x54, 0xee, 0xc1, 0x94,
+	0x72, 0x6f, 0x5e, 0x52, 0xe5, 0x39, 0x80, 0x6b, 0xe9, 0xf6, 0xab, 0x02, 0x2b, 0x0f, 0xf9, 0x9e,
+	0x1d, 0xfa, 0x7e, 0xa6, 0x58, 0x03, 0x2a, 0xfe, 0x88, 0x0c, 0x0f, 0x27, 0x12, 0xd1, 0x1d, 0x60,
+	0x4b, 0x22, 0x16, 0xba, 0x05, 0x3a, 0x25, 0xd9, 0x76, 0x49, 0x74, 0x02, 0x4a, 0xe4, 0x66, 0x4e,
+	0x51, 0xf5, 0xc5, 0x28, 0x6a, 0xfe, 0xa9, 0x80, 0xc6, 0x2e, 0x39, 0x4b, 0x1b, 0x21, 0x28, 0xb3,
+	0x5b, 0x2e, 0x13, 0xe5, 0xe3, 0x99, 0x68, 0x60, 0xdd, 0x85, 0x0c, 0xbc, 0x43, 0xd1, 0x5d, 0x54,
+	0xce, 0xfb, 0x9d, 0x4b, 0x78, 0x67, 0x9e, 0x9e, 0x68, 0x2f, 0x1a, 0x19, 0x88, 0x11, 0xc3, 0x88,
+	0xf0, 0xb1, 0xc4, 0x28, 0xcf, 0x84, 0x11, 0xe1, 0x63, 0x3e, 0x32, 0x7f, 0x28, 0x41, 0x4d, 0x8a,
+	0x3a, 0x16, 0x25, 0xcf, 0xa7, 0xf2, 0xd2, 0x6e, 0x48, 0xe9, 0x85, 0xdc, 0x90, 0x77, 0x61, 0xde,
+	0x0b, 0x7d, 0x3f, 0xa9, 0xab, 0x4d, 0x75, 0xad, 0xd2, 0xbe, 0x55, 0xa0, 0x4b, 0x26, 0xab, 0x23,
+	0x2c, 0xcd, 0x3d, 0x40, 0x93, 0x25, 0x2a, 0xef, 0xc9, 0x27, 0xb0, 0x28, 0x90, 0x93, 0xba, 0xc2,
+	0xa1, 0xde, 0x28, 0x80, 0x9a, 0xe6, 0xd2, 0xc9, 0x7c, 0xcc, 0x9f, 0x14, 0x58, 0xb2, 0x47, 0xa1,
+	0x4f, 0xb3, 0x9a, 0x7f, 0x29, 0x2c, 0xdf, 0x80, 0x79, 0x37, 0xf5, 0x42, 0x2a, 0x7f, 0x90, 0x62,
+	0x92, 0x6f, 0x90, 0xea, 0xf4, 0xaf, 0xf3, 0x37, 0x05, 0x74, 0x4e, 0x09, 0xcb, 0xb6, 0xb0, 0xd4,
+	0x1f, 0xc3, 0x72, 0x18, 0x51, 0x1c, 0x79, 0x38, 0x2b, 0xe1, 0xd2, 0x2c, 0xe5, 0x57, 0xcd, 0x9c,
+	0xf9, 0x14, 0x7d, 0x0a, 0x4b, 0x6e, 0x8f, 0xa6, 0xee, 0xe0, 0x3a, 0xd7, 0xa1, 0x22, 0x5c, 0x45,
+	0x35, 0xff, 0xa8, 0x42, 0x55, 0x72, 0x2c, 0x45, 0xfb, 0x5f, 0x95, 0xf2, 0x7d, 0x58, 0xe4, 0xfa,
+	0x61, 0x4f, 0x72, 0x64, 0x58, 0xe2, 0x75, 0x68, 0x65, 0xaf, 0x43, 0x6b, 0x3f, 0x7b, 0x1d, 0x6e,
+	0x6a, 0xbf, 0x9f, 0x36, 0xe6, 0xbe, 0xff, 0xab, 0xa1, 0x38, 0x99, 0x13, 0x7a, 0x1f, 0x16, 0x3c,
+	0xc6, 0x4e, 0x52, 0x2f, 0xf3, 0x02, 0xbe, 0x7d, 0xd1, 0x5d, 0xe0, 0x14, 0x4a, 0x5b, 0xd6, 0x66,
+	0xd2, 0x68, 0xe8, 0x46, 0x6e, 0x80, 0xbd, 0xfa, 0x7c, 0x53, 0xbd, 0xfa, 0x43, 0x68, 0xec, 0x76,
+	0xf7, 0x03, 0x28, 0x33, 0xcd, 0x90, 0x06, 0xe5, 0x9d, 0xdd, 0x9d, 0x4e, 0x6d, 0x0e, 0xe9, 0x30,
+	0xbf, 0x61, 0xdb, 0x1d, 0xbb, 0xa6, 0xa0, 0x0a, 0x2c, 0x3e, 0x7d, 0x62, 0x6f, 0xec, 0x77, 0xec,
+	0x5a, 0x89, 0x4d, 0x9c, 0xce, 0xe7, 0xbb, 0x07, 0x1d, 0xbb, 0xa6, 0xb6, 0x7f, 0x56, 0xa1, 0x2a,
+	0x7a, 0xfc, 0x1e, 0x1e, 0x31, 0x74, 0x94, 0x00, 0x3a, 0xf7, 0x06, 0x49, 0xd0, 0x7a, 0xc1, 0x41,
+	0x2e, 0x7a, 0x77, 0x19, 0x6f, 0x5f, 0xcd, 0x58, 0x14, 0x90, 0x39, 0x77, 0x4f, 0x41, 0x31, 0xac,
+	0x4c, 0xff, 0x3d, 0x13, 0x74, 0xf7, 0x02, 0x98, 0x82, 0x07, 0x89, 0xb1, 0x7e, 0x25, 0xdb, 0x89,
+	0x88, 0x5f, 0x42, 0x75, 0x0b, 0xd3, 0x89, 0x96, 0xbc, 0x5a, 0x80, 0x70, 0xee, 0x37, 0x6a, 0xdc,
+	0xf9, 0x17, 0xab, 0x2c, 0x02, 0xfa, 0x02, 0xb4, 0x2d, 0x4c, 0xc5, 0x05, 0x6f, 0x14, 0xb5, 0xb1,
+	0x89, 0x46, 0x65, 0x34, 0x2f, 0x36, 0x38, 0x4b, 0xb9, 0x8d, 0x61, 0x49, 0xbe, 0xb7, 0x6d, 0x66,
+	0x81, 0x9e, 0x02, 0x6c, 0x61, 0x2a, 0x97, 0xd0, 0xeb, 0x05, 0x18, 0xf9, 0xb7, 0xbe, 0x61, 0x5e,
+	0x66, 0x22, 0x02, 0xdd, 0x53, 0xba, 0x0b, 0xbc, 0xd4, 0xdf, 0xfb, 0x67, 0x00, 0x8a, 0xb3, 0xff,
+	0xc8, 0x58, 0x0d, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -475,6 +927,12 @@ type ChangeServiceClient interface {
 	// List gets a stream of device change add/update/remove events for device
 	// changes matching changeid
 	ListDeviceChanges(ctx context.Context, in *ListDeviceChangeRequest, opts ...grpc.CallOption) (ChangeService_ListDeviceChangesClient, error)
+	// GetConfigDiff gets the added, removed and modified leaves of each device between
+	// two network changes
+	GetConfigDiff(ctx context.Context, in *ConfigDiffRequest, opts ...grpc.CallOption) (*ConfigDiffResponse, error)
+	// GetDrift gets the configuration drift of a device. If subscribe is true keep on streaming
+	// the outcome of the audits that follow
+	GetDrift(ctx context.Context, in *DriftRequest, opts ...grpc.CallOption) (ChangeService_GetDriftClient, error)
 }
 
 type changeServiceClient struct {
@@ -549,6 +1007,47 @@ func (x *changeServiceListDeviceChangesClient) Recv() (*ListDeviceChangeResponse
 	return m, nil
 }
 
+func (c *changeServiceClient) GetConfigDiff(ctx context.Context, in *ConfigDiffRequest, opts ...grpc.CallOption) (*ConfigDiffResponse, error) {
+	out := new(ConfigDiffResponse)
+	err := c.cc.Invoke(ctx, "/onos.config.diags.ChangeService/GetConfigDiff", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *changeServiceClient) GetDrift(ctx context.Context, in *DriftRequest, opts ...grpc.CallOption) (ChangeService_GetDriftClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ChangeService_serviceDesc.Streams[2], "/onos.config.diags.ChangeService/GetDrift", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &changeServiceGetDriftClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ChangeService_GetDriftClient interface {
+	Recv() (*DriftResponse, error)
+	grpc.ClientStream
+}
+
+type changeServiceGetDriftClient struct {
+	grpc.ClientStream
+}
+
+func (x *changeServiceGetDriftClient) Recv() (*DriftResponse, error) {
+	m := new(DriftResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // ChangeServiceServer is the server API for ChangeService service.
 type ChangeServiceServer interface {
 	// List gets a stream of network change add/update/remove events for network
@@ -557,6 +1056,12 @@ type ChangeServiceServer interface {
 	// List gets a stream of device change add/update/remove events for device
 	// changes matching changeid
 	ListDeviceChanges(*ListDeviceChangeRequest, ChangeService_ListDeviceChangesServer) error
+	// GetConfigDiff gets the added, removed and modified leaves of each device between
+	// two network changes
+	GetConfigDiff(context.Context, *ConfigDiffRequest) (*ConfigDiffResponse, error)
+	// GetDrift gets the configuration drift of a device. If subscribe is true keep on streaming
+	// the outcome of the audits that follow
+	GetDrift(*DriftRequest, ChangeService_GetDriftServer) error
 }
 
 // UnimplementedChangeServiceServer can be embedded to have forward compatible implementations.
@@ -569,6 +1074,12 @@ func (*UnimplementedChangeServiceServer) ListNetworkChanges(req *ListNetworkChan
 func (*UnimplementedChangeServiceServer) ListDeviceChanges(req *ListDeviceChangeRequest, srv ChangeService_ListDeviceChangesServer) error {
 	return status.Errorf(codes.Unimplemented, "method ListDeviceChanges not implemented")
 }
+func (*UnimplementedChangeServiceServer) GetConfigDiff(ctx context.Context, req *ConfigDiffRequest) (*ConfigDiffResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfigDiff not implemented")
+}
+func (*UnimplementedChangeServiceServer) GetDrift(req *DriftRequest, srv ChangeService_GetDriftServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetDrift not implemented")
+}
 
 func RegisterChangeServiceServer(s *grpc.Server, srv ChangeServiceServer) {
 	s.RegisterService(&_ChangeService_serviceDesc, srv)
@@ -616,10 +1127,54 @@ func (x *changeServiceListDeviceChangesServer) Send(m *ListDeviceChangeResponse)
 	return x.ServerStream.SendMsg(m)
 }
 
+func _ChangeService_GetConfigDiff_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfigDiffRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChangeServiceServer).GetConfigDiff(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/onos.config.diags.ChangeService/GetConfigDiff",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChangeServiceServer).GetConfigDiff(ctx, req.(*ConfigDiffRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChangeService_GetDrift_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DriftRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChangeServiceServer).GetDrift(m, &changeServiceGetDriftServer{stream})
+}
+
+type ChangeService_GetDriftServer interface {
+	Send(*DriftResponse) error
+	grpc.ServerStream
+}
+
+type changeServiceGetDriftServer struct {
+	grpc.ServerStream
+}
+
+func (x *changeServiceGetDriftServer) Send(m *DriftResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _ChangeService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "onos.config.diags.ChangeService",
 	HandlerType: (*ChangeServiceServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetConfigDiff",
+			Handler:    _ChangeService_GetConfigDiff_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "ListNetworkChanges",
@@ -631,6 +1186,11 @@ var _ChangeService_serviceDesc = grpc.ServiceDesc{
 			Handler:       _ChangeService_ListDeviceChanges_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetDrift",
+			Handler:       _ChangeService_GetDrift_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "api/diags/diags.proto",
 }
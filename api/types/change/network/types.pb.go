@@ -12,6 +12,7 @@ import (
 	change "github.com/onosproject/onos-config/api/types/change"
 	device "github.com/onosproject/onos-config/api/types/change/device"
 	github_com_onosproject_onos_config_api_types_change_device "github.com/onosproject/onos-config/api/types/change/device"
+	github_com_onosproject_onos_config_api_types_device "github.com/onosproject/onos-config/api/types/device"
 	io "io"
 	math "math"
 	math_bits "math/bits"
@@ -30,6 +31,40 @@ var _ = time.Kitchen
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
+// OfflinePolicy is what a network change does while devices it changes are not connected
+type OfflinePolicy int32
+
+const (
+	// WAIT waits for all the devices to be connected, however long it takes
+	OfflinePolicy_WAIT OfflinePolicy = 0
+	// WAIT_TIMEOUT waits for all the devices to be connected up to the offline timeout of the
+	// change, and then fails the change
+	OfflinePolicy_WAIT_TIMEOUT OfflinePolicy = 1
+	// APPLY_REACHABLE applies the change to the connected devices straight away, and to each
+	// of the other devices once it is connected
+	OfflinePolicy_APPLY_REACHABLE OfflinePolicy = 2
+)
+
+var OfflinePolicy_name = map[int32]string{
+	0: "WAIT",
+	1: "WAIT_TIMEOUT",
+	2: "APPLY_REACHABLE",
+}
+
+var OfflinePolicy_value = map[string]int32{
+	"WAIT":            0,
+	"WAIT_TIMEOUT":    1,
+	"APPLY_REACHABLE": 2,
+}
+
+func (x OfflinePolicy) String() string {
+	return proto.EnumName(OfflinePolicy_name, int32(x))
+}
+
+func (OfflinePolicy) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_6dd0d36e65f2772f, []int{0}
+}
+
 // NetworkChange specifies the configuration for a network change
 // A network change is a configuration change that spans multiple devices. The change contains a list of
 // per-device changes to be applied to the network.
@@ -59,6 +94,14 @@ type NetworkChange struct {
 	Refs []*DeviceChangeRef `protobuf:"bytes,8,rep,name=refs,proto3" json:"refs,omitempty"`
 	// 'deleted' is a flag indicating whether this change is being deleted by a snapshot
 	Deleted bool `protobuf:"varint,9,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	// 'offline_policy' is what the change does while devices it changes are not connected
+	OfflinePolicy OfflinePolicy `protobuf:"varint,10,opt,name=offline_policy,json=offlinePolicy,proto3,enum=onos.config.change.network.OfflinePolicy" json:"offline_policy,omitempty"`
+	// 'offline_timeout' is how long a change with the WAIT_TIMEOUT offline policy waits for its
+	// devices to be connected before failing
+	OfflineTimeout time.Duration `protobuf:"bytes,11,opt,name=offline_timeout,json=offlineTimeout,proto3,stdduration" json:"offline_timeout"`
+	// 'remediation' is given for a change made by onos-config to remediate the configuration drift of
+	// a device, and records what the change overwrites on the device
+	Remediation *Remediation `protobuf:"bytes,12,opt,name=remediation,proto3" json:"remediation,omitempty"`
 }
 
 func (m *NetworkChange) Reset()         { *m = NetworkChange{} }
@@ -157,6 +200,110 @@ func (m *NetworkChange) GetDeleted() bool {
 	return false
 }
 
+func (m *NetworkChange) GetOfflinePolicy() OfflinePolicy {
+	if m != nil {
+		return m.OfflinePolicy
+	}
+	return OfflinePolicy_WAIT
+}
+
+func (m *NetworkChange) GetOfflineTimeout() time.Duration {
+	if m != nil {
+		return m.OfflineTimeout
+	}
+	return 0
+}
+
+func (m *NetworkChange) GetRemediation() *Remediation {
+	if m != nil {
+		return m.Remediation
+	}
+	return nil
+}
+
+// Remediation is the audit record of a network change that remediates the configuration drift of a device
+type Remediation struct {
+	// 'device_id' is the device whose configuration drifted
+	DeviceID github_com_onosproject_onos_config_api_types_device.ID `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3,casttype=github.com/onosproject/onos-config/api/types/device.ID" json:"device_id,omitempty"`
+	// 'device_version' is the version of the device
+	DeviceVersion github_com_onosproject_onos_config_api_types_device.Version `protobuf:"bytes,2,opt,name=device_version,json=deviceVersion,proto3,casttype=github.com/onosproject/onos-config/api/types/device.Version" json:"device_version,omitempty"`
+	// 'audited' is the time of the audit that found the drift
+	Audited time.Time `protobuf:"bytes,3,opt,name=audited,proto3,stdtime" json:"audited"`
+	// 'overwritten' are the values on the device that the change overwrites. A path given without
+	// a value was missing from the device
+	Overwritten []*device.PathValue `protobuf:"bytes,4,rep,name=overwritten,proto3" json:"overwritten,omitempty"`
+	// 'excluded' are the paths that drifted but are excluded from remediation, and are left as they are
+	Excluded []string `protobuf:"bytes,5,rep,name=excluded,proto3" json:"excluded,omitempty"`
+}
+
+func (m *Remediation) Reset()         { *m = Remediation{} }
+func (m *Remediation) String() string { return proto.CompactTextString(m) }
+func (*Remediation) ProtoMessage()    {}
+func (*Remediation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_6dd0d36e65f2772f, []int{1}
+}
+func (m *Remediation) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Remediation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Remediation.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Remediation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Remediation.Merge(m, src)
+}
+func (m *Remediation) XXX_Size() int {
+	return m.Size()
+}
+func (m *Remediation) XXX_DiscardUnknown() {
+	xxx_messageInfo_Remediation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Remediation proto.InternalMessageInfo
+
+func (m *Remediation) GetDeviceID() github_com_onosproject_onos_config_api_types_device.ID {
+	if m != nil {
+		return m.DeviceID
+	}
+	return ""
+}
+
+func (m *Remediation) GetDeviceVersion() github_com_onosproject_onos_config_api_types_device.Version {
+	if m != nil {
+		return m.DeviceVersion
+	}
+	return ""
+}
+
+func (m *Remediation) GetAudited() time.Time {
+	if m != nil {
+		return m.Audited
+	}
+	return time.Time{}
+}
+
+func (m *Remediation) GetOverwritten() []*device.PathValue {
+	if m != nil {
+		return m.Overwritten
+	}
+	return nil
+}
+
+func (m *Remediation) GetExcluded() []string {
+	if m != nil {
+		return m.Excluded
+	}
+	return nil
+}
+
 // DeviceChangeRef is a reference to a device change
 type DeviceChangeRef struct {
 	// 'device_change_id' is the unique identifier of the device change
@@ -167,7 +314,7 @@ func (m *DeviceChangeRef) Reset()         { *m = DeviceChangeRef{} }
 func (m *DeviceChangeRef) String() string { return proto.CompactTextString(m) }
 func (*DeviceChangeRef) ProtoMessage()    {}
 func (*DeviceChangeRef) Descriptor() ([]byte, []int) {
-	return fileDescriptor_6dd0d36e65f2772f, []int{1}
+	return fileDescriptor_6dd0d36e65f2772f, []int{2}
 }
 func (m *DeviceChangeRef) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -204,7 +351,9 @@ func (m *DeviceChangeRef) GetDeviceChangeID() github_com_onosproject_onos_config
 }
 
 func init() {
+	proto.RegisterEnum("onos.config.change.network.OfflinePolicy", OfflinePolicy_name, OfflinePolicy_value)
 	proto.RegisterType((*NetworkChange)(nil), "onos.config.change.network.NetworkChange")
+	proto.RegisterType((*Remediation)(nil), "onos.config.change.network.Remediation")
 	proto.RegisterType((*DeviceChangeRef)(nil), "onos.config.change.network.DeviceChangeRef")
 }
 
@@ -213,36 +362,53 @@ func init() {
 }
 
 var fileDescriptor_6dd0d36e65f2772f = []byte{
-	// 461 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x92, 0x3f, 0x6f, 0xd4, 0x30,
-	0x18, 0xc6, 0xcf, 0xf7, 0x37, 0xe7, 0x96, 0x82, 0x2c, 0x06, 0x2b, 0x42, 0x49, 0xa8, 0x18, 0x22,
-	0x21, 0x1c, 0xa9, 0x2c, 0x48, 0x08, 0x2a, 0x85, 0x13, 0xd2, 0x2d, 0x0c, 0x2e, 0x7b, 0x95, 0x8b,
-	0x7d, 0xa9, 0xa1, 0x17, 0x47, 0x89, 0x53, 0x60, 0xe0, 0x3b, 0xdc, 0xc7, 0xaa, 0x98, 0x3a, 0x32,
-	0x05, 0x94, 0xfb, 0x16, 0x37, 0xa1, 0xd8, 0x49, 0x75, 0x40, 0x19, 0xb8, 0x25, 0x7a, 0xf3, 0xe6,
-	0xf9, 0x3d, 0x79, 0xfc, 0xbe, 0x86, 0x4f, 0xa2, 0x4c, 0x04, 0xea, 0x4b, 0xc6, 0x8b, 0x20, 0xbe,
-	0x88, 0xd2, 0x84, 0x07, 0x29, 0x57, 0x9f, 0x64, 0xfe, 0xd1, 0x34, 0x49, 0x96, 0x4b, 0x25, 0x91,
-	0x2d, 0x53, 0x59, 0x90, 0x58, 0xa6, 0x4b, 0x91, 0x10, 0xa3, 0x23, 0xad, 0xce, 0x76, 0x13, 0x29,
-	0x93, 0x4b, 0x1e, 0x68, 0xe5, 0xa2, 0x5c, 0x06, 0x4a, 0xac, 0x78, 0xa1, 0xa2, 0x55, 0x66, 0x60,
-	0xfb, 0x61, 0x22, 0x13, 0xa9, 0xcb, 0xa0, 0xa9, 0xda, 0xee, 0x69, 0x22, 0xd4, 0x45, 0xb9, 0x20,
-	0xb1, 0x5c, 0x05, 0x8d, 0x7b, 0x96, 0xcb, 0x0f, 0x3c, 0x56, 0xba, 0x7e, 0x66, 0xfe, 0x14, 0xfc,
-	0x95, 0x6d, 0x27, 0x93, 0xfd, 0x76, 0x1f, 0x03, 0xc6, 0xaf, 0x44, 0xfc, 0x9b, 0xcf, 0xf1, 0xb7,
-	0x01, 0xbc, 0xf7, 0xce, 0x9c, 0xe5, 0x8d, 0x16, 0xa1, 0x47, 0xb0, 0x2f, 0x18, 0x06, 0x1e, 0xf0,
-	0xa7, 0xe1, 0x61, 0x5d, 0xb9, 0xfd, 0xf9, 0x6c, 0xab, 0x9f, 0xb4, 0x2f, 0x18, 0x72, 0xe1, 0x48,
-	0xa4, 0x8c, 0x7f, 0xc6, 0x7d, 0x0f, 0xf8, 0xc3, 0x70, 0xba, 0xad, 0xdc, 0xd1, 0xbc, 0x69, 0x50,
-	0xd3, 0x47, 0x3e, 0xb4, 0x72, 0x7e, 0x25, 0x0a, 0x21, 0x53, 0x3c, 0xd0, 0x9a, 0xc3, 0x6d, 0xe5,
-	0x5a, 0xb4, 0xed, 0xd1, 0xdb, 0xaf, 0xe8, 0x05, 0x1c, 0x17, 0x2a, 0x52, 0x65, 0x81, 0x87, 0x1e,
-	0xf0, 0x0f, 0x4e, 0x6c, 0x72, 0xc7, 0x9c, 0xcf, 0xb4, 0x22, 0x1c, 0x5e, 0x57, 0x6e, 0x8f, 0xb6,
-	0x7a, 0xf4, 0x1a, 0x4e, 0xe2, 0x9c, 0x47, 0x8a, 0x33, 0x3c, 0x6a, 0x51, 0xb3, 0x06, 0xd2, 0xad,
-	0x81, 0xbc, 0xef, 0xd6, 0x10, 0x5a, 0x0d, 0xba, 0xfe, 0xe1, 0x02, 0xda, 0x41, 0x0d, 0x5f, 0x66,
-	0x4c, 0xf3, 0xe3, 0xff, 0xe1, 0x5b, 0x08, 0xbd, 0x84, 0x13, 0x13, 0xaf, 0xc0, 0x13, 0x6f, 0xe0,
-	0x1f, 0x9c, 0x3c, 0xbe, 0x2b, 0xba, 0x99, 0x36, 0x31, 0x63, 0xa5, 0x1d, 0x81, 0x4e, 0xe1, 0x30,
-	0xe7, 0xcb, 0x02, 0x5b, 0x9a, 0x7c, 0x4a, 0xfe, 0x7d, 0xb9, 0xc8, 0x4c, 0x3b, 0xb4, 0x06, 0x7c,
-	0x49, 0x35, 0x88, 0x30, 0x9c, 0x30, 0x7e, 0xc9, 0x9b, 0xf4, 0x53, 0x0f, 0xf8, 0x16, 0xed, 0x5e,
-	0x8f, 0xd7, 0x00, 0xde, 0xff, 0x83, 0x41, 0x5f, 0xe1, 0x03, 0x13, 0xe4, 0xdc, 0x98, 0x9f, 0xdf,
-	0x2e, 0xf7, 0xac, 0xae, 0xdc, 0xa3, 0x5d, 0xb9, 0x5e, 0xf4, 0xab, 0xfd, 0x2f, 0x16, 0x99, 0xcf,
-	0xe8, 0x11, 0xdb, 0x35, 0x64, 0x21, 0xbe, 0xae, 0x1d, 0x70, 0x53, 0x3b, 0xe0, 0x67, 0xed, 0x80,
-	0xf5, 0xc6, 0xe9, 0xdd, 0x6c, 0x9c, 0xde, 0xf7, 0x8d, 0xd3, 0x5b, 0x8c, 0xf5, 0xac, 0x9f, 0xff,
-	0x0a, 0x00, 0x00, 0xff, 0xff, 0x6c, 0x54, 0x42, 0x3a, 0x84, 0x03, 0x00, 0x00,
+	// 730 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0xcd, 0x6e, 0xd3, 0x4c,
+	0x14, 0x8d, 0xf3, 0xd3, 0x38, 0x93, 0x9f, 0x46, 0xf3, 0x7d, 0x0b, 0x13, 0xa1, 0x38, 0x54, 0x95,
+	0x30, 0x20, 0x6c, 0xa9, 0x48, 0x08, 0xa9, 0x82, 0x92, 0x34, 0xad, 0x88, 0x54, 0x68, 0x34, 0x0d,
+	0x45, 0xac, 0x22, 0xd7, 0x9e, 0xa4, 0x03, 0x89, 0x27, 0xb2, 0xc7, 0x69, 0xbb, 0xe0, 0x1d, 0xba,
+	0xe4, 0x45, 0x78, 0x87, 0x2e, 0xbb, 0x64, 0x15, 0x50, 0xfa, 0x16, 0x59, 0x20, 0xe4, 0x99, 0x49,
+	0xeb, 0xfe, 0x50, 0xa9, 0xdd, 0x54, 0x73, 0xef, 0x9c, 0x73, 0xe6, 0xf6, 0xdc, 0xe3, 0x80, 0x65,
+	0x7b, 0x44, 0x2c, 0x76, 0x34, 0xc2, 0x81, 0xe5, 0xec, 0xdb, 0x5e, 0x1f, 0x5b, 0x1e, 0x66, 0x07,
+	0xd4, 0xff, 0x2a, 0x9a, 0xe6, 0xc8, 0xa7, 0x8c, 0xc2, 0x0a, 0xf5, 0x68, 0x60, 0x3a, 0xd4, 0xeb,
+	0x91, 0xbe, 0x29, 0x70, 0xa6, 0xc4, 0x55, 0xf4, 0x3e, 0xa5, 0xfd, 0x01, 0xb6, 0x38, 0x72, 0x2f,
+	0xec, 0x59, 0x8c, 0x0c, 0x71, 0xc0, 0xec, 0xe1, 0x48, 0x90, 0x2b, 0xd5, 0xab, 0x00, 0x37, 0xf4,
+	0x6d, 0x46, 0xa8, 0x27, 0xef, 0xff, 0xef, 0xd3, 0x3e, 0xe5, 0x47, 0x2b, 0x3a, 0xc9, 0xee, 0x5a,
+	0x9f, 0xb0, 0xfd, 0x70, 0xcf, 0x74, 0xe8, 0xd0, 0x8a, 0x5e, 0x1f, 0xf9, 0xf4, 0x0b, 0x76, 0x18,
+	0x3f, 0x3f, 0x17, 0x93, 0x58, 0xd7, 0x66, 0x8f, 0xcd, 0x5c, 0xd9, 0xbc, 0x8f, 0x80, 0x8b, 0xc7,
+	0xc4, 0xb9, 0xa4, 0xb3, 0xf4, 0x23, 0x03, 0x8a, 0x1f, 0xc4, 0xff, 0xba, 0xce, 0x41, 0xf0, 0x21,
+	0x48, 0x12, 0x57, 0x53, 0x6a, 0x8a, 0x91, 0x6b, 0x14, 0xa6, 0x13, 0x3d, 0xd9, 0x6a, 0xce, 0xf8,
+	0x5f, 0x94, 0x24, 0x2e, 0xd4, 0x41, 0x86, 0x78, 0x2e, 0x3e, 0xd4, 0x92, 0x35, 0xc5, 0x48, 0x37,
+	0x72, 0xb3, 0x89, 0x9e, 0x69, 0x45, 0x0d, 0x24, 0xfa, 0xd0, 0x00, 0xaa, 0x8f, 0xc7, 0x24, 0x20,
+	0xd4, 0xd3, 0x52, 0x1c, 0x53, 0x98, 0x4d, 0x74, 0x15, 0xc9, 0x1e, 0x3a, 0xbf, 0x85, 0xaf, 0xc0,
+	0x42, 0xc0, 0x6c, 0x16, 0x06, 0x5a, 0xba, 0xa6, 0x18, 0xf9, 0x95, 0x8a, 0x79, 0xc3, 0x1e, 0x76,
+	0x38, 0xa2, 0x91, 0x3e, 0x99, 0xe8, 0x09, 0x24, 0xf1, 0xf0, 0x0d, 0xc8, 0x3a, 0x3e, 0xb6, 0x19,
+	0x76, 0xb5, 0x8c, 0xa4, 0x8a, 0x2d, 0x98, 0xf3, 0x2d, 0x98, 0x9d, 0xf9, 0x9a, 0x1a, 0x6a, 0x44,
+	0x3d, 0xfe, 0xa5, 0x2b, 0x68, 0x4e, 0x8a, 0xf8, 0xe1, 0xc8, 0xe5, 0xfc, 0x85, 0xbb, 0xf0, 0x25,
+	0x09, 0xae, 0x82, 0xac, 0x18, 0x2f, 0xd0, 0xb2, 0xb5, 0x94, 0x91, 0x5f, 0x79, 0x74, 0xd3, 0xe8,
+	0xc2, 0x6d, 0x53, 0xd8, 0x8a, 0xe6, 0x0c, 0xb8, 0x06, 0xd2, 0x3e, 0xee, 0x05, 0x9a, 0xca, 0x99,
+	0xcf, 0xcc, 0x7f, 0x87, 0xcf, 0x6c, 0x72, 0x05, 0x29, 0x80, 0x7b, 0x88, 0x13, 0xa1, 0x06, 0xb2,
+	0x2e, 0x1e, 0xe0, 0x68, 0xfa, 0x5c, 0x4d, 0x31, 0x54, 0x34, 0x2f, 0x61, 0x1b, 0x94, 0x68, 0xaf,
+	0x37, 0x20, 0x1e, 0xee, 0x8e, 0xe8, 0x80, 0x38, 0x47, 0x1a, 0xa8, 0x29, 0x46, 0x69, 0xe5, 0xc9,
+	0x6d, 0x8f, 0x6c, 0x0b, 0x46, 0x9b, 0x13, 0x50, 0x91, 0xc6, 0x4b, 0xb8, 0x05, 0x16, 0xe7, 0x8a,
+	0x51, 0xf0, 0x69, 0xc8, 0xb4, 0x3c, 0x77, 0xec, 0xc1, 0x35, 0xc7, 0x9a, 0x32, 0xf7, 0xc2, 0xb0,
+	0xef, 0x91, 0x61, 0xf3, 0x69, 0x3a, 0x82, 0x0a, 0x5b, 0x20, 0xef, 0xe3, 0x21, 0x76, 0x09, 0x07,
+	0x6a, 0x05, 0xae, 0xf4, 0xf8, 0xb6, 0xe1, 0xd0, 0x05, 0x1c, 0xc5, 0xb9, 0x4b, 0x7f, 0x92, 0x20,
+	0x1f, 0xbb, 0x84, 0x0e, 0xc8, 0x09, 0xbf, 0xbb, 0xe7, 0xe1, 0xdd, 0x9c, 0x4e, 0x74, 0x55, 0x58,
+	0xc8, 0x23, 0xfc, 0xf2, 0x4e, 0x9f, 0x8c, 0xdc, 0x5e, 0xab, 0x89, 0x54, 0x71, 0x6c, 0xb9, 0xb0,
+	0x07, 0x4a, 0xf2, 0x91, 0x31, 0xf6, 0x79, 0xc2, 0x93, 0xfc, 0xa5, 0xb5, 0xd9, 0x44, 0x5f, 0xbd,
+	0x8f, 0xfa, 0xae, 0x90, 0x41, 0x45, 0x51, 0xcb, 0x32, 0xca, 0xa7, 0x1d, 0xba, 0x24, 0xda, 0x70,
+	0xea, 0x2e, 0xf9, 0x94, 0x24, 0xb8, 0x09, 0xf2, 0x74, 0x8c, 0xfd, 0x03, 0x9f, 0x30, 0x86, 0x3d,
+	0x2d, 0xcd, 0x93, 0xb6, 0x7c, 0x4b, 0x46, 0xdb, 0x36, 0xdb, 0xdf, 0xb5, 0x07, 0x21, 0x46, 0x71,
+	0x22, 0xac, 0x00, 0x15, 0x1f, 0x3a, 0x83, 0xd0, 0xe5, 0x1f, 0x5a, 0xca, 0xc8, 0xa1, 0xf3, 0x7a,
+	0xe9, 0x58, 0x01, 0x8b, 0x57, 0xf2, 0x09, 0xbf, 0x81, 0xb2, 0xf4, 0x47, 0xc8, 0x5f, 0xec, 0x62,
+	0x67, 0x3a, 0xd1, 0x4b, 0x71, 0x38, 0xdf, 0xc8, 0xeb, 0xfb, 0xff, 0x88, 0x45, 0x8b, 0x29, 0xb9,
+	0x71, 0x41, 0xf7, 0xe9, 0x5b, 0x50, 0xbc, 0x14, 0x66, 0xa8, 0x82, 0xf4, 0xa7, 0x7a, 0xab, 0x53,
+	0x4e, 0xc0, 0x32, 0x28, 0x44, 0xa7, 0x6e, 0xa7, 0xf5, 0x7e, 0x63, 0xfb, 0x63, 0xa7, 0xac, 0xc0,
+	0xff, 0xc0, 0x62, 0xbd, 0xdd, 0xde, 0xfa, 0xdc, 0x45, 0x1b, 0xf5, 0xf5, 0x77, 0xf5, 0xc6, 0xd6,
+	0x46, 0x39, 0xd9, 0xd0, 0x4e, 0xa6, 0x55, 0xe5, 0x74, 0x5a, 0x55, 0x7e, 0x4f, 0xab, 0xca, 0xf1,
+	0x59, 0x35, 0x71, 0x7a, 0x56, 0x4d, 0xfc, 0x3c, 0xab, 0x26, 0xf6, 0x16, 0xb8, 0xf3, 0x2f, 0xfe,
+	0x0e, 0x00, 0xe6, 0x02, 0xc2, 0xd1, 0x52, 0x06, 0x00, 0x00,
 }
 
 func (m *NetworkChange) Marshal() (dAtA []byte, err error) {
@@ -265,6 +431,31 @@ func (m *NetworkChange) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Remediation != nil {
+		{
+			size, err := m.Remediation.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTypes(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x62
+	}
+	n2, err2 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.OfflineTimeout, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.OfflineTimeout):])
+	if err2 != nil {
+		return 0, err2
+	}
+	i -= n2
+	i = encodeVarintTypes(dAtA, i, uint64(n2))
+	i--
+	dAtA[i] = 0x5a
+	if m.OfflinePolicy != 0 {
+		i = encodeVarintTypes(dAtA, i, uint64(m.OfflinePolicy))
+		i--
+		dAtA[i] = 0x50
+	}
 	if m.Deleted {
 		i--
 		if m.Deleted {
@@ -303,20 +494,20 @@ func (m *NetworkChange) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			dAtA[i] = 0x3a
 		}
 	}
-	n1, err1 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Updated, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Updated):])
-	if err1 != nil {
-		return 0, err1
+	n3, err3 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Updated, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Updated):])
+	if err3 != nil {
+		return 0, err3
 	}
-	i -= n1
-	i = encodeVarintTypes(dAtA, i, uint64(n1))
+	i -= n3
+	i = encodeVarintTypes(dAtA, i, uint64(n3))
 	i--
 	dAtA[i] = 0x32
-	n2, err2 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Created, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Created):])
-	if err2 != nil {
-		return 0, err2
+	n4, err4 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Created, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Created):])
+	if err4 != nil {
+		return 0, err4
 	}
-	i -= n2
-	i = encodeVarintTypes(dAtA, i, uint64(n2))
+	i -= n4
+	i = encodeVarintTypes(dAtA, i, uint64(n4))
 	i--
 	dAtA[i] = 0x2a
 	{
@@ -349,6 +540,74 @@ func (m *NetworkChange) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *Remediation) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Remediation) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Remediation) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Excluded) > 0 {
+		for iNdEx := len(m.Excluded) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Excluded[iNdEx])
+			copy(dAtA[i:], m.Excluded[iNdEx])
+			i = encodeVarintTypes(dAtA, i, uint64(len(m.Excluded[iNdEx])))
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if len(m.Overwritten) > 0 {
+		for iNdEx := len(m.Overwritten) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Overwritten[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintTypes(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	n6, err6 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Audited, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Audited):])
+	if err6 != nil {
+		return 0, err6
+	}
+	i -= n6
+	i = encodeVarintTypes(dAtA, i, uint64(n6))
+	i--
+	dAtA[i] = 0x1a
+	if len(m.DeviceVersion) > 0 {
+		i -= len(m.DeviceVersion)
+		copy(dAtA[i:], m.DeviceVersion)
+		i = encodeVarintTypes(dAtA, i, uint64(len(m.DeviceVersion)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.DeviceID) > 0 {
+		i -= len(m.DeviceID)
+		copy(dAtA[i:], m.DeviceID)
+		i = encodeVarintTypes(dAtA, i, uint64(len(m.DeviceID)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func (m *DeviceChangeRef) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -427,6 +686,46 @@ func (m *NetworkChange) Size() (n int) {
 	if m.Deleted {
 		n += 2
 	}
+	if m.OfflinePolicy != 0 {
+		n += 1 + sovTypes(uint64(m.OfflinePolicy))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.OfflineTimeout)
+	n += 1 + l + sovTypes(uint64(l))
+	if m.Remediation != nil {
+		l = m.Remediation.Size()
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	return n
+}
+
+func (m *Remediation) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.DeviceID)
+	if l > 0 {
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	l = len(m.DeviceVersion)
+	if l > 0 {
+		n += 1 + l + sovTypes(uint64(l))
+	}
+	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.Audited)
+	n += 1 + l + sovTypes(uint64(l))
+	if len(m.Overwritten) > 0 {
+		for _, e := range m.Overwritten {
+			l = e.Size()
+			n += 1 + l + sovTypes(uint64(l))
+		}
+	}
+	if len(m.Excluded) > 0 {
+		for _, s := range m.Excluded {
+			l = len(s)
+			n += 1 + l + sovTypes(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -735,6 +1034,310 @@ func (m *NetworkChange) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.Deleted = bool(v != 0)
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OfflinePolicy", wireType)
+			}
+			m.OfflinePolicy = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.OfflinePolicy |= OfflinePolicy(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OfflineTimeout", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.OfflineTimeout, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Remediation", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Remediation == nil {
+				m.Remediation = &Remediation{}
+			}
+			if err := m.Remediation.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipTypes(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Remediation) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowTypes
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Remediation: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Remediation: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeviceID", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DeviceID = github_com_onosproject_onos_config_api_types_device.ID(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeviceVersion", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DeviceVersion = github_com_onosproject_onos_config_api_types_device.Version(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Audited", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdTimeUnmarshal(&m.Audited, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Overwritten", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Overwritten = append(m.Overwritten, &device.PathValue{})
+			if err := m.Overwritten[len(m.Overwritten)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Excluded", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Excluded = append(m.Excluded, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
@@ -5,16 +5,21 @@ package change
 
 import (
 	fmt "fmt"
+	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
+	_ "github.com/gogo/protobuf/types"
+	github_com_gogo_protobuf_types "github.com/gogo/protobuf/types"
 	io "io"
 	math "math"
 	math_bits "math/bits"
+	time "time"
 )
 
 // Reference imports to suppress errors if they are not otherwise used.
 var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
+var _ = time.Kitchen
 
 // This is a compile-time assertion to ensure that this generated file
 // is compatible with the proto package it is being compiled against.
@@ -122,6 +127,8 @@ type Status struct {
 	Message string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
 	// incarnation is the status incarnation number
 	Incarnation uint64 `protobuf:"varint,5,opt,name=incarnation,proto3" json:"incarnation,omitempty"`
+	// 'waiting_since' is when the change started waiting for devices that are not connected
+	WaitingSince *time.Time `protobuf:"bytes,6,opt,name=waiting_since,json=waitingSince,proto3,stdtime" json:"waiting_since,omitempty"`
 }
 
 func (m *Status) Reset()         { *m = Status{} }
@@ -192,6 +199,13 @@ func (m *Status) GetIncarnation() uint64 {
 	return 0
 }
 
+func (m *Status) GetWaitingSince() *time.Time {
+	if m != nil {
+		return m.WaitingSince
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterEnum("onos.config.change.Phase", Phase_name, Phase_value)
 	proto.RegisterEnum("onos.config.change.State", State_name, State_value)
@@ -202,27 +216,31 @@ func init() {
 func init() { proto.RegisterFile("api/types/change/types.proto", fileDescriptor_0083573634b6757f) }
 
 var fileDescriptor_0083573634b6757f = []byte{
-	// 307 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x91, 0xcf, 0x4a, 0xc3, 0x30,
-	0x1c, 0xc7, 0x9b, 0x6d, 0xed, 0xb6, 0xdf, 0x44, 0x4a, 0x4e, 0x51, 0xb4, 0x54, 0x4f, 0x63, 0x87,
-	0x0c, 0xe6, 0x13, 0xec, 0x4f, 0x9c, 0xc3, 0xda, 0x8e, 0xe8, 0x0b, 0xc4, 0x11, 0xb7, 0x1e, 0x4c,
-	0xca, 0x12, 0x0f, 0xbe, 0x85, 0x8f, 0xe5, 0x71, 0x47, 0xf1, 0x24, 0xeb, 0x8b, 0x48, 0x1b, 0x05,
-	0x41, 0x3d, 0x7e, 0x93, 0xcf, 0xe7, 0xcb, 0x17, 0x7e, 0x70, 0x22, 0x8a, 0x7c, 0x68, 0x9f, 0x0b,
-	0x69, 0x86, 0xab, 0x8d, 0x50, 0x6b, 0xe9, 0x02, 0x2d, 0xb6, 0xda, 0x6a, 0x8c, 0xb5, 0xd2, 0x86,
-	0xae, 0xb4, 0x7a, 0xc8, 0xd7, 0xd4, 0xfd, 0x9f, 0xbf, 0x23, 0x08, 0x6e, 0xad, 0xb0, 0x4f, 0x06,
-	0x0f, 0xc1, 0x2f, 0x36, 0xc2, 0x48, 0x82, 0x62, 0xd4, 0x3f, 0x1c, 0x1d, 0xd1, 0xdf, 0x38, 0x5d,
-	0x56, 0x00, 0x77, 0x5c, 0x25, 0x18, 0x2b, 0xac, 0x24, 0x8d, 0xff, 0x85, 0xaa, 0x5b, 0x72, 0xc7,
-	0xe1, 0x11, 0x04, 0x5b, 0x29, 0x8c, 0x56, 0xa4, 0x59, 0x1b, 0xc7, 0x7f, 0x19, 0xbc, 0x26, 0xf8,
-	0x17, 0x89, 0x09, 0xb4, 0x1f, 0xa5, 0x31, 0x62, 0x2d, 0x49, 0x2b, 0x46, 0xfd, 0x2e, 0xff, 0x8e,
-	0x38, 0x86, 0x5e, 0xae, 0x56, 0x62, 0xab, 0x84, 0xcd, 0xb5, 0x22, 0x7e, 0x8c, 0xfa, 0x2d, 0xfe,
-	0xf3, 0x69, 0x70, 0x06, 0x7e, 0x3d, 0x18, 0x03, 0x04, 0xd3, 0xab, 0x71, 0x3a, 0x67, 0xa1, 0x87,
-	0x0f, 0xa0, 0xc3, 0xb3, 0x24, 0x99, 0x8c, 0xa7, 0xd7, 0x21, 0x1a, 0x50, 0xf0, 0xeb, 0x89, 0xb8,
-	0x07, 0xed, 0x25, 0x4b, 0x67, 0x8b, 0x74, 0xee, 0x98, 0x69, 0x76, 0xb3, 0x4c, 0xd8, 0x1d, 0x0b,
-	0x1b, 0x95, 0x7d, 0x39, 0x5e, 0x24, 0x6c, 0x16, 0x36, 0x07, 0xa7, 0x10, 0xb8, 0x81, 0xb8, 0x03,
-	0xad, 0x34, 0x4b, 0xab, 0xc6, 0x2e, 0xf8, 0x8c, 0xf3, 0x8c, 0x87, 0x68, 0x42, 0x5e, 0xf7, 0x11,
-	0xda, 0xed, 0x23, 0xf4, 0xb1, 0x8f, 0xd0, 0x4b, 0x19, 0x79, 0xbb, 0x32, 0xf2, 0xde, 0xca, 0xc8,
-	0xbb, 0x0f, 0xea, 0x1b, 0x5c, 0x7c, 0x06, 0x00, 0x00, 0xff, 0xff, 0x0d, 0xde, 0xc6, 0x87, 0xa3,
-	0x01, 0x00, 0x00,
+	// 383 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x90, 0x41, 0x6f, 0xd3, 0x30,
+	0x14, 0xc7, 0xeb, 0x2e, 0xc9, 0xb6, 0xd7, 0x81, 0x22, 0x8b, 0x83, 0x89, 0x20, 0x0b, 0x9c, 0xa2,
+	0x1e, 0x1c, 0xa9, 0x7c, 0x82, 0xb6, 0x0b, 0x63, 0x22, 0x24, 0x95, 0xb7, 0x3b, 0xf2, 0x22, 0xcf,
+	0xb3, 0x44, 0xed, 0xa8, 0xf6, 0x84, 0xf8, 0x16, 0xfb, 0x0a, 0x7c, 0x1b, 0x8e, 0x3b, 0x72, 0x03,
+	0xb5, 0x5f, 0x04, 0x25, 0x5e, 0x25, 0x24, 0xd8, 0xed, 0xbd, 0x97, 0xdf, 0xef, 0xc5, 0xef, 0x0f,
+	0xaf, 0x78, 0xa7, 0x0a, 0xf7, 0xad, 0x13, 0xb6, 0x68, 0x6f, 0xb9, 0x96, 0xc2, 0x37, 0xb4, 0xdb,
+	0x18, 0x67, 0x30, 0x36, 0xda, 0x58, 0xda, 0x1a, 0x7d, 0xa3, 0x24, 0xf5, 0xdf, 0x93, 0x53, 0x69,
+	0x8c, 0xfc, 0x22, 0x8a, 0x81, 0xb8, 0xbe, 0xbb, 0x29, 0x9c, 0x5a, 0x0b, 0xeb, 0xf8, 0xba, 0xf3,
+	0x52, 0xf2, 0x42, 0x1a, 0x69, 0x86, 0xb2, 0xe8, 0x2b, 0x3f, 0x7d, 0xfb, 0x7d, 0x0c, 0xd1, 0xa5,
+	0xe3, 0xee, 0xce, 0xe2, 0x02, 0xc2, 0xee, 0x96, 0x5b, 0x41, 0x50, 0x86, 0xf2, 0xe7, 0xb3, 0x97,
+	0xf4, 0xdf, 0xbf, 0xd0, 0x55, 0x0f, 0x30, 0xcf, 0xf5, 0x82, 0x75, 0xdc, 0x09, 0x32, 0x7e, 0x5a,
+	0xe8, 0x77, 0x0b, 0xe6, 0x39, 0x3c, 0x83, 0x68, 0x23, 0xb8, 0x35, 0x9a, 0x1c, 0x0c, 0x46, 0xf2,
+	0x3f, 0x83, 0x0d, 0x04, 0x7b, 0x24, 0x31, 0x81, 0xc3, 0xb5, 0xb0, 0x96, 0x4b, 0x41, 0x82, 0x0c,
+	0xe5, 0xc7, 0x6c, 0xdf, 0xe2, 0x0c, 0x26, 0x4a, 0xb7, 0x7c, 0xa3, 0xb9, 0x53, 0x46, 0x93, 0x30,
+	0x43, 0x79, 0xc0, 0xfe, 0x1e, 0xe1, 0x12, 0x9e, 0x7d, 0xe5, 0xca, 0x29, 0x2d, 0x3f, 0x5b, 0xa5,
+	0x5b, 0x41, 0xa2, 0x0c, 0xe5, 0x93, 0x59, 0x42, 0x7d, 0x56, 0x74, 0x9f, 0x15, 0xbd, 0xda, 0x67,
+	0xb5, 0x08, 0xee, 0x7f, 0x9d, 0x22, 0x76, 0xf2, 0xa8, 0x5d, 0xf6, 0xd6, 0xf4, 0x0d, 0x84, 0xc3,
+	0xdd, 0x18, 0x20, 0x5a, 0x7e, 0x98, 0xd7, 0xe7, 0x65, 0x3c, 0xc2, 0x27, 0x70, 0xc4, 0x9a, 0xaa,
+	0x5a, 0xcc, 0x97, 0x1f, 0x63, 0x34, 0xa5, 0x10, 0x0e, 0x97, 0xe2, 0x09, 0x1c, 0xae, 0xca, 0xfa,
+	0xec, 0xa2, 0x3e, 0xf7, 0xcc, 0xb2, 0xf9, 0xb4, 0xaa, 0xca, 0xab, 0x32, 0x1e, 0xf7, 0xf6, 0xfb,
+	0xf9, 0x45, 0x55, 0x9e, 0xc5, 0x07, 0xd3, 0xd7, 0x10, 0xf9, 0x3b, 0xf1, 0x11, 0x04, 0x75, 0x53,
+	0xf7, 0x1b, 0x8f, 0x21, 0x2c, 0x19, 0x6b, 0x58, 0x8c, 0x16, 0xe4, 0xc7, 0x36, 0x45, 0x0f, 0xdb,
+	0x14, 0xfd, 0xde, 0xa6, 0xe8, 0x7e, 0x97, 0x8e, 0x1e, 0x76, 0xe9, 0xe8, 0xe7, 0x2e, 0x1d, 0x5d,
+	0x47, 0xc3, 0x9b, 0xdf, 0xfd, 0x19, 0x00, 0x6d, 0x45, 0xa2, 0x75, 0x21, 0x02, 0x00, 0x00,
 }
 
 func (m *Status) Marshal() (dAtA []byte, err error) {
@@ -245,6 +263,16 @@ func (m *Status) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.WaitingSince != nil {
+		n1, err1 := github_com_gogo_protobuf_types.StdTimeMarshalTo(*m.WaitingSince, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(*m.WaitingSince):])
+		if err1 != nil {
+			return 0, err1
+		}
+		i -= n1
+		i = encodeVarintTypes(dAtA, i, uint64(n1))
+		i--
+		dAtA[i] = 0x32
+	}
 	if m.Incarnation != 0 {
 		i = encodeVarintTypes(dAtA, i, uint64(m.Incarnation))
 		i--
@@ -308,6 +336,10 @@ func (m *Status) Size() (n int) {
 	if m.Incarnation != 0 {
 		n += 1 + sovTypes(uint64(m.Incarnation))
 	}
+	if m.WaitingSince != nil {
+		l = github_com_gogo_protobuf_types.SizeOfStdTime(*m.WaitingSince)
+		n += 1 + l + sovTypes(uint64(l))
+	}
 	return n
 }
 
@@ -454,6 +486,42 @@ func (m *Status) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WaitingSince", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTypes
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTypes
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTypes
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.WaitingSince == nil {
+				m.WaitingSince = new(time.Time)
+			}
+			if err := github_com_gogo_protobuf_types.StdTimeUnmarshal(m.WaitingSince, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTypes(dAtA[iNdEx:])
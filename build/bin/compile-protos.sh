@@ -7,7 +7,7 @@ protoc -I=$proto_imports --doc_out=docs/api  --doc_opt=markdown,admin.md  --gogo
 protoc -I=$proto_imports --doc_out=docs/api  --doc_opt=markdown,diags.md --gogo_out=Mgoogle/protobuf/timestamp.proto=github.com/gogo/protobuf/types,Mconfig/admin/admin.proto=github.com/onosproject/onos-config/api/admin,import_path=github.com/onosproject/onos-config/api/diags,plugins=grpc:. api/diags/*.proto
 
 protoc -I=$proto_imports --doc_out=docs/api  --doc_opt=markdown,types_change.md --gogofaster_out=Mgoogle/protobuf/timestamp.proto=github.com/gogo/protobuf/types,import_path=github.com/onosproject/onos-config/api/types/change,plugins=grpc:. api/types/change/*.proto
-protoc -I=$proto_imports --doc_out=docs/api  --doc_opt=markdown,types_change_network.md --gogofaster_out=Mgoogle/protobuf/timestamp.proto=github.com/gogo/protobuf/types,import_path=github.com/onosproject/onos-config/api/types/change/network,plugins=grpc:. api/types/change/network/*.proto
+protoc -I=$proto_imports --doc_out=docs/api  --doc_opt=markdown,types_change_network.md --gogofaster_out=Mgoogle/protobuf/timestamp.proto=github.com/gogo/protobuf/types,Mgoogle/protobuf/duration.proto=github.com/gogo/protobuf/types,import_path=github.com/onosproject/onos-config/api/types/change/network,plugins=grpc:. api/types/change/network/*.proto
 protoc -I=$proto_imports --doc_out=docs/api  --doc_opt=markdown,types_change_device.md --gogofaster_out=Mgoogle/protobuf/timestamp.proto=github.com/gogo/protobuf/types,import_path=github.com/onosproject/onos-config/api/types/change/device,plugins=grpc:. api/types/change/device/*.proto
 protoc -I=$proto_imports --doc_out=docs/api  --doc_opt=markdown,types_snapshot.md --gogofaster_out=Mgoogle/protobuf/duration.proto=github.com/gogo/protobuf/types,import_path=github.com/onosproject/onos-config/api/types/snapshot,plugins=grpc:. api/types/snapshot/*.proto
 protoc -I=$proto_imports --doc_out=docs/api  --doc_opt=markdown,types_snapshot_network.md --gogofaster_out=Mgoogle/protobuf/timestamp.proto=github.com/gogo/protobuf/types,import_path=github.com/onosproject/onos-config/api/types/snapshot/network,plugins=grpc:. api/types/snapshot/network/*.proto
@@ -30,6 +30,24 @@ Arguments
 
 -certPath <the location of a client certificate>
 
+-subscribeQueueSize <the number of responses a streaming subscription holds for a slow client>
+
+-subscribeOverflowPolicy <drop-oldest, coalesce or disconnect - what a subscription does when its queue is full>
+
+-configReplayMode <none, merge or replace - how the stored configuration is given to a device when it connects>
+
+-driftAuditInterval <how often the configuration of connected devices is audited for drift - never if 0>
+
+-remediateDevice (repeated) <a device whose configuration drift is remediated automatically>
+
+-remediateDeviceType (repeated) <a type of device whose configuration drift is remediated automatically>
+
+-remediationExclude (repeated) <a path, which may contain wildcards, that drift remediation leaves as it is>
+
+-remediateRemoveUnmanaged <remove the paths on a device that are not stored when remediating its drift>
+
+-metricsPort <the port the Prometheus metrics are served on at /metrics - not served if 0>
+
 
 See ../../docs/run.md for how to run the application.
 */
@@ -38,6 +56,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"time"
 
@@ -49,6 +68,7 @@ import (
 	"github.com/onosproject/onos-config/pkg/northbound/admin"
 	"github.com/onosproject/onos-config/pkg/northbound/diags"
 	"github.com/onosproject/onos-config/pkg/northbound/gnmi"
+	"github.com/onosproject/onos-config/pkg/southbound/synchronizer"
 	"github.com/onosproject/onos-config/pkg/store/change/device"
 	"github.com/onosproject/onos-config/pkg/store/change/device/state"
 	"github.com/onosproject/onos-config/pkg/store/change/network"
@@ -61,6 +81,7 @@ import (
 	"github.com/onosproject/onos-lib-go/pkg/certs"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
 	"github.com/onosproject/onos-lib-go/pkg/northbound"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type arrayFlags []string
@@ -88,12 +109,24 @@ var ClusterFactory = func(configuration config.Config) (cluster.Cluster, error)
 // The main entry point
 func main() {
 	var modelPlugins arrayFlags
+	var remediateDevices arrayFlags
+	var remediateDeviceTypes arrayFlags
+	var remediationExcludes arrayFlags
 	allowUnvalidatedConfig := flag.Bool("allowUnvalidatedConfig", false, "allow configuration for devices without a corresponding model plugin")
 	flag.Var(&modelPlugins, "modelPlugin", "names of model plugins to load (repeated)")
 	caPath := flag.String("caPath", "", "path to CA certificate")
 	keyPath := flag.String("keyPath", "", "path to client private key")
 	certPath := flag.String("certPath", "", "path to client certificate")
 	topoEndpoint := flag.String("topoEndpoint", "onos-topo:5150", "topology service endpoint")
+	subscribeQueueSize := flag.Int("subscribeQueueSize", gnmi.DefaultSubscribeQueueSize, "number of responses a streaming subscription holds for a slow client")
+	subscribeOverflowPolicy := flag.String("subscribeOverflowPolicy", string(gnmi.OverflowDropOldest), "what a streaming subscription does when its queue is full: drop-oldest, coalesce or disconnect")
+	configReplayMode := flag.String("configReplayMode", string(synchronizer.ReplayNone), "how the stored configuration is given to a device when it connects: none, merge or replace")
+	driftAuditInterval := flag.Duration("driftAuditInterval", 5*time.Minute, "how often the configuration of connected devices is audited for drift - never if 0")
+	flag.Var(&remediateDevices, "remediateDevice", "devices whose configuration drift is remediated automatically (repeated)")
+	flag.Var(&remediateDeviceTypes, "remediateDeviceType", "types of device whose configuration drift is remediated automatically (repeated)")
+	flag.Var(&remediationExcludes, "remediationExclude", "paths that drift remediation leaves as they are (repeated)")
+	remediateRemoveUnmanaged := flag.Bool("remediateRemoveUnmanaged", false, "remove the paths on a device that are not stored when remediating its drift")
+	metricsPort := flag.Int("metricsPort", 7070, "port the Prometheus metrics are served on at /metrics - not served if 0")
 	//This flag is used in logging.init()
 	flag.Bool("debug", false, "enable debug logging")
 	flag.Parse()
@@ -105,6 +138,25 @@ func main() {
 		log.Fatal(err)
 	}
 
+	overflowPolicy, err := gnmi.ParseOverflowPolicy(*subscribeOverflowPolicy)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	replayMode, err := synchronizer.ParseReplayMode(*configReplayMode)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var remediationPolicy *manager.RemediationPolicy
+	if len(remediateDevices) > 0 || len(remediateDeviceTypes) > 0 {
+		remediationPolicy, err = manager.NewRemediationPolicy(remediateDevices, remediateDeviceTypes, remediationExcludes,
+			*remediateRemoveUnmanaged)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	configuration, err := config.GetConfig()
 	if err != nil {
 		fmt.Println(err)
@@ -166,6 +218,9 @@ func main() {
 	mgr := manager.NewManager(leadershipStore, mastershipStore, deviceChangesStore,
 		deviceStateStore, deviceStore, deviceCache, networkChangesStore, networkSnapshotStore,
 		deviceSnapshotStore, *allowUnvalidatedConfig)
+	mgr.ConfigReplayMode = replayMode
+	mgr.DriftAuditInterval = *driftAuditInterval
+	mgr.RemediationPolicy = remediationPolicy
 	log.Info("Manager created")
 
 	defer func() {
@@ -185,21 +240,37 @@ func main() {
 	}
 
 	mgr.Run()
-	err = startServer(*caPath, *keyPath, *certPath)
+	if *metricsPort != 0 {
+		go startMetricsServer(*metricsPort)
+	}
+	err = startServer(*caPath, *keyPath, *certPath, gnmi.Service{
+		SubscribeQueueSize:      *subscribeQueueSize,
+		SubscribeOverflowPolicy: overflowPolicy,
+	})
 	if err != nil {
 		log.Fatal("Unable to start onos-config ", err)
 	}
 }
 
 // Creates gRPC server and registers various services; then serves.
-func startServer(caPath string, keyPath string, certPath string) error {
+func startServer(caPath string, keyPath string, certPath string, gnmiService gnmi.Service) error {
 	s := northbound.NewServer(northbound.NewServerCfg(caPath, keyPath, certPath, 5150, true, northbound.SecurityConfig{}))
 	s.AddService(admin.Service{})
 	s.AddService(diags.Service{})
-	s.AddService(gnmi.Service{})
+	s.AddService(gnmiService)
 	s.AddService(logging.Service{})
 
 	return s.Serve(func(started string) {
 		log.Info("Started NBI on ", started)
 	})
 }
+
+// Serves the Prometheus metrics over HTTP
+func startMetricsServer(port int) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	log.Infof("Serving metrics on port %d", port)
+	if err := http.ListenAndServe(fmt.Sprintf(":%d", port), mux); err != nil {
+		log.Error("Unable to serve metrics ", err)
+	}
+}
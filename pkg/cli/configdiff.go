@@ -0,0 +1,88 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"github.com/onosproject/onos-config/api/diags"
+	devicechange "github.com/onosproject/onos-config/api/types/change/device"
+	devicetype "github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"github.com/spf13/cobra"
+	"text/template"
+)
+
+const configDiffHeader = "\tPATH                                              |TYPE    |OLD VALUE                               |NEW VALUE                               |\n"
+
+const pathDiffFormat = "\t{{wrappath .Path 50 1| printf \"|%-50s|\"}}{{printf \"%-8s|\" .Type}}" +
+	"{{difftostring .OldValue | printf \"%-40s|\"}}{{difftostring .NewValue | printf \"%-40s|\"}}\n"
+
+const configDiffTemplate = "{{range .Devices}}" + deviceIDFormat + "\n" +
+	"{{range .Diffs}}" + pathDiffFormat + "{{end}}\n" +
+	"{{end}}"
+
+func getGetConfigDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <from change> <to change> [deviceId]",
+		Short: "Show the configuration differences between two network changes",
+		Long: "Show the leaves added, removed and modified in the configuration of each device between two network changes.\n" +
+			"A network change is given by its ID or its index",
+		Args: cobra.RangeArgs(2, 3),
+		RunE: runGetConfigDiffCommand,
+	}
+	cmd.Flags().Bool("no-headers", false, "disables output headers")
+	return cmd
+}
+
+func runGetConfigDiffCommand(cmd *cobra.Command, args []string) error {
+	noHeaders, _ := cmd.Flags().GetBool("no-headers")
+	diffReq := diags.ConfigDiffRequest{
+		FromChange: args[0],
+		ToChange:   args[1],
+	}
+	if len(args) > 2 {
+		diffReq.DeviceID = devicetype.ID(args[2])
+	}
+
+	clientConnection, clientConnectionError := cli.GetConnection(cmd)
+	if clientConnectionError != nil {
+		return clientConnectionError
+	}
+	client := diags.CreateChangeServiceClient(clientConnection)
+
+	response, err := client.GetConfigDiff(context.Background(), &diffReq)
+	if err != nil {
+		return err
+	}
+
+	funcMapDiff := template.FuncMap{
+		"wrappath":     wrapPath,
+		"difftostring": diffValueToString,
+	}
+	tmplDiff, _ := template.New("diff").Funcs(funcMapDiff).Parse(configDiffTemplate)
+	if !noHeaders {
+		cli.GetOutput().Write([]byte(configDiffHeader))
+	}
+	return tmplDiff.Execute(cli.GetOutput(), response)
+}
+
+// diffValueToString formats the type and the value of one side of a difference, which is
+// not given for added or removed leaves
+func diffValueToString(value *devicechange.TypedValue) string {
+	if value == nil {
+		return "-"
+	}
+	return "(" + value.Type.String() + ") " + value.ValueToString()
+}
@@ -0,0 +1,60 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Unit tests for get diff CLI
+package cli
+
+import (
+	"bytes"
+	"github.com/onosproject/onos-config/api/diags"
+	devicechange "github.com/onosproject/onos-config/api/types/change/device"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"gotest.tools/assert"
+	"strings"
+	"testing"
+)
+
+func Test_GetConfigDiff(t *testing.T) {
+	outputBuffer := bytes.NewBufferString("")
+	cli.CaptureOutput(outputBuffer)
+
+	setUpMockClients(MockClientsConfig{
+		configDiffResponse: &diags.ConfigDiffResponse{
+			Devices: []*diags.DeviceConfigDiff{
+				{
+					DeviceID:      "device-1",
+					DeviceVersion: "1.0.0",
+					Diffs: []*diags.PathDiff{
+						{Path: "/aa/bb/cc", Type: diags.Type_ADDED, NewValue: devicechange.NewTypedValueString("Test1")},
+						{Path: "/aa/bb/dd", Type: diags.Type_UPDATED, OldValue: devicechange.NewTypedValueString("Test2"),
+							NewValue: devicechange.NewTypedValueString("Test3")},
+						{Path: "/aa/bb/ee", Type: diags.Type_REMOVED, OldValue: devicechange.NewTypedValueString("Test4")},
+					},
+				},
+			},
+		},
+	})
+
+	diffCmd := getGetConfigDiffCommand()
+	err := diffCmd.RunE(diffCmd, []string{"change-1", "change-2"})
+	assert.NilError(t, err)
+	output := outputBuffer.String()
+	assert.Assert(t, strings.Contains(output, "Device: device-1 (1.0.0)"))
+	assert.Assert(t, strings.Contains(output, "ADDED"))
+	assert.Assert(t, strings.Contains(output, "(STRING) Test1"))
+	assert.Assert(t, strings.Contains(output, "(STRING) Test2"))
+	assert.Assert(t, strings.Contains(output, "(STRING) Test3"))
+	assert.Assert(t, strings.Contains(output, "REMOVED"))
+	assert.Equal(t, strings.Count(output, "/aa/bb/"), 3)
+}
@@ -21,7 +21,7 @@ import (
 
 func getGetCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "get {device-changes,network-changes,plugins,opstate,snapshots} [args]",
+		Use:   "get {device-changes,network-changes,plugins,opstate,snapshots,diff,drift} [args]",
 		Short: "Get config resources",
 	}
 	cmd.AddCommand(getListNetworkChangesCommand())
@@ -29,6 +29,8 @@ func getGetCommand() *cobra.Command {
 	cmd.AddCommand(getGetPluginsCommand())
 	cmd.AddCommand(getGetOpstateCommand())
 	cmd.AddCommand(getListSnapshotsCommand())
+	cmd.AddCommand(getGetConfigDiffCommand())
+	cmd.AddCommand(getGetDriftCommand())
 	return cmd
 }
 
@@ -49,6 +51,7 @@ func getWatchCommand() *cobra.Command {
 	cmd.AddCommand(getWatchDeviceChangesCommand())
 	cmd.AddCommand(getWatchNetworkChangesCommand())
 	cmd.AddCommand(getWatchOpstateCommand())
+	cmd.AddCommand(getWatchDriftCommand())
 	cmd.AddCommand(getWatchSnapshotsCommand())
 	return cmd
 }
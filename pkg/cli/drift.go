@@ -0,0 +1,115 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/onosproject/onos-config/api/diags"
+	devicetype "github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"github.com/spf13/cobra"
+	"io"
+	"text/template"
+)
+
+const driftHeader = "\tPATH                                              |INTENDED VALUE                          |ACTUAL VALUE                            |\n"
+
+const pathDriftFormat = "\t{{wrappath .Path 50 1| printf \"|%-50s|\"}}" +
+	"{{difftostring .IntendedValue | printf \"%-40s|\"}}{{difftostring .ActualValue | printf \"%-40s|\"}}\n"
+
+// unmanagedFormat shows a path on the device that onos-config does not hold, which is not drift
+const unmanagedFormat = "\t{{wrappath .Path 50 1| printf \"|%-50s|\"}}" +
+	"{{printf \"%-40s|\" \"(not managed)\"}}{{difftostring .Value | printf \"%-40s|\"}}\n"
+
+const driftTemplate = deviceIDFormat + " audited {{.Audited.Format \"2006-01-02T15:04:05Z07:00\"}}\n" +
+	"{{range .Drifts}}" + pathDriftFormat + "{{else}}\tNo drift\n{{end}}" +
+	"{{range .Unmanaged}}" + unmanagedFormat + "{{end}}"
+
+func getGetDriftCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drift <deviceId>",
+		Short: "Audit a device for configuration drift",
+		Long: "Show the paths whose value on the device differs from the configuration intended for it,\n" +
+			"followed by the paths on the device that onos-config does not hold.\n" +
+			"The device is audited now, unless --last is given",
+		Args: cobra.ExactArgs(1),
+		RunE: runGetDriftCommand,
+	}
+	cmd.Flags().Bool("last", false, "show the outcome of the latest audit rather than audit now")
+	cmd.Flags().Bool("no-headers", false, "disables output headers")
+	return cmd
+}
+
+func getWatchDriftCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "drift <deviceId>",
+		Short: "Watch the configuration drift of a device",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runWatchDriftCommand,
+	}
+	cmd.Flags().Bool("no-headers", false, "disables output headers")
+	return cmd
+}
+
+func runGetDriftCommand(cmd *cobra.Command, args []string) error {
+	last, _ := cmd.Flags().GetBool("last")
+	return driftCommand(cmd, &diags.DriftRequest{
+		DeviceID: devicetype.ID(args[0]),
+		Audit:    !last,
+	})
+}
+
+func runWatchDriftCommand(cmd *cobra.Command, args []string) error {
+	return driftCommand(cmd, &diags.DriftRequest{
+		DeviceID:  devicetype.ID(args[0]),
+		Subscribe: true,
+	})
+}
+
+func driftCommand(cmd *cobra.Command, request *diags.DriftRequest) error {
+	noHeaders, _ := cmd.Flags().GetBool("no-headers")
+	clientConnection, clientConnectionError := cli.GetConnection(cmd)
+	if clientConnectionError != nil {
+		return clientConnectionError
+	}
+	client := diags.CreateChangeServiceClient(clientConnection)
+
+	stream, err := client.GetDrift(context.Background(), request)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+
+	funcMapDrift := template.FuncMap{
+		"wrappath":     wrapPath,
+		"difftostring": diffValueToString,
+	}
+	tmplDrift, _ := template.New("drift").Funcs(funcMapDrift).Parse(driftTemplate)
+	if !noHeaders {
+		cli.GetOutput().Write([]byte(driftHeader))
+	}
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tmplDrift.Execute(cli.GetOutput(), in); err != nil {
+			return err
+		}
+	}
+}
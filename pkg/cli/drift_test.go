@@ -0,0 +1,76 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Unit tests for get drift CLI
+package cli
+
+import (
+	"bytes"
+	"github.com/onosproject/onos-config/api/diags"
+	devicechange "github.com/onosproject/onos-config/api/types/change/device"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"gotest.tools/assert"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_GetDrift(t *testing.T) {
+	outputBuffer := bytes.NewBufferString("")
+	cli.CaptureOutput(outputBuffer)
+
+	responses := []*diags.DriftResponse{
+		{
+			DeviceID:      "device-1",
+			DeviceVersion: "1.0.0",
+			Audited:       time.Date(2020, 4, 1, 10, 30, 0, 0, time.UTC),
+			Drifts: []*diags.PathDrift{
+				{Path: "/aa/bb/cc", IntendedValue: devicechange.NewTypedValueString("Test1"),
+					ActualValue: devicechange.NewTypedValueString("Test2")},
+				{Path: "/aa/bb/dd", IntendedValue: devicechange.NewTypedValueString("Test3")},
+				{Path: "/aa/bb/ee", ActualValue: devicechange.NewTypedValueString("Test4")},
+			},
+		},
+		{
+			DeviceID:      "device-1",
+			DeviceVersion: "1.0.0",
+			Audited:       time.Date(2020, 4, 1, 10, 35, 0, 0, time.UTC),
+		},
+	}
+	setUpMockClients(MockClientsConfig{
+		driftClient: &MockChangeServiceGetDriftClient{
+			recvFn: func() (*diags.DriftResponse, error) {
+				if len(responses) == 0 {
+					return nil, io.EOF
+				}
+				response := responses[0]
+				responses = responses[1:]
+				return response, nil
+			},
+		},
+	})
+
+	driftCmd := getWatchDriftCommand()
+	err := driftCmd.RunE(driftCmd, []string{"device-1"})
+	assert.NilError(t, err)
+	output := outputBuffer.String()
+	assert.Assert(t, strings.Contains(output, "Device: device-1 (1.0.0) audited 2020-04-01T10:30:00Z"))
+	assert.Assert(t, strings.Contains(output, "(STRING) Test1"))
+	assert.Assert(t, strings.Contains(output, "(STRING) Test2"))
+	assert.Assert(t, strings.Contains(output, "(STRING) Test3"))
+	assert.Assert(t, strings.Contains(output, "(STRING) Test4"))
+	assert.Equal(t, strings.Count(output, "/aa/bb/"), 3)
+	assert.Assert(t, strings.Contains(output, "Device: device-1 (1.0.0) audited 2020-04-01T10:35:00Z\n\tNo drift"))
+}
@@ -29,6 +29,8 @@ type MockClientsConfig struct {
 	opstateClient            *MockOpStateDiagsGetOpStateClient
 	listDeviceChangesClient  *MockChangeServiceListDeviceChangesClient
 	listNetworkChangesClient *MockChangeServiceListNetworkChangesClient
+	configDiffResponse       *diags.ConfigDiffResponse
+	driftClient              *MockChangeServiceGetDriftClient
 }
 
 // mockConfigAdminServiceClient is the mock for the ConfigAdminServiceClient
@@ -246,6 +248,8 @@ func (c MockChangeServiceListNetworkChangesClient) RecvMsg(m interface{}) error
 type mockChangeServiceClient struct {
 	getChangeServiceClientDeviceChanges  diags.ChangeService_ListDeviceChangesClient
 	getChangeServiceClientNetworkChanges diags.ChangeService_ListNetworkChangesClient
+	configDiffResponse                   *diags.ConfigDiffResponse
+	getDriftClient                       diags.ChangeService_GetDriftClient
 }
 
 func (m mockChangeServiceClient) ListNetworkChanges(ctx context.Context, in *diags.ListNetworkChangeRequest, opts ...grpc.CallOption) (diags.ChangeService_ListNetworkChangesClient, error) {
@@ -256,6 +260,54 @@ func (m mockChangeServiceClient) ListDeviceChanges(ctx context.Context, in *diag
 	return m.getChangeServiceClientDeviceChanges, nil
 }
 
+func (m mockChangeServiceClient) GetConfigDiff(ctx context.Context, in *diags.ConfigDiffRequest, opts ...grpc.CallOption) (*diags.ConfigDiffResponse, error) {
+	return m.configDiffResponse, nil
+}
+
+func (m mockChangeServiceClient) GetDrift(ctx context.Context, in *diags.DriftRequest, opts ...grpc.CallOption) (diags.ChangeService_GetDriftClient, error) {
+	return m.getDriftClient, nil
+}
+
+// MockChangeServiceGetDriftClient is a mock of the ChangeService_GetDriftClient
+// Function pointers are used to allow mocking specific APIs
+type MockChangeServiceGetDriftClient struct {
+	recvFn      func() (*diags.DriftResponse, error)
+	headerFn    func() (metadata.MD, error)
+	trailerFn   func() metadata.MD
+	closeSendFn func() error
+	contextFn   func() context.Context
+	sendMsgFn   func(interface{}) error
+	recvMsgFn   func(interface{}) error
+}
+
+func (c MockChangeServiceGetDriftClient) Recv() (*diags.DriftResponse, error) {
+	return c.recvFn()
+}
+
+func (c MockChangeServiceGetDriftClient) Header() (metadata.MD, error) {
+	return c.headerFn()
+}
+
+func (c MockChangeServiceGetDriftClient) Trailer() metadata.MD {
+	return c.trailerFn()
+}
+
+func (c MockChangeServiceGetDriftClient) CloseSend() error {
+	return c.closeSendFn()
+}
+
+func (c MockChangeServiceGetDriftClient) Context() context.Context {
+	return c.contextFn()
+}
+
+func (c MockChangeServiceGetDriftClient) SendMsg(m interface{}) error {
+	return c.sendMsgFn(m)
+}
+
+func (c MockChangeServiceGetDriftClient) RecvMsg(m interface{}) error {
+	return c.recvMsgFn(m)
+}
+
 // setUpMockClients sets up factories to create mocks of top level clients used by the CLI
 func setUpMockClients(config MockClientsConfig) {
 	admin.ConfigAdminClientFactory = func(cc *grpc.ClientConn) admin.ConfigAdminServiceClient {
@@ -274,6 +326,8 @@ func setUpMockClients(config MockClientsConfig) {
 		return mockChangeServiceClient{
 			getChangeServiceClientDeviceChanges:  config.listDeviceChangesClient,
 			getChangeServiceClientNetworkChanges: config.listNetworkChangesClient,
+			configDiffResponse:                   config.configDiffResponse,
+			getDriftClient:                       config.driftClient,
 		}
 	}
 }
@@ -91,7 +91,7 @@ func (r *Reconciler) Reconcile(id types.ID) (controller.Result, error) {
 	device, err := r.devices.Get(topodevice.ID(change.Change.DeviceID))
 	if err != nil {
 		return controller.Result{}, err
-	} else if getProtocolState(device) != topodevice.ChannelState_CONNECTED {
+	} else if !isConnected(device) {
 		// If the device is not available, fail the change
 		change.Status.State = changetypes.State_FAILED
 		change.Status.Reason = changetypes.Reason_ERROR
@@ -206,6 +206,19 @@ func getProtocolState(device *topodevice.Device) topodevice.ChannelState {
 	return protocol.ChannelState
 }
 
+// isConnected indicates whether the gNMI channel of the device is connected and ready for changes. A
+// connected device is not ready while its service is unavailable, as when the configuration stored
+// for it could not be replayed to it
+func isConnected(device *topodevice.Device) bool {
+	for _, p := range device.Protocols {
+		if p.Protocol == topodevice.Protocol_GNMI {
+			return p.ChannelState == topodevice.ChannelState_CONNECTED &&
+				p.ServiceState != topodevice.ServiceState_UNAVAILABLE
+		}
+	}
+	return false
+}
+
 // computeRollback returns a change containing the previous value for each path of the rollbackChange
 func (r *Reconciler) computeRollback(deviceChange *devicechange.DeviceChange) (*devicechange.Change, error) {
 	//TODO We might want to consider doing reverse iteration to get the previous value for a path instead of
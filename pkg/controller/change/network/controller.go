@@ -15,20 +15,25 @@
 package network
 
 import (
+	"fmt"
 	"github.com/onosproject/onos-config/api/types"
 	changetypes "github.com/onosproject/onos-config/api/types/change"
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
 	networkchange "github.com/onosproject/onos-config/api/types/change/network"
+	devicetype "github.com/onosproject/onos-config/api/types/device"
 	"github.com/onosproject/onos-config/pkg/controller"
 	devicechangestore "github.com/onosproject/onos-config/pkg/store/change/device"
 	networkchangestore "github.com/onosproject/onos-config/pkg/store/change/network"
 	devicestore "github.com/onosproject/onos-config/pkg/store/device"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	leadershipstore "github.com/onosproject/onos-config/pkg/store/leadership"
+	"github.com/onosproject/onos-config/pkg/utils"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
 	devicetopo "github.com/onosproject/onos-topo/api/device"
+	"github.com/openconfig/gnmi/proto/gnmi"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"time"
 )
 
 var log = logging.GetLogger("controller", "change", "network")
@@ -47,14 +52,26 @@ func NewController(leadership leadershipstore.Store, deviceCache cache.Cache, de
 		DeviceStore: devices,
 		ChangeStore: deviceChanges,
 	})
-	c.Reconcile(&Reconciler{
+	reconciler := &Reconciler{
 		networkChanges: networkChanges,
 		deviceChanges:  deviceChanges,
 		devices:        devices,
-	})
+	}
+	c.Retry(RetryPolicy)
+	c.OnFailure(reconciler)
+	c.Reconcile(reconciler)
 	return c
 }
 
+// RetryPolicy is the retry policy of the network change controller. A change whose reconciliation
+// keeps failing is given up and failed, rather than blocking the changes that follow it
+var RetryPolicy = controller.RetryPolicy{
+	InitialDelay: 10 * time.Millisecond,
+	MaxDelay:     5 * time.Second,
+	Jitter:       0.2,
+	MaxAttempts:  20,
+}
+
 // Reconciler is a config reconciler
 type Reconciler struct {
 	networkChanges networkchangestore.Store
@@ -84,6 +101,42 @@ func (r *Reconciler) Reconcile(id types.ID) (controller.Result, error) {
 	return controller.Result{}, nil
 }
 
+// Fail gives up the pending change or rollback the controller has given up reconciling. A change
+// that has been applied to its devices is rolled back, so that none of its device changes are left
+// to be applied. A change that has not been applied yet, or a rollback, is failed
+func (r *Reconciler) Fail(id types.ID, err error) {
+	change, getErr := r.networkChanges.Get(networkchange.ID(id))
+	if getErr != nil {
+		log.Errorf("Could not get NetworkChange %s to fail it %v", id, getErr)
+		return
+	} else if change == nil || change.Status.State != changetypes.State_PENDING {
+		return
+	}
+
+	change.Status.Reason = changetypes.Reason_ERROR
+	change.Status.Message = fmt.Sprintf("Reconciliation gave up: %v", err)
+	if change.Status.Phase == changetypes.Phase_CHANGE && change.Status.Incarnation > 0 && hasDeviceChanges(change) {
+		// Roll back the device changes of the current incarnation, skipping those that were not started
+		deviceChanges, getErr := r.getDeviceChanges(change)
+		if getErr != nil {
+			log.Errorf("Could not get the DeviceChanges of NetworkChange %s to roll them back %v", id, getErr)
+			return
+		}
+		if _, rollbackErr := r.ensureDeviceChangeRollbacks(change, deviceChanges); rollbackErr != nil {
+			log.Errorf("Could not roll back the DeviceChanges of NetworkChange %s %v", id, rollbackErr)
+			return
+		}
+		change.Status.Phase = changetypes.Phase_ROLLBACK
+		log.Warnf("Rolling back NetworkChange %v", change)
+	} else {
+		change.Status.State = changetypes.State_FAILED
+		log.Warnf("Failing NetworkChange %v", change)
+	}
+	if updateErr := r.networkChanges.Update(change); updateErr != nil {
+		log.Errorf("Could not give up NetworkChange %s %v", id, updateErr)
+	}
+}
+
 // reconcileChange reconciles a change in the CHANGE phase
 func (r *Reconciler) reconcileChange(change *networkchange.NetworkChange) (controller.Result, error) {
 	switch change.Status.State {
@@ -114,7 +167,7 @@ func (r *Reconciler) reconcilePendingChange(change *networkchange.NetworkChange)
 	}
 
 	// If the network change can be applied, apply it by incrementing the incarnation number
-	apply, err := r.canTryChange(change, deviceChanges)
+	apply, wait, err := r.canTryChange(change, deviceChanges)
 	if err != nil {
 		return controller.Result{}, err
 	} else if apply {
@@ -122,6 +175,7 @@ func (r *Reconciler) reconcilePendingChange(change *networkchange.NetworkChange)
 		change.Status.State = changetypes.State_PENDING
 		change.Status.Reason = changetypes.Reason_NONE
 		change.Status.Message = ""
+		change.Status.WaitingSince = nil
 		log.Infof("Applying NetworkChange %v", change)
 		if err := r.networkChanges.Update(change); err != nil {
 			return controller.Result{}, err
@@ -132,6 +186,7 @@ func (r *Reconciler) reconcilePendingChange(change *networkchange.NetworkChange)
 	// If all device changes are complete, complete the network change
 	if r.isDeviceChangesComplete(change, deviceChanges) {
 		change.Status.State = changetypes.State_COMPLETE
+		change.Status.Message = ""
 		log.Infof("Completing NetworkChange %v", change)
 		if err := r.networkChanges.Update(change); err != nil {
 			return controller.Result{}, err
@@ -143,7 +198,9 @@ func (r *Reconciler) reconcilePendingChange(change *networkchange.NetworkChange)
 	if r.isDeviceChangesFailed(change, deviceChanges) {
 		return r.ensureDeviceChangeRollbacks(change, deviceChanges)
 	}
-	return controller.Result{}, nil
+
+	// A change waiting for its devices with a timeout is reconciled again once the timeout has passed
+	return controller.Result{RequeueAfter: wait}, nil
 }
 
 // reconcileCompleteChange reconciles a change in the COMPLETE state during the CHANGE phase
@@ -176,13 +233,14 @@ func hasDeviceChanges(change *networkchange.NetworkChange) bool {
 
 // createDeviceChanges creates device changes in sequential order
 func (r *Reconciler) createDeviceChanges(networkChange *networkchange.NetworkChange) (controller.Result, error) {
-	// If the previous network change has not created device changes, requeue to wait for changes to be propagated
+	// If the previous network change has not created device changes, back off to wait for changes to be propagated.
+	// A previous change that has been given up before creating them never will
 	// TODO devices changes should be written to stores by index to avoid having to manage index order
 	prevChange, err := r.networkChanges.GetByIndex(networkChange.Index - 1)
 	if err != nil {
 		return controller.Result{}, err
-	} else if prevChange != nil && !hasDeviceChanges(prevChange) {
-		return controller.Result{Requeue: types.ID(networkChange.ID)}, nil
+	} else if prevChange != nil && !hasDeviceChanges(prevChange) && prevChange.Status.State != changetypes.State_FAILED {
+		return controller.Result{Requeue: types.ID(networkChange.ID), Backoff: true}, nil
 	}
 
 	// Loop through changes and create device changes
@@ -213,38 +271,37 @@ func (r *Reconciler) createDeviceChanges(networkChange *networkchange.NetworkCha
 	return controller.Result{Requeue: types.ID(networkChange.ID)}, nil
 }
 
-// canTryChange returns a bool indicating whether the change can be attempted
-func (r *Reconciler) canTryChange(change *networkchange.NetworkChange, deviceChanges []*devicechange.DeviceChange) (bool, error) {
+// canTryChange returns a bool indicating whether the change can be attempted, and the delay before the change
+// is to be tried again when it is waiting for its devices with a timeout
+func (r *Reconciler) canTryChange(change *networkchange.NetworkChange, deviceChanges []*devicechange.DeviceChange) (bool, time.Duration, error) {
 	// If the incarnation number is positive, verify all device changes have been rolled back
 	if change.Status.Incarnation > 0 {
 		for _, deviceChange := range deviceChanges {
 			if deviceChange.Status.Incarnation != change.Status.Incarnation ||
 				deviceChange.Status.Phase != changetypes.Phase_ROLLBACK ||
 				deviceChange.Status.State != changetypes.State_COMPLETE {
-				return false, nil
+				return false, 0, nil
 			}
 		}
 	}
 
-	// First, check if the devices affected by the change are available
-	for _, deviceChange := range change.Changes {
-		device, err := r.devices.Get(devicetopo.ID(deviceChange.DeviceID))
-		if err != nil && status.Code(err) != codes.NotFound {
-			return false, err
-		} else if device == nil {
-			return false, nil
-		}
-		state := getProtocolState(device)
-		if state != devicetopo.ChannelState_CONNECTED {
-			log.Infof("Cannot apply NetworkChange %v: %v is offline", change.ID, deviceChange.DeviceID)
-			return false, nil
+	// First, check if the devices affected by the change are available, unless the change is applied
+	// to the reachable devices only
+	if change.OfflinePolicy != networkchange.OfflinePolicy_APPLY_REACHABLE {
+		offline, err := r.getOfflineDevices(change)
+		if err != nil {
+			return false, 0, err
+		} else if len(offline) > 0 {
+			log.Infof("Cannot apply NetworkChange %v: %v offline", change.ID, offline)
+			wait, err := r.waitForDevices(change, offline)
+			return false, wait, err
 		}
 	}
 
 	// If the devices are available, ensure the change does not intersect prior changes
 	prevChange, err := r.networkChanges.GetPrev(change.Index)
 	if err != nil {
-		return false, err
+		return false, 0, err
 	}
 
 	for prevChange != nil {
@@ -254,28 +311,99 @@ func (r *Reconciler) canTryChange(change *networkchange.NetworkChange, deviceCha
 			// If the change is in the ROLLBACK phase, verify it's complete but continue iterating
 			// back to the last CHANGE phase change
 			if prevChange.Status.Phase == changetypes.Phase_CHANGE {
-				return prevChange.Status.State != changetypes.State_PENDING, nil
+				return prevChange.Status.State != changetypes.State_PENDING, 0, nil
 			} else if prevChange.Status.Phase == changetypes.Phase_ROLLBACK {
 				if prevChange.Status.State == changetypes.State_PENDING {
-					return false, nil
+					return false, 0, nil
 				}
 			}
 		}
 
 		prevChange, err = r.networkChanges.GetPrev(prevChange.Index)
 		if err != nil {
-			return false, err
+			return false, 0, err
 		}
 	}
-	return true, nil
+	return true, 0, nil
+}
+
+// getOfflineDevices returns the devices of the change that are not connected
+func (r *Reconciler) getOfflineDevices(change *networkchange.NetworkChange) ([]devicetype.ID, error) {
+	offline := make([]devicetype.ID, 0)
+	for _, deviceChange := range change.Changes {
+		connected, err := r.isDeviceConnected(deviceChange.DeviceID)
+		if err != nil {
+			return nil, err
+		} else if !connected {
+			offline = append(offline, deviceChange.DeviceID)
+		}
+	}
+	return offline, nil
+}
+
+// isDeviceConnected indicates whether the device is known and connected
+func (r *Reconciler) isDeviceConnected(deviceID devicetype.ID) (bool, error) {
+	device, err := r.devices.Get(devicetopo.ID(deviceID))
+	if err != nil && status.Code(err) != codes.NotFound {
+		return false, err
+	}
+	return device != nil && isConnected(device), nil
+}
+
+// waitForDevices records that the change is waiting for its offline devices, and when it started waiting.
+// A change with the WAIT_TIMEOUT policy is failed once it has waited for its timeout, and the time
+// remaining until then is returned
+func (r *Reconciler) waitForDevices(change *networkchange.NetworkChange, offline []devicetype.ID) (time.Duration, error) {
+	message := fmt.Sprintf("Waiting for devices %v to be connected", offline)
+	if change.Status.WaitingSince == nil {
+		now := time.Now()
+		change.Status.WaitingSince = &now
+		change.Status.Message = message
+		if err := r.networkChanges.Update(change); err != nil {
+			return 0, err
+		}
+	}
+	var remaining time.Duration
+	if change.OfflinePolicy == networkchange.OfflinePolicy_WAIT_TIMEOUT {
+		remaining = change.OfflineTimeout - time.Since(*change.Status.WaitingSince)
+		if remaining <= 0 {
+			change.Status.State = changetypes.State_FAILED
+			change.Status.Reason = changetypes.Reason_ERROR
+			change.Status.Message = fmt.Sprintf("Devices %v not connected within %v", offline, change.OfflineTimeout)
+			log.Infof("Failing NetworkChange %v", change)
+			return 0, r.networkChanges.Update(change)
+		}
+	}
+	return remaining, r.updateStatusMessage(change, message)
+}
+
+// updateStatusMessage sets the status message of the change, unless it is already set
+func (r *Reconciler) updateStatusMessage(change *networkchange.NetworkChange, message string) error {
+	if change.Status.Message == message {
+		return nil
+	}
+	change.Status.Message = message
+	return r.networkChanges.Update(change)
 }
 
-// ensureDeviceChangesPending ensures device changes are pending
+// ensureDeviceChangesPending ensures device changes are pending. A change with the APPLY_REACHABLE
+// policy only starts the device changes of the connected devices - the others are started once
+// their device is connected
 func (r *Reconciler) ensureDeviceChangesPending(networkChange *networkchange.NetworkChange, changes []*devicechange.DeviceChange) (bool, error) {
 	// Ensure all device changes are being applied
 	updated := false
+	offline := make([]devicetype.ID, 0)
 	for _, deviceChange := range changes {
 		if deviceChange.Status.Incarnation < networkChange.Status.Incarnation {
+			if networkChange.OfflinePolicy == networkchange.OfflinePolicy_APPLY_REACHABLE {
+				connected, err := r.isDeviceConnected(deviceChange.Change.DeviceID)
+				if err != nil {
+					return false, err
+				} else if !connected {
+					offline = append(offline, deviceChange.Change.DeviceID)
+					continue
+				}
+			}
 			deviceChange.Status.Incarnation = networkChange.Status.Incarnation
 			deviceChange.Status.Phase = changetypes.Phase_CHANGE
 			deviceChange.Status.State = changetypes.State_PENDING
@@ -287,6 +415,10 @@ func (r *Reconciler) ensureDeviceChangesPending(networkChange *networkchange.Net
 			updated = true
 		}
 	}
+	if !updated && len(offline) > 0 {
+		log.Infof("NetworkChange %v applied to the reachable devices, %v offline", networkChange.ID, offline)
+		return false, r.updateStatusMessage(networkChange, fmt.Sprintf("Waiting for devices %v to be connected", offline))
+	}
 	return updated, nil
 }
 
@@ -325,10 +457,20 @@ func (r *Reconciler) isDeviceChangesFailed(networkChange *networkchange.NetworkC
 	return false
 }
 
-// ensureDeviceChangeRollbacks ensures device changes are being rolled back
+// ensureDeviceChangeRollbacks ensures device changes are being rolled back. A device change that
+// was not started for the current incarnation, as its device was offline, has nothing to roll back
 func (r *Reconciler) ensureDeviceChangeRollbacks(networkChange *networkchange.NetworkChange, changes []*devicechange.DeviceChange) (controller.Result, error) {
 	for _, deviceChange := range changes {
-		if deviceChange.Status.Incarnation != networkChange.Status.Incarnation ||
+		if deviceChange.Status.Incarnation < networkChange.Status.Incarnation &&
+			deviceChange.Status.Phase == changetypes.Phase_CHANGE {
+			deviceChange.Status.Incarnation = networkChange.Status.Incarnation
+			deviceChange.Status.Phase = changetypes.Phase_ROLLBACK
+			deviceChange.Status.State = changetypes.State_COMPLETE
+			log.Infof("Skipping rollback of DeviceChange %v", deviceChange)
+			if err := r.deviceChanges.Update(deviceChange); err != nil {
+				return controller.Result{}, err
+			}
+		} else if deviceChange.Status.Incarnation != networkChange.Status.Incarnation ||
 			deviceChange.Status.Phase != changetypes.Phase_ROLLBACK ||
 			deviceChange.Status.State == changetypes.State_FAILED {
 			deviceChange.Status.Incarnation = networkChange.Status.Incarnation
@@ -500,11 +642,14 @@ func (r *Reconciler) canTryRollback(change *networkchange.NetworkChange, deviceC
 	return true, nil
 }
 
-// isIntersectingChange indicates whether the changes from the two given NetworkChanges intersect
+// isIntersectingChange indicates whether the changes from the two given NetworkChanges intersect.
+// Changes intersect if they change overlapping paths of the same device, so that changes to
+// different subtrees of a device can be applied concurrently
 func isIntersectingChange(config *networkchange.NetworkChange, history *networkchange.NetworkChange) bool {
 	for _, configChange := range config.Changes {
 		for _, historyChange := range history.Changes {
-			if configChange.DeviceID == historyChange.DeviceID {
+			if configChange.DeviceID == historyChange.DeviceID &&
+				isIntersectingValues(configChange.Values, historyChange.Values) {
 				return true
 			}
 		}
@@ -512,19 +657,44 @@ func isIntersectingChange(config *networkchange.NetworkChange, history *networkc
 	return false
 }
 
-func getProtocolState(device *devicetopo.Device) devicetopo.ChannelState {
-	// Find the gNMI protocol state for the device
-	var protocol *devicetopo.ProtocolState
+// isIntersectingValues indicates whether any paths of the two sets of change values overlap. A path
+// that cannot be parsed is taken to overlap every path
+func isIntersectingValues(configValues []*devicechange.ChangeValue, historyValues []*devicechange.ChangeValue) bool {
+	historyPaths := make([]*gnmi.Path, 0, len(historyValues))
+	for _, historyValue := range historyValues {
+		historyPath, err := utils.ParseGNMIElements(utils.SplitPath(historyValue.Path))
+		if err != nil {
+			log.Warnf("Unable to parse path %s: %v", historyValue.Path, err)
+			return true
+		}
+		historyPaths = append(historyPaths, historyPath)
+	}
+	for _, configValue := range configValues {
+		configPath, err := utils.ParseGNMIElements(utils.SplitPath(configValue.Path))
+		if err != nil {
+			log.Warnf("Unable to parse path %s: %v", configValue.Path, err)
+			return true
+		}
+		for _, historyPath := range historyPaths {
+			if utils.PathsOverlap(configPath, historyPath) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isConnected indicates whether the gNMI channel of the device is connected and ready for changes. A
+// connected device is not ready while its service is unavailable, as when the configuration stored
+// for it could not be replayed to it
+func isConnected(device *devicetopo.Device) bool {
 	for _, p := range device.Protocols {
 		if p.Protocol == devicetopo.Protocol_GNMI {
-			protocol = p
-			break
+			return p.ChannelState == devicetopo.ChannelState_CONNECTED &&
+				p.ServiceState != devicetopo.ServiceState_UNAVAILABLE
 		}
 	}
-	if protocol == nil {
-		return devicetopo.ChannelState_UNKNOWN_CHANNEL_STATE
-	}
-	return protocol.ChannelState
+	return false
 }
 
 var _ controller.Reconciler = &Reconciler{}
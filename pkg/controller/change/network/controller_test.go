@@ -16,18 +16,22 @@ package network
 
 import (
 	"context"
+	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/onosproject/onos-config/api/types"
 	"github.com/onosproject/onos-config/api/types/change"
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
 	networkchange "github.com/onosproject/onos-config/api/types/change/network"
 	"github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-config/pkg/controller"
 	devicechanges "github.com/onosproject/onos-config/pkg/store/change/device"
 	networkchanges "github.com/onosproject/onos-config/pkg/store/change/network"
 	devicestore "github.com/onosproject/onos-config/pkg/store/device"
 	devicetopo "github.com/onosproject/onos-topo/api/device"
 	"github.com/stretchr/testify/assert"
+	"sync"
 	"testing"
+	"time"
 )
 
 const (
@@ -37,6 +41,7 @@ const (
 
 const (
 	change1 = networkchange.ID("change-1")
+	change2 = networkchange.ID("change-2")
 )
 
 // TestReconcilerChangeRollback tests applying and then rolling back a change
@@ -309,7 +314,353 @@ func TestReconcilerError(t *testing.T) {
 	assert.Equal(t, change.State_PENDING, networkChange.Status.State)
 }
 
+// TestReconcilerConcurrentPaths tests that a change waits for the pending changes to the same paths
+// of a device, but not for those to other paths
+func TestReconcilerConcurrentPaths(t *testing.T) {
+	networkChanges, deviceChanges, devices := newStores(t)
+	defer networkChanges.Close()
+	defer deviceChanges.Close()
+
+	reconciler := &Reconciler{
+		networkChanges: networkChanges,
+		deviceChanges:  deviceChanges,
+		devices:        devices,
+	}
+
+	pendingChange := newPathsChange(change1, device1, "/interfaces/interface[name=eth1]/config/mtu")
+	err := networkChanges.Create(pendingChange)
+	assert.NoError(t, err)
+
+	// A change to another interface of the device can be tried while the first is pending
+	otherInterface := newPathsChange(change2, device1, "/interfaces/interface[name=eth2]/config/mtu")
+	err = networkChanges.Create(otherInterface)
+	assert.NoError(t, err)
+	canTry, _, err := reconciler.canTryChange(otherInterface, nil)
+	assert.NoError(t, err)
+	assert.True(t, canTry)
+
+	// A change to the same interface has to wait
+	sameInterface := newPathsChange("change-3", device1, "/interfaces/interface[name=eth1]")
+	err = networkChanges.Create(sameInterface)
+	assert.NoError(t, err)
+	canTry, _, err = reconciler.canTryChange(sameInterface, nil)
+	assert.NoError(t, err)
+	assert.False(t, canTry)
+}
+
+// TestReconcilerBackoff tests that a change backs off until the previous change has created its device changes
+func TestReconcilerBackoff(t *testing.T) {
+	networkChanges, deviceChanges, devices := newStores(t)
+	defer networkChanges.Close()
+	defer deviceChanges.Close()
+
+	reconciler := &Reconciler{
+		networkChanges: networkChanges,
+		deviceChanges:  deviceChanges,
+		devices:        devices,
+	}
+
+	err := networkChanges.Create(newChange(change1, device1))
+	assert.NoError(t, err)
+	err = networkChanges.Create(newChange(change2, device2))
+	assert.NoError(t, err)
+
+	result, err := reconciler.Reconcile(types.ID(change2))
+	assert.NoError(t, err)
+	assert.Equal(t, controller.Result{Requeue: types.ID(change2), Backoff: true}, result)
+
+	result, err = reconciler.Reconcile(types.ID(change1))
+	assert.NoError(t, err)
+	assert.Equal(t, controller.Result{Requeue: types.ID(change1)}, result)
+
+	result, err = reconciler.Reconcile(types.ID(change2))
+	assert.NoError(t, err)
+	assert.Equal(t, controller.Result{Requeue: types.ID(change2)}, result)
+}
+
+// TestReconcilerOfflineWait tests that a change waits for its offline devices, failing once its
+// timeout has passed if it has one
+func TestReconcilerOfflineWait(t *testing.T) {
+	offline := &sync.Map{}
+	offline.Store(device2, true)
+	networkChanges, deviceChanges, devices := newStoresWithOffline(t, offline)
+	defer networkChanges.Close()
+	defer deviceChanges.Close()
+
+	reconciler := &Reconciler{
+		networkChanges: networkChanges,
+		deviceChanges:  deviceChanges,
+		devices:        devices,
+	}
+
+	waitChange := newChange(change1, device1, device2)
+	err := networkChanges.Create(waitChange)
+	assert.NoError(t, err)
+	timeoutChange := newChange(change2, device2)
+	timeoutChange.Changes[0].Values[0].Path = "bar"
+	timeoutChange.OfflinePolicy = networkchange.OfflinePolicy_WAIT_TIMEOUT
+	timeoutChange.OfflineTimeout = 200 * time.Millisecond
+	err = networkChanges.Create(timeoutChange)
+	assert.NoError(t, err)
+
+	// The timeout is measured from when the change starts waiting, not from when it was made
+	time.Sleep(timeoutChange.OfflineTimeout)
+
+	// Create the device changes and try to apply the changes
+	var waitResult, timeoutResult controller.Result
+	for i := 0; i < 2; i++ {
+		waitResult, err = reconciler.Reconcile(types.ID(change1))
+		assert.NoError(t, err)
+		timeoutResult, err = reconciler.Reconcile(types.ID(change2))
+		assert.NoError(t, err)
+	}
+
+	// Only the change with a timeout is requeued, once its timeout has passed
+	assert.Equal(t, time.Duration(0), waitResult.RequeueAfter)
+	assert.True(t, timeoutResult.RequeueAfter > 0)
+	assert.True(t, timeoutResult.RequeueAfter <= timeoutChange.OfflineTimeout)
+
+	// Both changes are waiting for device-2
+	waitChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_PENDING, waitChange.Status.State)
+	assert.Equal(t, uint64(0), waitChange.Status.Incarnation)
+	assert.Contains(t, waitChange.Status.Message, "device-2")
+	assert.NotNil(t, waitChange.Status.WaitingSince)
+	timeoutChange, err = networkChanges.Get(change2)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_PENDING, timeoutChange.Status.State)
+	assert.NotNil(t, timeoutChange.Status.WaitingSince)
+	assert.True(t, timeoutChange.Status.WaitingSince.After(timeoutChange.Created))
+
+	// Once the timeout has passed the change fails, and the other keeps on waiting
+	time.Sleep(timeoutChange.OfflineTimeout)
+	_, err = reconciler.Reconcile(types.ID(change2))
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(change1))
+	assert.NoError(t, err)
+
+	timeoutChange, err = networkChanges.Get(change2)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_FAILED, timeoutChange.Status.State)
+	assert.Contains(t, timeoutChange.Status.Message, "not connected within 200ms")
+	waitChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_PENDING, waitChange.Status.State)
+	assert.Equal(t, uint64(0), waitChange.Status.Incarnation)
+}
+
+// TestReconcilerApplyReachable tests that a change is applied to the connected devices straight away
+// and to the others once they are connected
+func TestReconcilerApplyReachable(t *testing.T) {
+	offline := &sync.Map{}
+	offline.Store(device2, true)
+	networkChanges, deviceChanges, devices := newStoresWithOffline(t, offline)
+	defer networkChanges.Close()
+	defer deviceChanges.Close()
+
+	reconciler := &Reconciler{
+		networkChanges: networkChanges,
+		deviceChanges:  deviceChanges,
+		devices:        devices,
+	}
+
+	networkChange := newChange(change1, device1, device2)
+	networkChange.OfflinePolicy = networkchange.OfflinePolicy_APPLY_REACHABLE
+	err := networkChanges.Create(networkChange)
+	assert.NoError(t, err)
+
+	// Create the device changes, apply the change and start the device changes
+	for i := 0; i < 4; i++ {
+		_, err = reconciler.Reconcile(types.ID(change1))
+		assert.NoError(t, err)
+	}
+
+	// Only the change to the connected device is started
+	deviceChange1, err := deviceChanges.Get("change-1:device-1:1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), deviceChange1.Status.Incarnation)
+	assert.Equal(t, change.State_PENDING, deviceChange1.Status.State)
+	deviceChange2, err := deviceChanges.Get("change-1:device-2:1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), deviceChange2.Status.Incarnation)
+
+	// The network change is not complete until the change to device-2 is
+	deviceChange1.Status.State = change.State_COMPLETE
+	err = deviceChanges.Update(deviceChange1)
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(change1))
+	assert.NoError(t, err)
+	networkChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_PENDING, networkChange.Status.State)
+	assert.Contains(t, networkChange.Status.Message, "device-2")
+
+	// device-2 is connected - its change is started
+	offline.Delete(device2)
+	_, err = reconciler.Reconcile(types.ID(change1))
+	assert.NoError(t, err)
+	deviceChange2, err = deviceChanges.Get("change-1:device-2:1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), deviceChange2.Status.Incarnation)
+	assert.Equal(t, change.State_PENDING, deviceChange2.Status.State)
+
+	deviceChange2.Status.State = change.State_COMPLETE
+	err = deviceChanges.Update(deviceChange2)
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(change1))
+	assert.NoError(t, err)
+	networkChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_COMPLETE, networkChange.Status.State)
+	assert.Equal(t, "", networkChange.Status.Message)
+}
+
+// TestReconcilerFail tests giving up a change the controller could not reconcile
+func TestReconcilerFail(t *testing.T) {
+	networkChanges, deviceChanges, devices := newStores(t)
+	defer networkChanges.Close()
+	defer deviceChanges.Close()
+
+	reconciler := &Reconciler{
+		networkChanges: networkChanges,
+		deviceChanges:  deviceChanges,
+		devices:        devices,
+	}
+
+	// A change that has been applied is rolled back, so that its pending device changes are not applied
+	networkChange := newChange(change1, device1, device2)
+	err := networkChanges.Create(networkChange)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = reconciler.Reconcile(types.ID(change1))
+		assert.NoError(t, err)
+	}
+	deviceChange1, err := deviceChanges.Get("change-1:device-1:1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), deviceChange1.Status.Incarnation)
+	deviceChange1.Status.State = change.State_COMPLETE
+	err = deviceChanges.Update(deviceChange1)
+	assert.NoError(t, err)
+
+	reconciler.Fail(types.ID(change1), errors.New("some error"))
+	networkChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.Phase_ROLLBACK, networkChange.Status.Phase)
+	assert.Equal(t, change.State_PENDING, networkChange.Status.State)
+	assert.Equal(t, change.Reason_ERROR, networkChange.Status.Reason)
+	assert.Contains(t, networkChange.Status.Message, "some error")
+	for _, id := range []devicechange.ID{"change-1:device-1:1.0.0", "change-1:device-2:1.0.0"} {
+		deviceChange, err := deviceChanges.Get(id)
+		assert.NoError(t, err)
+		assert.Equal(t, change.Phase_ROLLBACK, deviceChange.Status.Phase)
+		assert.Equal(t, change.State_PENDING, deviceChange.Status.State)
+		deviceChange.Status.State = change.State_COMPLETE
+		err = deviceChanges.Update(deviceChange)
+		assert.NoError(t, err)
+	}
+	_, err = reconciler.Reconcile(types.ID(change1))
+	assert.NoError(t, err)
+	networkChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.Phase_ROLLBACK, networkChange.Status.Phase)
+	assert.Equal(t, change.State_COMPLETE, networkChange.Status.State)
+
+	// A rollback that is given up is failed
+	networkChange.Status.State = change.State_PENDING
+	err = networkChanges.Update(networkChange)
+	assert.NoError(t, err)
+	reconciler.Fail(types.ID(change1), errors.New("some error"))
+	networkChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.Phase_ROLLBACK, networkChange.Status.Phase)
+	assert.Equal(t, change.State_FAILED, networkChange.Status.State)
+
+	// A change that has not been applied to its devices is failed
+	networkChange = newChange(change2, device1)
+	err = networkChanges.Create(networkChange)
+	assert.NoError(t, err)
+	reconciler.Fail(types.ID(change2), errors.New("some error"))
+	networkChange, err = networkChanges.Get(change2)
+	assert.NoError(t, err)
+	assert.Equal(t, change.Phase_CHANGE, networkChange.Status.Phase)
+	assert.Equal(t, change.State_FAILED, networkChange.Status.State)
+	assert.Equal(t, change.Reason_ERROR, networkChange.Status.Reason)
+	assert.Contains(t, networkChange.Status.Message, "some error")
+
+	// A change that is not pending is left as it is
+	reconciler.Fail(types.ID(change2), errors.New("other error"))
+	networkChange, err = networkChanges.Get(change2)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_FAILED, networkChange.Status.State)
+	assert.NotContains(t, networkChange.Status.Message, "other error")
+
+	// The change that follows a change failed before creating its device changes creates its own
+	networkChange = newChange("change-3", device1)
+	err = networkChanges.Create(networkChange)
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID("change-3"))
+	assert.NoError(t, err)
+	deviceChange1, err = deviceChanges.Get("change-3:device-1:1.0.0")
+	assert.NoError(t, err)
+	assert.NotNil(t, deviceChange1)
+
+	// A change that no longer exists is ignored
+	reconciler.Fail(types.ID("change-4"), errors.New("some error"))
+}
+
+// TestIsConnected tests that a connected device whose service is unavailable is not ready for changes
+func TestIsConnected(t *testing.T) {
+	newDevice := func(channelState devicetopo.ChannelState, serviceState devicetopo.ServiceState) *devicetopo.Device {
+		return &devicetopo.Device{
+			ID: devicetopo.ID(device1),
+			Protocols: []*devicetopo.ProtocolState{
+				{
+					Protocol:     devicetopo.Protocol_GNMI,
+					ChannelState: channelState,
+					ServiceState: serviceState,
+				},
+			},
+		}
+	}
+	assert.True(t, isConnected(newDevice(devicetopo.ChannelState_CONNECTED, devicetopo.ServiceState_AVAILABLE)))
+	assert.True(t, isConnected(newDevice(devicetopo.ChannelState_CONNECTED, devicetopo.ServiceState_UNKNOWN_SERVICE_STATE)))
+	assert.False(t, isConnected(newDevice(devicetopo.ChannelState_CONNECTED, devicetopo.ServiceState_UNAVAILABLE)))
+	assert.False(t, isConnected(newDevice(devicetopo.ChannelState_DISCONNECTED, devicetopo.ServiceState_AVAILABLE)))
+	assert.False(t, isConnected(&devicetopo.Device{ID: devicetopo.ID(device1)}))
+}
+
+func TestIsIntersectingChange(t *testing.T) {
+	tests := []struct {
+		device1 device.ID
+		path1   string
+		device2 device.ID
+		path2   string
+		expect  bool
+	}{
+		{device1, "/system/config/hostname", device1, "/system/config/hostname", true},
+		{device1, "/system/config/hostname", device2, "/system/config/hostname", false},
+		{device1, "/system/config/hostname", device1, "/system/config/domain-name", false},
+		{device1, "/system", device1, "/system/config/domain-name", true},
+		{device1, "/interfaces/interface[name=eth1]/config/mtu", device1, "/interfaces/interface[name=eth2]/config/mtu", false},
+		{device1, "/interfaces/interface[name=eth1]/config/mtu", device1, "/interfaces/interface", true},
+	}
+	for _, test := range tests {
+		change1 := newPathsChange(change1, test.device1, test.path1)
+		change2 := newPathsChange(change2, test.device2, test.path2)
+		assert.Equal(t, test.expect, isIntersectingChange(change1, change2), "%s %s and %s %s",
+			test.device1, test.path1, test.device2, test.path2)
+		assert.Equal(t, test.expect, isIntersectingChange(change2, change1), "%s %s and %s %s",
+			test.device2, test.path2, test.device1, test.path1)
+	}
+}
+
 func newStores(t *testing.T) (networkchanges.Store, devicechanges.Store, devicestore.Store) {
+	return newStoresWithOffline(t, &sync.Map{})
+}
+
+// newStoresWithOffline creates stores where the devices in the offline map are disconnected
+func newStoresWithOffline(t *testing.T, offline *sync.Map) (networkchanges.Store, devicechanges.Store, devicestore.Store) {
 	networkChanges, err := networkchanges.NewLocalStore()
 	assert.NoError(t, err)
 	deviceChanges, err := devicechanges.NewLocalStore()
@@ -317,13 +668,17 @@ func newStores(t *testing.T) (networkchanges.Store, devicechanges.Store, devices
 	ctrl := gomock.NewController(t)
 	client := NewMockDeviceServiceClient(ctrl)
 	client.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, request *devicetopo.GetRequest) (*devicetopo.GetResponse, error) {
+		channelState := devicetopo.ChannelState_CONNECTED
+		if _, ok := offline.Load(device.ID(request.ID)); ok {
+			channelState = devicetopo.ChannelState_DISCONNECTED
+		}
 		return &devicetopo.GetResponse{
 			Device: &devicetopo.Device{
 				ID: request.ID,
 				Protocols: []*devicetopo.ProtocolState{
 					{
 						Protocol:          devicetopo.Protocol_GNMI,
-						ChannelState:      devicetopo.ChannelState_CONNECTED,
+						ChannelState:      channelState,
 						ConnectivityState: devicetopo.ConnectivityState_REACHABLE,
 					},
 				},
@@ -358,3 +713,19 @@ func newChange(id networkchange.ID, devices ...device.ID) *networkchange.Network
 		Changes: changes,
 	}
 }
+
+func newPathsChange(id networkchange.ID, device device.ID, paths ...string) *networkchange.NetworkChange {
+	networkChange := newChange(id, device)
+	values := make([]*devicechange.ChangeValue, len(paths))
+	for i, path := range paths {
+		values[i] = &devicechange.ChangeValue{
+			Path: path,
+			Value: &devicechange.TypedValue{
+				Bytes: []byte("Hello world!"),
+				Type:  devicechange.ValueType_STRING,
+			},
+		}
+	}
+	networkChange.Changes[0].Values = values
+	return networkChange
+}
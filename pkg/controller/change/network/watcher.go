@@ -150,8 +150,7 @@ func (w *DeviceWatcher) updateWatch(topodevice *devicetopo.Device, ch chan<- typ
 	// If the protocol state is connected, ensure a stream is open
 	// If the protocol state is not connected, close any stream that's open
 	ctx := w.streams[deviceID]
-	state := getProtocolState(topodevice)
-	if state == devicetopo.ChannelState_CONNECTED {
+	if isConnected(topodevice) {
 		if ctx != nil {
 			return
 		}
@@ -49,6 +49,12 @@ type Reconciler interface {
 type Result struct {
 	// Requeue is the identifier of an event to requeue
 	Requeue types.ID
+	// RequeueAfter is the delay before the event is requeued. If no Requeue identifier is given,
+	// the reconciled request is requeued
+	RequeueAfter time.Duration
+	// Backoff requeues the event after the delay of the controller's RetryPolicy, which grows for as long
+	// as the event is backed off. If no Requeue identifier is given, the reconciled request is requeued
+	Backoff bool
 }
 
 // NewController creates a new controller
@@ -59,6 +65,8 @@ func NewController(name string) *Controller {
 		partitioner: &UnaryPartitioner{},
 		watchers:    make([]Watcher, 0),
 		partitions:  make(map[PartitionKey]chan types.ID),
+		retry:       DefaultRetryPolicy,
+		timers:      make(map[types.ID]*requeueTimer),
 	}
 }
 
@@ -73,7 +81,10 @@ func NewController(name string) *Controller {
 // Once the Reconciler receives a request, it should process the request using the current state of the cluster
 // Reconcilers should not cache state themselves and should instead rely on stores for consistency.
 // If a Reconciler returns false, the request will be requeued to be retried after all pending requests.
-// If a Reconciler returns an error, the request will be retried after a backoff period.
+// If a Reconciler returns an error, the request will be retried after a backoff period set by the RetryPolicy,
+// while the other requests are processed, and given up to the FailureHandler once the policy's maximum number
+// of attempts is reached.
+// A Reconciler may also ask for a request to be requeued after a delay, or after a backoff period.
 // Once a Reconciler successfully processes a request by returning true, the request will be discarded.
 // Requests can be partitioned among concurrent goroutines by configuring a WorkPartitioner. The controller
 // will create a goroutine per PartitionKey provided by the WorkPartitioner, and requests to different
@@ -86,7 +97,16 @@ type Controller struct {
 	filter      Filter
 	watchers    []Watcher
 	reconciler  Reconciler
+	retry       RetryPolicy
+	failure     FailureHandler
 	partitions  map[PartitionKey]chan types.ID
+	timers      map[types.ID]*requeueTimer
+}
+
+// requeueTimer requeues a request once its deadline has passed
+type requeueTimer struct {
+	timer    *time.Timer
+	deadline time.Time
 }
 
 // Activate sets an activator for the controller
@@ -129,6 +149,22 @@ func (c *Controller) Reconcile(reconciler Reconciler) *Controller {
 	return c
 }
 
+// Retry sets the retry policy for the controller
+func (c *Controller) Retry(policy RetryPolicy) *Controller {
+	c.mu.Lock()
+	c.retry = policy
+	c.mu.Unlock()
+	return c
+}
+
+// OnFailure sets the handler of the requests the controller gives up on
+func (c *Controller) OnFailure(handler FailureHandler) *Controller {
+	c.mu.Lock()
+	c.failure = handler
+	c.mu.Unlock()
+	return c
+}
+
 // Start starts the request controller
 func (c *Controller) Start() error {
 	ch := make(chan bool)
@@ -198,6 +234,12 @@ func (c *Controller) deactivate() {
 	for _, watcher := range c.watchers {
 		watcher.Stop()
 	}
+	c.mu.Lock()
+	for id, requeue := range c.timers {
+		requeue.timer.Stop()
+		delete(c.timers, id)
+	}
+	c.mu.Unlock()
 }
 
 // processEvents processes the events from the given channel
@@ -233,10 +275,12 @@ func (c *Controller) partition(id types.ID, partitioner WorkPartitioner) {
 				if !ok {
 					partition = make(chan types.ID)
 					c.partitions[key] = partition
+					partitionsTotal.WithLabelValues(c.name).Inc()
 					go c.processRequests(partition)
 				}
 				c.mu.Unlock()
 			}
+			queueDepth.WithLabelValues(c.name).Inc()
 			partition <- id
 			return
 		}
@@ -248,36 +292,99 @@ func (c *Controller) partition(id types.ID, partitioner WorkPartitioner) {
 func (c *Controller) processRequests(ch chan types.ID) {
 	c.mu.RLock()
 	reconciler := c.reconciler
+	retry := c.retry
+	failure := c.failure
 	c.mu.RUnlock()
 
+	// The number of times in a row each request has failed, and has been backed off
+	attempts := make(map[types.ID]int)
+	backoffs := make(map[types.ID]int)
 	for id := range ch {
-		// Reconcile the request. If the reconciliation is not successful, requeue the request to be processed
-		// after the remaining enqueued events.
-		result := c.reconcile(id, reconciler)
-		if result.Requeue != "" {
-			go c.requeueRequest(ch, result.Requeue)
+		queueDepth.WithLabelValues(c.name).Dec()
+
+		// Reconcile the request. If an error occurs, retry the request once the delay of the retry policy
+		// has passed, or give it up once it has failed the maximum number of attempts.
+		result, err := c.reconcile(id, reconciler)
+		if err != nil {
+			attempts[id]++
+			if retry.MaxAttempts > 0 && attempts[id] >= retry.MaxAttempts {
+				log.Errorf("Giving up reconciliation of %s after %d attempts", id, attempts[id])
+				requestsGivenUpTotal.WithLabelValues(c.name).Inc()
+				delete(attempts, id)
+				delete(backoffs, id)
+				if failure != nil {
+					failure.Fail(id, err)
+				}
+				continue
+			}
+			c.requeueAfter(ch, id, retry.Delay(attempts[id]))
+			continue
+		}
+		delete(attempts, id)
+
+		// If the reconciliation is not successful, requeue the request to be processed after the
+		// remaining enqueued events, or after the delay the reconciler asks for.
+		requeue := result.Requeue
+		if requeue == "" && (result.RequeueAfter > 0 || result.Backoff) {
+			requeue = id
+		}
+		if result.Backoff {
+			backoffs[requeue]++
+			c.requeueAfter(ch, requeue, retry.Delay(backoffs[requeue]))
+			continue
+		}
+		delete(backoffs, id)
+		if result.RequeueAfter > 0 {
+			c.requeueAfter(ch, requeue, result.RequeueAfter)
+		} else if requeue != "" {
+			go c.requeueRequest(ch, requeue)
 		}
 	}
 }
 
 // requeueRequest requeues the given request
 func (c *Controller) requeueRequest(ch chan types.ID, id types.ID) {
+	queueDepth.WithLabelValues(c.name).Inc()
 	ch <- id
 }
 
-// reconcile reconciles the given request ID until complete
-func (c *Controller) reconcile(id types.ID, reconciler Reconciler) Result {
-	iteration := 1
-	for {
-		// Reconcile the request. If an error occurs, use exponential backoff to retry in order.
-		// Otherwise, return the result.
-		result, err := reconciler.Reconcile(id)
-		if err != nil {
-			log.Errorf("An error occurred during reconciliation of %s: %v", id, err)
-			time.Sleep(time.Duration(iteration*2) * time.Millisecond)
-		} else {
-			return result
+// requeueAfter requeues the given request once the delay has passed. If the request is already to be
+// requeued sooner, it is left to be requeued then
+func (c *Controller) requeueAfter(ch chan types.ID, id types.ID, delay time.Duration) {
+	deadline := time.Now().Add(delay)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if requeue, ok := c.timers[id]; ok {
+		if !requeue.deadline.After(deadline) {
+			return
 		}
-		iteration++
+		requeue.timer.Stop()
+	}
+	requeue := &requeueTimer{
+		deadline: deadline,
+	}
+	requeue.timer = time.AfterFunc(delay, func() {
+		c.mu.Lock()
+		if c.timers[id] != requeue {
+			c.mu.Unlock()
+			return
+		}
+		delete(c.timers, id)
+		c.mu.Unlock()
+		c.requeueRequest(ch, id)
+	})
+	c.timers[id] = requeue
+}
+
+// reconcile reconciles the given request ID once
+func (c *Controller) reconcile(id types.ID, reconciler Reconciler) (Result, error) {
+	start := time.Now()
+	result, err := reconciler.Reconcile(id)
+	reconcileDuration.WithLabelValues(c.name).Observe(time.Since(start).Seconds())
+	reconcilesTotal.WithLabelValues(c.name).Inc()
+	if err != nil {
+		reconcileErrorsTotal.WithLabelValues(c.name).Inc()
+		log.Errorf("An error occurred during reconciliation of %s: %v", id, err)
 	}
+	return result, err
 }
@@ -18,11 +18,13 @@ import (
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/onosproject/onos-config/api/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"strconv"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestController(t *testing.T) {
@@ -117,3 +119,146 @@ func TestController(t *testing.T) {
 	watcherCh <- types.ID("3")
 	watcherCh <- types.ID("4")
 }
+
+// failures records the requests given up by a controller
+type failures chan types.ID
+
+func (f failures) Fail(id types.ID, err error) {
+	f <- id
+}
+
+func TestControllerRetry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	activatorValue := &atomic.Value{}
+	activator := NewMockActivator(ctrl)
+	activator.EXPECT().
+		Start(gomock.Any()).
+		DoAndReturn(func(ch chan<- bool) error {
+			activatorValue.Store(ch)
+			return nil
+		})
+	activator.EXPECT().Stop()
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	watcherValue := &atomic.Value{}
+	watcher := NewMockWatcher(ctrl)
+	watcher.EXPECT().
+		Start(gomock.Any()).
+		DoAndReturn(func(ch chan<- types.ID) error {
+			watcherValue.Store(ch)
+			wg.Done()
+			return nil
+		})
+
+	// The metrics of the controller are counted from here
+	reconciles := testutil.ToFloat64(reconcilesTotal.WithLabelValues("Retry"))
+	reconcileErrors := testutil.ToFloat64(reconcileErrorsTotal.WithLabelValues("Retry"))
+	givenUp := testutil.ToFloat64(requestsGivenUpTotal.WithLabelValues("Retry"))
+	partitions := testutil.ToFloat64(partitionsTotal.WithLabelValues("Retry"))
+
+	reconciler := NewMockReconciler(ctrl)
+	failed := make(failures, 1)
+	reconciled := make(chan time.Time, 10)
+
+	controller := NewController("Retry").
+		Activate(activator).
+		Watch(watcher).
+		Retry(RetryPolicy{
+			InitialDelay: 5 * time.Millisecond,
+			MaxDelay:     40 * time.Millisecond,
+			MaxAttempts:  3,
+		}).
+		OnFailure(failed).
+		Reconcile(reconciler)
+	defer controller.Stop()
+
+	err := controller.Start()
+	assert.NoError(t, err)
+
+	activatorCh := activatorValue.Load().(chan<- bool)
+	activatorCh <- true
+
+	wg.Wait()
+	watcherCh := watcherValue.Load().(chan<- types.ID)
+
+	// A request that keeps failing is given up after the maximum number of attempts, and the
+	// requests that follow it are processed while it waits to be retried
+	reconciler.EXPECT().
+		Reconcile(gomock.Eq(types.ID("1"))).
+		DoAndReturn(func(id types.ID) (Result, error) {
+			go func() {
+				watcherCh <- types.ID("3")
+			}()
+			return Result{}, errors.New("some error")
+		})
+	reconciler.EXPECT().
+		Reconcile(gomock.Eq(types.ID("1"))).
+		Return(Result{}, errors.New("some error")).
+		Times(2)
+	reconciler.EXPECT().
+		Reconcile(gomock.Eq(types.ID("3"))).
+		DoAndReturn(func(id types.ID) (Result, error) {
+			reconciled <- time.Now()
+			return Result{}, nil
+		})
+
+	// A request is requeued after the delay it asks for, and backed off for longer each time
+	reconciler.EXPECT().
+		Reconcile(gomock.Eq(types.ID("2"))).
+		Return(Result{RequeueAfter: 50 * time.Millisecond}, nil)
+	reconciler.EXPECT().
+		Reconcile(gomock.Eq(types.ID("2"))).
+		DoAndReturn(func(id types.ID) (Result, error) {
+			reconciled <- time.Now()
+			return Result{Backoff: true}, nil
+		}).
+		Times(4)
+	reconciler.EXPECT().
+		Reconcile(gomock.Eq(types.ID("2"))).
+		DoAndReturn(func(id types.ID) (Result, error) {
+			reconciled <- time.Now()
+			return Result{}, nil
+		})
+
+	watcherCh <- types.ID("1")
+
+	select {
+	case id := <-failed:
+		assert.Equal(t, types.ID("1"), id)
+	case <-time.After(5 * time.Second):
+		t.FailNow()
+	}
+	select {
+	case <-reconciled:
+	default:
+		assert.Fail(t, "request 3 was not reconciled before request 1 was given up")
+	}
+
+	requeued := time.Now()
+	watcherCh <- types.ID("2")
+	times := make([]time.Time, 0)
+	for len(times) < 5 {
+		select {
+		case reconcileTime := <-reconciled:
+			times = append(times, reconcileTime)
+		case <-time.After(5 * time.Second):
+			t.FailNow()
+		}
+	}
+	assert.True(t, times[0].Sub(requeued) >= 50*time.Millisecond)
+	assert.True(t, times[2].Sub(times[1]) >= 10*time.Millisecond)
+	assert.True(t, times[3].Sub(times[2]) >= 20*time.Millisecond)
+	assert.True(t, times[4].Sub(times[3]) >= 40*time.Millisecond)
+
+	// The last reconciliation is counted once it has returned
+	assert.Eventually(t, func() bool {
+		return testutil.ToFloat64(reconcilesTotal.WithLabelValues("Retry"))-reconciles == 10
+	}, 5*time.Second, time.Millisecond)
+	assert.Equal(t, float64(3), testutil.ToFloat64(reconcileErrorsTotal.WithLabelValues("Retry"))-reconcileErrors)
+	assert.Equal(t, float64(1), testutil.ToFloat64(requestsGivenUpTotal.WithLabelValues("Retry"))-givenUp)
+	assert.Equal(t, float64(1), testutil.ToFloat64(partitionsTotal.WithLabelValues("Retry"))-partitions)
+	assert.Equal(t, float64(0), testutil.ToFloat64(queueDepth.WithLabelValues("Retry")))
+}
@@ -0,0 +1,66 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "onos_config"
+
+var (
+	reconcilesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "controller",
+		Name:      "reconciles_total",
+		Help:      "The number of reconciliations by the controller",
+	}, []string{"controller"})
+	reconcileErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "controller",
+		Name:      "reconcile_errors_total",
+		Help:      "The number of reconciliations by the controller that returned an error",
+	}, []string{"controller"})
+	reconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "controller",
+		Name:      "reconcile_duration_seconds",
+		Help:      "The duration of the reconciliations by the controller",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"controller"})
+	requestsGivenUpTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "controller",
+		Name:      "requests_given_up_total",
+		Help:      "The number of requests the controller gave up after the maximum number of attempts",
+	}, []string{"controller"})
+	queueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "controller",
+		Name:      "queue_depth",
+		Help:      "The number of requests waiting to be reconciled by the controller",
+	}, []string{"controller"})
+	partitionsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: "controller",
+		Name:      "partitions",
+		Help:      "The number of partitions the controller processes requests in",
+	}, []string{"controller"})
+)
+
+func init() {
+	prometheus.MustRegister(reconcilesTotal, reconcileErrorsTotal, reconcileDuration, requestsGivenUpTotal,
+		queueDepth, partitionsTotal)
+}
@@ -0,0 +1,71 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/onosproject/onos-config/api/types"
+)
+
+// DefaultRetryPolicy is the retry policy of a controller that is not given one. Requests are
+// retried until they succeed
+var DefaultRetryPolicy = RetryPolicy{
+	InitialDelay: 2 * time.Millisecond,
+	MaxDelay:     5 * time.Second,
+	Jitter:       0.2,
+}
+
+// RetryPolicy sets how a controller retries requests
+// A request whose reconciliation fails is retried after a delay that doubles with each attempt, up to the
+// maximum delay. The other requests of the controller are processed while it waits. A request the Reconciler asks to back off is requeued after the same delays,
+// growing for as long as the request is backed off. Part of each delay is random, so that the requests
+// that fail together are not all retried together.
+// A request that has failed MaxAttempts times is given up and passed to the controller's FailureHandler.
+type RetryPolicy struct {
+	// InitialDelay is the delay before the first retry
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between retries, 0 for no cap
+	MaxDelay time.Duration
+	// Jitter is the fraction of each delay that is random, from 0 to 1
+	Jitter float64
+	// MaxAttempts is the number of reconciliations of a request before it is given up, 0 for no limit
+	MaxAttempts int
+}
+
+// Delay returns the delay before the given attempt to retry a request, counting from 1
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.InitialDelay
+	for i := 1; i < attempt && (p.MaxDelay == 0 || delay < p.MaxDelay) && delay <= math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay -= time.Duration(float64(delay) * p.Jitter * rand.Float64())
+	}
+	return delay
+}
+
+// FailureHandler handles the requests a controller gives up on
+// Once a request has failed the maximum number of attempts of the controller's RetryPolicy, it is
+// discarded and passed to the FailureHandler, which may record the failure in the stores.
+type FailureHandler interface {
+	// Fail is called with the request that has been given up and the error of its last attempt
+	Fail(id types.ID, err error)
+}
@@ -0,0 +1,48 @@
+// Copyright 2019-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{
+		InitialDelay: 10 * time.Millisecond,
+		MaxDelay:     50 * time.Millisecond,
+	}
+	assert.Equal(t, 10*time.Millisecond, policy.Delay(1))
+	assert.Equal(t, 20*time.Millisecond, policy.Delay(2))
+	assert.Equal(t, 40*time.Millisecond, policy.Delay(3))
+	assert.Equal(t, 50*time.Millisecond, policy.Delay(4))
+	assert.Equal(t, 50*time.Millisecond, policy.Delay(100))
+
+	// Without a maximum the delay keeps growing, without overflowing
+	uncapped := RetryPolicy{InitialDelay: policy.InitialDelay}
+	assert.Equal(t, 80*time.Millisecond, uncapped.Delay(4))
+	assert.Equal(t, 160*time.Millisecond, uncapped.Delay(5))
+	assert.True(t, uncapped.Delay(1000) > 0)
+
+	policy.Jitter = 0.5
+	for attempt := 1; attempt < 10; attempt++ {
+		delay := policy.Delay(attempt)
+		max := RetryPolicy{InitialDelay: policy.InitialDelay, MaxDelay: policy.MaxDelay}.Delay(attempt)
+		assert.True(t, delay <= max, "%v is over %v", delay, max)
+		assert.True(t, delay >= max/2, "%v is under %v", delay, max/2)
+	}
+}
@@ -108,7 +108,7 @@ func (r *Reconciler) reconcilePendingMark(snapshot *networksnapshot.NetworkSnaps
 	if err != nil {
 		return controller.Result{}, err
 	} else if !canApply {
-		return controller.Result{Requeue: types.ID(snapshot.ID)}, nil
+		return controller.Result{Requeue: types.ID(snapshot.ID), Backoff: true}, nil
 	}
 
 	// If the snapshot can be applied, update the snapshot state to RUNNING
@@ -24,41 +24,143 @@ package dispatcher
 
 import (
 	"fmt"
+	"regexp"
 	"sync"
+	"sync/atomic"
 
 	"github.com/onosproject/onos-config/pkg/events"
+	"github.com/onosproject/onos-config/pkg/utils"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/openconfig/gnmi/proto/gnmi"
 )
 
 var log = logging.GetLogger("dispatcher")
 
+// OpStateFilter selects the operational state events delivered to a listener
+type OpStateFilter struct {
+	// Targets are the devices whose events are delivered. No targets selects all the devices
+	Targets []string
+	// TargetPatterns select the devices whose ID they match, in addition to the targets
+	TargetPatterns []*regexp.Regexp
+	// Paths are matched against the path of an event. No paths selects all the paths
+	Paths []*utils.PathMatcher
+}
+
+// matchTarget returns true if the device is selected by the filter
+func (f OpStateFilter) matchTarget(target string) bool {
+	if len(f.TargetPatterns) == 0 {
+		// The listener is indexed by its targets
+		return true
+	}
+	for _, t := range f.Targets {
+		if t == target {
+			return true
+		}
+	}
+	for _, targetRegexp := range f.TargetPatterns {
+		if targetRegexp.MatchString(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath returns true if the path is selected by the filter. A path that could not be parsed
+// is only selected by a filter without paths
+func (f OpStateFilter) matchPath(path *gnmi.Path) bool {
+	if len(f.Paths) == 0 {
+		return true
+	} else if path == nil {
+		return false
+	}
+	for _, pathMatcher := range f.Paths {
+		if pathMatcher.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// opStateListener is a registered listener with its filter
+type opStateListener struct {
+	filter  OpStateFilter
+	channel chan events.OperationalStateEvent
+	// done is closed when the listener is unregistered, to release a send that is blocked on it
+	done     chan struct{}
+	sendLock sync.Mutex
+}
+
+// send blocks until the listener receives the event or is unregistered
+func (l *opStateListener) send(event events.OperationalStateEvent) {
+	l.sendLock.Lock()
+	defer l.sendLock.Unlock()
+	select {
+	case <-l.done:
+		return
+	default:
+	}
+	select {
+	case l.channel <- event:
+	case <-l.done:
+	}
+}
+
+// close closes the channel of the listener once no event is being sent on it
+func (l *opStateListener) close() {
+	close(l.done)
+	l.sendLock.Lock()
+	defer l.sendLock.Unlock()
+	close(l.channel)
+}
+
+// allTargets indexes the listeners that have no targets in their filter
+const allTargets = ""
+
 // Dispatcher manages SB and NB configuration event listeners
 type Dispatcher struct {
 	nbiOpStateListenersLock sync.RWMutex
-	nbiOpStateListeners     map[string]chan events.OperationalStateEvent
+	nbiOpStateListeners     map[string]*opStateListener
+	// targetOpStateListeners indexes the listeners by the targets of their filter
+	targetOpStateListeners map[string]map[string]*opStateListener
+	nextSubscriptionID     uint64
 }
 
 // NewDispatcher creates and initializes a new event dispatcher
 func NewDispatcher() *Dispatcher {
 	return &Dispatcher{
-		nbiOpStateListeners: make(map[string]chan events.OperationalStateEvent),
+		nbiOpStateListeners:    make(map[string]*opStateListener),
+		targetOpStateListeners: make(map[string]map[string]*opStateListener),
 	}
 }
 
 // ListenOperationalState is a go routine function that listens out for changes made in the
 // configuration and distributes this to registered deviceListeners on the
 // Southbound and registered nbiListeners on the northbound
-// Southbound listeners are only sent the events that matter to them
-// All events.Events are sent to northbound listeners
+// Each event is only sent to the listeners whose filter matches its device and path
 func (d *Dispatcher) ListenOperationalState(operationalStateChannel <-chan events.OperationalStateEvent) {
 	log.Info("Operational State Event listener initialized")
 
 	for operationalStateEvent := range operationalStateChannel {
+		eventPath, err := utils.ParseGNMIElements(utils.SplitPath(operationalStateEvent.Path()))
+		if err != nil {
+			log.Warnf("Unable to parse path %s %v", operationalStateEvent.Path(), err)
+			eventPath = nil
+		}
+		listeners := make([]*opStateListener, 0)
 		d.nbiOpStateListenersLock.RLock()
-		for _, nbiChan := range d.nbiOpStateListeners {
-			nbiChan <- operationalStateEvent
+		for _, target := range []string{operationalStateEvent.Subject(), allTargets} {
+			for _, listener := range d.targetOpStateListeners[target] {
+				if listener.filter.matchTarget(operationalStateEvent.Subject()) &&
+					listener.filter.matchPath(eventPath) {
+					listeners = append(listeners, listener)
+				}
+			}
 		}
 		d.nbiOpStateListenersLock.RUnlock()
+		// The lock is not held while sending, so that a listener can be unregistered by its reader
+		for _, listener := range listeners {
+			listener.send(operationalStateEvent)
+		}
 	}
 }
 
@@ -70,22 +172,55 @@ func (d *Dispatcher) RegisterOpState(subscriber string) (chan events.Operational
 	if _, ok := d.nbiOpStateListeners[subscriber]; ok {
 		return nil, fmt.Errorf("NBI operational state %s is already registered", subscriber)
 	}
-	channel := make(chan events.OperationalStateEvent)
-	d.nbiOpStateListeners[subscriber] = channel
-	return channel, nil
+	return d.register(subscriber, OpStateFilter{}), nil
+}
+
+// RegisterOpStateSubscription registers a listener for the operational state events selected by
+// the filter. It returns the unique ID of the subscription, to be used to unregister it
+func (d *Dispatcher) RegisterOpStateSubscription(filter OpStateFilter) (string, chan events.OperationalStateEvent) {
+	d.nbiOpStateListenersLock.Lock()
+	defer d.nbiOpStateListenersLock.Unlock()
+	subscriptionID := fmt.Sprintf("subscription-%d", atomic.AddUint64(&d.nextSubscriptionID, 1))
+	return subscriptionID, d.register(subscriptionID, filter)
+}
+
+// register adds a listener - the caller must hold the write lock
+func (d *Dispatcher) register(subscriber string, filter OpStateFilter) chan events.OperationalStateEvent {
+	listener := &opStateListener{
+		filter:  filter,
+		channel: make(chan events.OperationalStateEvent),
+		done:    make(chan struct{}),
+	}
+	d.nbiOpStateListeners[subscriber] = listener
+	for _, target := range listenerTargets(filter) {
+		targetListeners, ok := d.targetOpStateListeners[target]
+		if !ok {
+			targetListeners = make(map[string]*opStateListener)
+			d.targetOpStateListeners[target] = targetListeners
+		}
+		targetListeners[subscriber] = listener
+	}
+	return listener.channel
 }
 
 // UnregisterOperationalState closes the device channel and removes it from the deviceListeners
 func (d *Dispatcher) UnregisterOperationalState(subscriber string) {
-	d.nbiOpStateListenersLock.RLock()
-	defer d.nbiOpStateListenersLock.RUnlock()
-	channel, ok := d.nbiOpStateListeners[subscriber]
+	d.nbiOpStateListenersLock.Lock()
+	listener, ok := d.nbiOpStateListeners[subscriber]
 	if !ok {
+		d.nbiOpStateListenersLock.Unlock()
 		log.Infof("Subscriber %s had not been registered", subscriber)
 		return
 	}
 	delete(d.nbiOpStateListeners, subscriber)
-	close(channel)
+	for _, target := range listenerTargets(listener.filter) {
+		delete(d.targetOpStateListeners[target], subscriber)
+		if len(d.targetOpStateListeners[target]) == 0 {
+			delete(d.targetOpStateListeners, target)
+		}
+	}
+	d.nbiOpStateListenersLock.Unlock()
+	listener.close()
 }
 
 // GetListeners returns a list of registered listeners names
@@ -98,3 +233,12 @@ func (d *Dispatcher) GetListeners() []string {
 	}
 	return listenerKeys
 }
+
+// listenerTargets returns the targets under which a listener with the filter is indexed. A listener
+// with target patterns has to see the events of all the devices
+func listenerTargets(filter OpStateFilter) []string {
+	if len(filter.Targets) == 0 || len(filter.TargetPatterns) > 0 {
+		return []string{allTargets}
+	}
+	return filter.Targets
+}
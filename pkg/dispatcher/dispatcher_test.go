@@ -17,13 +17,16 @@ package dispatcher
 import (
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
 	"github.com/onosproject/onos-config/pkg/events"
+	"github.com/onosproject/onos-config/pkg/utils"
 	topodevice "github.com/onosproject/onos-topo/api/device"
 	"gotest.tools/assert"
 	is "gotest.tools/assert/cmp"
 	"os"
+	"regexp"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 var (
@@ -113,3 +116,111 @@ func testSyncOpState(testChan <-chan events.OperationalStateEvent, callback func
 		log.Info("OperationalState change for Test ", opStateChange)
 	}
 }
+
+func Test_subscriptionRouting(t *testing.T) {
+	d := NewDispatcher()
+	id1, ch1 := d.RegisterOpStateSubscription(OpStateFilter{
+		Targets: []string{"device1"},
+		Paths:   []*utils.PathMatcher{pathMatcher("/cont1a/leaf1a")},
+	})
+	id2, ch2 := d.RegisterOpStateSubscription(OpStateFilter{
+		Targets: []string{"device2"},
+	})
+	id3, ch3 := d.RegisterOpStateSubscription(OpStateFilter{})
+	assert.Assert(t, id1 != id2 && id2 != id3 && id1 != id3, "Subscription IDs should be unique")
+	assert.Equal(t, 3, len(d.GetListeners()))
+
+	opStateCh := make(chan events.OperationalStateEvent)
+	go d.ListenOperationalState(opStateCh)
+	defer close(opStateCh)
+
+	received := func(ch chan events.OperationalStateEvent) []string {
+		paths := make([]string, 0)
+		for {
+			select {
+			case event := <-ch:
+				paths = append(paths, event.Subject()+event.Path())
+			case <-time.After(50 * time.Millisecond):
+				return paths
+			}
+		}
+	}
+	results := make([][]string, 3)
+	wg := sync.WaitGroup{}
+	for i, ch := range []chan events.OperationalStateEvent{ch1, ch2, ch3} {
+		wg.Add(1)
+		go func(i int, ch chan events.OperationalStateEvent) {
+			defer wg.Done()
+			results[i] = received(ch)
+		}(i, ch)
+	}
+	for _, event := range []events.OperationalStateEvent{
+		events.NewOperationalStateEvent("device1", "/cont1a/leaf1a", devicechange.NewTypedValueString("a"), events.EventItemUpdated),
+		events.NewOperationalStateEvent("device1", "/cont1a/leaf1b", devicechange.NewTypedValueString("b"), events.EventItemUpdated),
+		events.NewOperationalStateEvent("device2", "/cont1a/leaf1a", devicechange.NewTypedValueString("c"), events.EventItemUpdated),
+	} {
+		opStateCh <- event
+	}
+	wg.Wait()
+
+	assert.DeepEqual(t, results[0], []string{"device1/cont1a/leaf1a"})
+	assert.DeepEqual(t, results[1], []string{"device2/cont1a/leaf1a"})
+	assert.DeepEqual(t, results[2], []string{"device1/cont1a/leaf1a", "device1/cont1a/leaf1b", "device2/cont1a/leaf1a"})
+
+	d.UnregisterOperationalState(id1)
+	d.UnregisterOperationalState(id2)
+	d.UnregisterOperationalState(id3)
+	assert.Equal(t, 0, len(d.GetListeners()))
+}
+
+func Test_unregisterBlockedSubscription(t *testing.T) {
+	d := NewDispatcher()
+	id, _ := d.RegisterOpStateSubscription(OpStateFilter{})
+
+	opStateCh := make(chan events.OperationalStateEvent)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		d.ListenOperationalState(opStateCh)
+		wg.Done()
+	}()
+	// Nobody reads the events, so the dispatcher blocks until the subscription is unregistered
+	opStateCh <- events.NewOperationalStateEvent("device1", "/cont1a/leaf1a",
+		devicechange.NewTypedValueString("a"), events.EventItemUpdated)
+	d.UnregisterOperationalState(id)
+	close(opStateCh)
+	wg.Wait()
+}
+
+func Test_subscriptionTargetPatterns(t *testing.T) {
+	d := NewDispatcher()
+	id, ch := d.RegisterOpStateSubscription(OpStateFilter{
+		Targets:        []string{"device1"},
+		TargetPatterns: []*regexp.Regexp{regexp.MustCompile("^stratum-.*$")},
+	})
+
+	opStateCh := make(chan events.OperationalStateEvent)
+	go d.ListenOperationalState(opStateCh)
+	defer close(opStateCh)
+
+	go func() {
+		for _, target := range []string{"device1", "device2", "stratum-1", "stratum-2"} {
+			opStateCh <- events.NewOperationalStateEvent(target, "/cont1a/leaf1a",
+				devicechange.NewTypedValueString("a"), events.EventItemUpdated)
+		}
+	}()
+	for _, target := range []string{"device1", "stratum-1", "stratum-2"} {
+		select {
+		case event := <-ch:
+			assert.Equal(t, event.Subject(), target)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected event for %s", target)
+		}
+	}
+	d.UnregisterOperationalState(id)
+}
+
+func pathMatcher(path string) *utils.PathMatcher {
+	matcher, _ := utils.ParsePathMatcher(path)
+	return matcher
+}
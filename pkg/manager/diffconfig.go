@@ -0,0 +1,162 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"bytes"
+	devicechange "github.com/onosproject/onos-config/api/types/change/device"
+	networkchange "github.com/onosproject/onos-config/api/types/change/network"
+	devicetype "github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"sort"
+)
+
+// ConfigDiff is the difference in the value of a single path between two configurations
+type ConfigDiff struct {
+	Path string
+	// OldValue is nil when the path has been added
+	OldValue *devicechange.TypedValue
+	// NewValue is nil when the path has been removed
+	NewValue *devicechange.TypedValue
+}
+
+// ComputeConfigDiff returns the differences the device change would make to the configuration of
+// its device as stored at the given revision
+func (m *Manager) ComputeConfigDiff(change *devicechange.Change, revision networkchange.Revision) ([]*ConfigDiff, error) {
+	configValues, err := m.DeviceStateStore.Get(change.GetVersionedDeviceID(), revision)
+	if err != nil {
+		return nil, err
+	}
+	return DiffConfig(configValues, ApplyChangeValues(configValues, change.Values)), nil
+}
+
+// ComputeNetworkChangeDiff returns the differences in the configuration of each device changed
+// between two network changes. If a device ID is given only that device is compared. The configuration
+// at each end is rebuilt as GetTargetConfigAt does, so a network change that has been compacted in to a
+// snapshot gives a FailedPrecondition error
+func (m *Manager) ComputeNetworkChangeDiff(from *networkchange.NetworkChange, to *networkchange.NetworkChange,
+	deviceID devicetype.ID) (map[devicetype.VersionedID][]*ConfigDiff, error) {
+	lowIndex, highIndex := from.Index, to.Index
+	if lowIndex > highIndex {
+		lowIndex, highIndex = highIndex, lowIndex
+	}
+
+	// Only the devices changed in between can differ
+	devices := make(map[devicetype.VersionedID]bool)
+	changeCh := make(chan *networkchange.NetworkChange)
+	ctx, err := m.NetworkChangesStore.List(changeCh)
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+	for networkChange := range changeCh {
+		if networkChange.Index <= lowIndex || networkChange.Index > highIndex {
+			continue
+		}
+		for _, change := range networkChange.Changes {
+			if deviceID == "" || change.DeviceID == deviceID {
+				devices[change.GetVersionedDeviceID()] = true
+			}
+		}
+	}
+
+	diffs := make(map[devicetype.VersionedID][]*ConfigDiff)
+	for device := range devices {
+		fromConfig, err := m.GetTargetConfigAt(device.GetID(), device.GetVersion(), "/", from)
+		if err != nil {
+			return nil, err
+		}
+		toConfig, err := m.GetTargetConfigAt(device.GetID(), device.GetVersion(), "/", to)
+		if err != nil {
+			return nil, err
+		}
+		diffs[device] = DiffConfig(fromConfig, toConfig)
+	}
+	return diffs, nil
+}
+
+// ApplyChangeValues returns the configuration resulting from applying the change values to the given
+// configuration. A removed value removes the path and everything beneath it
+func ApplyChangeValues(config []*devicechange.PathValue, values []*devicechange.ChangeValue) []*devicechange.PathValue {
+	pathValues := make(map[string]*devicechange.TypedValue)
+	for _, configValue := range config {
+		pathValues[configValue.Path] = configValue.Value
+	}
+	for _, changeValue := range values {
+		if changeValue.Removed {
+			for path := range pathValues {
+				if utils.IsPathUnder(path, changeValue.Path) {
+					delete(pathValues, path)
+				}
+			}
+		} else {
+			pathValues[changeValue.Path] = changeValue.Value
+		}
+	}
+
+	newConfig := make([]*devicechange.PathValue, 0, len(pathValues))
+	for path, value := range pathValues {
+		newConfig = append(newConfig, &devicechange.PathValue{
+			Path:  path,
+			Value: value,
+		})
+	}
+	sort.Slice(newConfig, func(i, j int) bool {
+		return newConfig[i].Path < newConfig[j].Path
+	})
+	return newConfig
+}
+
+// DiffConfig returns the paths that were added, removed or modified between the old and the new
+// configuration, sorted by path
+func DiffConfig(oldConfig, newConfig []*devicechange.PathValue) []*ConfigDiff {
+	oldValues := make(map[string]*devicechange.TypedValue)
+	for _, pathValue := range oldConfig {
+		oldValues[pathValue.Path] = pathValue.Value
+	}
+
+	diffs := make([]*ConfigDiff, 0)
+	for _, pathValue := range newConfig {
+		oldValue, ok := oldValues[pathValue.Path]
+		if !ok {
+			diffs = append(diffs, &ConfigDiff{Path: pathValue.Path, NewValue: pathValue.Value})
+		} else if !typedValuesEqual(oldValue, pathValue.Value) {
+			diffs = append(diffs, &ConfigDiff{Path: pathValue.Path, OldValue: oldValue, NewValue: pathValue.Value})
+		}
+		delete(oldValues, pathValue.Path)
+	}
+	for path, oldValue := range oldValues {
+		diffs = append(diffs, &ConfigDiff{Path: path, OldValue: oldValue})
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs
+}
+
+func typedValuesEqual(a, b *devicechange.TypedValue) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Type != b.Type || !bytes.Equal(a.Bytes, b.Bytes) || len(a.TypeOpts) != len(b.TypeOpts) {
+		return false
+	}
+	for i := range a.TypeOpts {
+		if a.TypeOpts[i] != b.TypeOpts[i] {
+			return false
+		}
+	}
+	return true
+}
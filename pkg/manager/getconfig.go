@@ -15,10 +15,16 @@
 package manager
 
 import (
+	changetypes "github.com/onosproject/onos-config/api/types/change"
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
 	networkchange "github.com/onosproject/onos-config/api/types/change/network"
 	devicetype "github.com/onosproject/onos-config/api/types/device"
 	"github.com/onosproject/onos-config/pkg/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sort"
+	"strconv"
+	"time"
 )
 
 // GetTargetConfig returns a set of change values given a target, a configuration name, a path and a layer.
@@ -30,18 +36,127 @@ func (m *Manager) GetTargetConfig(deviceID devicetype.ID, version devicetype.Ver
 		log.Error("Error while extracting config", errGetTargetCfg)
 		return nil, errGetTargetCfg
 	}
+	return filterPathValues(configValues, path), nil
+}
+
+// GetTargetConfigAt returns a set of change values given a target, a path and a network change.
+// The values are those of the configuration as it was right after the network change completed,
+// rebuilt from the snapshot of the device and the device changes that followed it
+func (m *Manager) GetTargetConfigAt(deviceID devicetype.ID, version devicetype.Version, path string,
+	networkChange *networkchange.NetworkChange) ([]*devicechange.PathValue, error) {
+	log.Infof("Getting config for %s at %s after change %s", deviceID, path, networkChange.ID)
+	if networkChange.Status.Phase == changetypes.Phase_CHANGE && networkChange.Status.State != changetypes.State_COMPLETE {
+		return nil, status.Errorf(codes.FailedPrecondition, "network change %s has not completed", networkChange.ID)
+	}
+
+	versionedID := devicetype.NewVersionedID(deviceID, version)
+	configValues := make([]*devicechange.PathValue, 0)
+	var snapshotIndex networkchange.Index
+	snapshot, err := m.DeviceSnapshotStore.Load(versionedID)
+	if err != nil {
+		return nil, err
+	} else if snapshot != nil {
+		deviceSnapshot, err := m.DeviceSnapshotStore.Get(snapshot.SnapshotID)
+		if err != nil {
+			return nil, err
+		} else if deviceSnapshot != nil {
+			snapshotIndex = networkchange.Index(deviceSnapshot.MaxNetworkChangeIndex)
+		}
+		if networkChange.Index < snapshotIndex {
+			return nil, status.Errorf(codes.FailedPrecondition,
+				"configuration of %s after network change %s has been compacted in to a snapshot", deviceID, networkChange.ID)
+		}
+		configValues = append(configValues, snapshot.Values...)
+	}
+
+	changeCh := make(chan *devicechange.DeviceChange)
+	ctx, err := m.DeviceChangesStore.List(versionedID, changeCh)
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+
+	deviceChanges := make([]*devicechange.DeviceChange, 0)
+	for deviceChange := range changeCh {
+		index := networkchange.Index(deviceChange.NetworkChange.Index)
+		if (snapshot != nil && index <= snapshotIndex) || index > networkChange.Index {
+			continue
+		}
+		// A change rolled back since the network change was applied is still part of the configuration
+		if deviceChange.Status.Phase == changetypes.Phase_ROLLBACK && index != networkChange.Index &&
+			!deviceChange.Updated.After(networkChange.Updated) {
+			continue
+		}
+		deviceChanges = append(deviceChanges, deviceChange)
+	}
+	sort.Slice(deviceChanges, func(i, j int) bool {
+		return deviceChanges[i].NetworkChange.Index < deviceChanges[j].NetworkChange.Index
+	})
+	for _, deviceChange := range deviceChanges {
+		configValues = ApplyChangeValues(configValues, deviceChange.Change.Values)
+	}
+	return filterPathValues(configValues, path), nil
+}
+
+// FindNetworkChange finds the NetworkChange with the given ID or else the given index. Otherwise the
+// reference is taken as an RFC 3339 timestamp and the last NetworkChange created by that time is found
+func (m *Manager) FindNetworkChange(ref string) (*networkchange.NetworkChange, error) {
+	networkChange, err := m.NetworkChangesStore.Get(networkchange.ID(ref))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	} else if networkChange != nil {
+		return networkChange, nil
+	}
+
+	if index, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		networkChange, err = m.NetworkChangesStore.GetByIndex(networkchange.Index(index))
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		} else if networkChange == nil {
+			return nil, status.Errorf(codes.NotFound, "no network change with index %d", index)
+		}
+		return networkChange, nil
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, ref)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "no network change with ID '%s' and not an index or timestamp", ref)
+	}
+	changeCh := make(chan *networkchange.NetworkChange)
+	ctx, err := m.NetworkChangesStore.List(changeCh)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	defer ctx.Close()
+	for change := range changeCh {
+		if !change.Created.After(timestamp) && (networkChange == nil || change.Index > networkChange.Index) {
+			networkChange = change
+		}
+	}
+	if networkChange == nil {
+		return nil, status.Errorf(codes.NotFound, "no network change created by %s", ref)
+	}
+	return networkChange, nil
+}
+
+// filterPathValues keeps only the values at paths matching the given path, which may contain wildcards
+func filterPathValues(configValues []*devicechange.PathValue, path string) []*devicechange.PathValue {
 	if len(configValues) == 0 {
-		return configValues, nil
+		return configValues
 	}
 	filteredValues := make([]*devicechange.PathValue, 0)
-	pathRegexp := utils.MatchWildcardRegexp(path)
+	pathMatcher, err := utils.ParsePathMatcher(path)
+	if err != nil {
+		log.Warnf("Unable to parse path %s %v", path, err)
+		return filteredValues
+	}
 	for _, cv := range configValues {
-		if pathRegexp.MatchString(cv.Path) {
+		if pathMatcher.MatchString(cv.Path) {
 			filteredValues = append(filteredValues, cv)
 		}
 	}
 	//TODO if filteredValue is empty return error
-	return filteredValues, nil
+	return filteredValues
 }
 
 // GetAllDeviceIds returns a list of just DeviceIDs from the device cache
@@ -25,10 +25,14 @@ func (m *Manager) GetTargetState(target string, path string) []*devicechange.Pat
 	log.Info("Getting State for ", target, path)
 	configValues := make([]*devicechange.PathValue, 0)
 	//First check the cache, if it's not empty for this path we read that and return,
-	pathRegexp := utils.MatchWildcardRegexp(path)
+	pathMatcher, err := utils.ParsePathMatcher(path)
+	if err != nil {
+		log.Warnf("Unable to parse path %s %v", path, err)
+		return configValues
+	}
 	m.OperationalStateCacheLock.RLock()
 	for pathCache, value := range m.OperationalStateCache[topodevice.ID(target)] {
-		if pathRegexp.MatchString(pathCache) {
+		if pathMatcher.MatchString(pathCache) {
 			configValues = append(configValues, &devicechange.PathValue{
 				Path:  pathCache,
 				Value: value,
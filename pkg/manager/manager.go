@@ -18,6 +18,7 @@ package manager
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
 	devicetype "github.com/onosproject/onos-config/api/types/device"
@@ -72,6 +73,10 @@ type Manager struct {
 	Dispatcher                *dispatcher.Dispatcher
 	OperationalStateCache     map[topodevice.ID]devicechange.TypedValueMap
 	OperationalStateCacheLock *sync.RWMutex
+	ConfigReplayMode          synchronizer.ReplayMode
+	DriftMonitor              *synchronizer.DriftMonitor
+	DriftAuditInterval        time.Duration
+	RemediationPolicy         *RemediationPolicy
 	allowUnvalidatedConfig    bool
 }
 
@@ -109,6 +114,8 @@ func NewManager(leadershipStore leadership.Store, mastershipStore mastership.Sto
 		Dispatcher:                dispatcher.NewDispatcher(),
 		OperationalStateCache:     make(map[topodevice.ID]devicechange.TypedValueMap),
 		OperationalStateCacheLock: &sync.RWMutex{},
+		ConfigReplayMode:          synchronizer.ReplayNone,
+		DriftMonitor:              synchronizer.NewDriftMonitor(),
 		allowUnvalidatedConfig:    allowUnvalidatedConfig,
 	}
 	return &mgr
@@ -156,6 +163,10 @@ func (m *Manager) Run() {
 		synchronizer.WithNewTargetFn(southbound.TargetGenerator),
 		synchronizer.WithOperationalStateCacheLock(m.OperationalStateCacheLock),
 		synchronizer.WithDeviceChangeStore(m.DeviceChangesStore),
+		synchronizer.WithDeviceSnapshotStore(m.DeviceSnapshotStore),
+		synchronizer.WithReplayMode(m.ConfigReplayMode),
+		synchronizer.WithDriftMonitor(m.DriftMonitor),
+		synchronizer.WithAuditInterval(m.DriftAuditInterval),
 		synchronizer.WithMastershipStore(m.MastershipStore),
 		synchronizer.WithDeviceStore(m.DeviceStore),
 		synchronizer.WithSessions(make(map[topodevice.ID]*synchronizer.Session)),
@@ -170,6 +181,11 @@ func (m *Manager) Run() {
 		log.Errorf("Error in starting session manager", err)
 	}
 
+	// Remediate the drift found by the audits of the devices that are opted in
+	if m.RemediationPolicy != nil {
+		go m.remediateDrift(m.RemediationPolicy)
+	}
+
 	log.Info("Manager Started")
 }
 
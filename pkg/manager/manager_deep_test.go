@@ -283,7 +283,7 @@ func Test_SetNetworkConfig_Deep(t *testing.T) {
 	updatesForDevice1, deletesForDevice1, deviceInfo := makeDeviceChanges(device1, updates, deletes)
 
 	// Verify the change
-	validationError := mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, deletes, 0)
+	validationError := mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, deletes, 0, nil)
 	assert.NilError(t, validationError, "ValidateTargetConfig error")
 
 	// Set the new change
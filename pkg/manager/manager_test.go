@@ -15,14 +15,20 @@
 package manager
 
 import (
+	"context"
 	"errors"
+	"fmt"
 
 	"github.com/golang/mock/gomock"
+	"github.com/onosproject/onos-config/api/types"
 	changetypes "github.com/onosproject/onos-config/api/types/change"
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
 	networkchange "github.com/onosproject/onos-config/api/types/change/network"
 	devicetype "github.com/onosproject/onos-config/api/types/device"
+	devicesnapshot "github.com/onosproject/onos-config/api/types/snapshot/device"
 	"github.com/onosproject/onos-config/pkg/modelregistry"
+	"github.com/onosproject/onos-config/pkg/southbound/synchronizer"
+	devicestore "github.com/onosproject/onos-config/pkg/store/change/device"
 	networkstore "github.com/onosproject/onos-config/pkg/store/change/network"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	"github.com/onosproject/onos-config/pkg/store/stream"
@@ -32,6 +38,8 @@ import (
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gotest.tools/assert"
 
 	"strings"
@@ -324,7 +332,7 @@ func Test_SetNetworkConfig(t *testing.T) {
 	updatesForDevice1, deletesForDevice1, deviceInfo := makeDeviceChanges(device1, updates, deletes)
 
 	// Verify the change
-	validationError := mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, deletes, 0)
+	validationError := mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, deletes, 0, nil)
 	assert.NilError(t, validationError, "ValidateTargetConfig error")
 
 	// Set the new change
@@ -510,7 +518,7 @@ func TestManager_ComputeRollbackFailure(t *testing.T) {
 
 	updatesForDevice1, deletesForDevice1, deviceInfo := makeDeviceChanges(device1, updates, deletes)
 
-	err := mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, deletes, 0)
+	err := mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, deletes, 0, nil)
 	assert.NilError(t, err, "ValidateTargetConfig error")
 	_, err = mgrTest.SetNetworkConfig(updatesForDevice1, deletesForDevice1, deviceInfo, "TestingRollback")
 	assert.NilError(t, err, "Can't create change", err)
@@ -519,7 +527,7 @@ func TestManager_ComputeRollbackFailure(t *testing.T) {
 	updates[test1Cont1ACont2ALeaf2D] = devicechange.NewTypedValueFloat(valueLeaf2D123)
 	deletes = append(deletes, test1Cont1ACont2ALeaf2A)
 
-	err = mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, deletes, 0)
+	err = mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, deletes, 0, nil)
 	assert.NilError(t, err, "ValidateTargetConfig error")
 
 	updatesForDevice1, deletesForDevice1, deviceInfo = makeDeviceChanges(device1, updates, deletes)
@@ -547,7 +555,7 @@ func TestManager_ComputeRollbackDelete(t *testing.T) {
 
 	updatesForDevice1, deletesForDevice1, deviceInfo := makeDeviceChanges(device1, updates, deletes)
 
-	err := mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, deletes, 0)
+	err := mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, deletes, 0, nil)
 	assert.NilError(t, err, "ValidateTargetConfig error")
 	_, err = mgrTest.SetNetworkConfig(updatesForDevice1, deletesForDevice1, deviceInfo, "TestingRollback")
 	assert.NilError(t, err, "Can't create change", err)
@@ -556,7 +564,7 @@ func TestManager_ComputeRollbackDelete(t *testing.T) {
 	updates[test1Cont1ACont2ALeaf2D] = devicechange.NewTypedValueFloat(valueLeaf2D123)
 	deletes = append(deletes, test1Cont1ACont2ALeaf2A)
 
-	err = mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, deletes, 0)
+	err = mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, deletes, 0, nil)
 	assert.NilError(t, err, "ValidateTargetConfig error")
 
 	updatesForDevice1, deletesForDevice1, deviceInfo = makeDeviceChanges(device1, updates, deletes)
@@ -628,6 +636,38 @@ func TestManager_GetTargetState(t *testing.T) {
 	assert.Assert(t, len(stateBad) == 0, "Bad path entry has incorrect length %d", len(stateBad))
 }
 
+func TestManager_GetTargetStateKeys(t *testing.T) {
+	const (
+		device1 = "device1"
+		path1   = "/interfaces/interface[name=eth1/1]/state/mtu"
+		path2   = "/interfaces/interface[name=eth1]/state/mtu"
+	)
+	mgrTest, _ := setUp(t)
+
+	mgrTest.OperationalStateCacheLock.Lock()
+	mgrTest.OperationalStateCache[device1] = map[string]*devicechange.TypedValue{
+		path1: devicechange.NewTypedValueUint64(1500),
+		path2: devicechange.NewTypedValueUint64(9000),
+	}
+	mgrTest.OperationalStateCacheLock.Unlock()
+
+	// A key value is compared as a whole
+	state := mgrTest.GetTargetState(device1, "/interfaces/interface[name=eth1/1]")
+	assert.Equal(t, len(state), 1)
+	assert.Equal(t, state[0].Path, path1)
+
+	state = mgrTest.GetTargetState(device1, "/interfaces/interface[name=eth1]")
+	assert.Equal(t, len(state), 1)
+	assert.Equal(t, state[0].Path, path2)
+
+	// Any number of elements can be skipped
+	state = mgrTest.GetTargetState(device1, "/.../mtu")
+	assert.Equal(t, len(state), 2)
+
+	state = mgrTest.GetTargetState(device1, "/.../config/mtu")
+	assert.Equal(t, len(state), 0)
+}
+
 type MockModelPlugin struct{}
 
 func (m MockModelPlugin) ModelData() (string, string, []*gnmi.ModelData, string) {
@@ -822,3 +862,572 @@ func TestManager_CheckCacheForDevice(t *testing.T) {
 	_, _, err = mgrTest.CheckCacheForDevice(deviceTest3, "", "")
 	assert.ErrorContains(t, err, "DeviceTest3 is not known. Need to supply a type and version through Extensions 101 and 102")
 }
+
+func TestManager_WaitForNetworkConfigFailed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockNetworkChangesStore := mockstore.NewMockNetworkChangesStore(ctrl)
+	mockDeviceChangesStore := mockstore.NewMockDeviceChangesStore(ctrl)
+	mgrTest := &Manager{
+		NetworkChangesStore: mockNetworkChangesStore,
+		DeviceChangesStore:  mockDeviceChangesStore,
+	}
+
+	change1 := devicechange.Change{
+		DeviceID:      device1,
+		DeviceVersion: deviceVersion1,
+	}
+	failedDeviceChange := &devicechange.DeviceChange{
+		ID:     deviceChange1,
+		Change: &change1,
+		Status: changetypes.Status{
+			Phase:       changetypes.Phase_CHANGE,
+			State:       changetypes.State_FAILED,
+			Reason:      changetypes.Reason_ERROR,
+			Message:     "rpc error: code = InvalidArgument desc = bad value",
+			Incarnation: 1,
+		},
+	}
+	pendingChange := &networkchange.NetworkChange{
+		ID:      networkChange1,
+		Changes: []*devicechange.Change{&change1},
+		Refs:    []*networkchange.DeviceChangeRef{{DeviceChangeID: deviceChange1}},
+		Status: changetypes.Status{
+			Phase:       changetypes.Phase_CHANGE,
+			State:       changetypes.State_PENDING,
+			Incarnation: 1,
+		},
+	}
+
+	mockNetworkChangesStore.EXPECT().Watch(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(c chan<- stream.Event, opts ...networkstore.WatchOption) (stream.Context, error) {
+			go func() {
+				c <- stream.Event{Type: stream.None, Object: pendingChange}
+			}()
+			return stream.NewContext(func() {}), nil
+		})
+	mockDeviceChangesStore.EXPECT().Watch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(id devicetype.VersionedID, c chan<- stream.Event, opts ...devicestore.WatchOption) (stream.Context, error) {
+			go func() {
+				c <- stream.Event{Type: stream.Updated, Object: failedDeviceChange}
+				close(c)
+			}()
+			return stream.NewContext(func() {}), nil
+		})
+	mockDeviceChangesStore.EXPECT().Get(deviceChange1).Return(failedDeviceChange, nil).AnyTimes()
+
+	err := mgrTest.WaitForNetworkConfig(context.Background(), networkChange1, 5*time.Second)
+	assert.ErrorContains(t, err, "Device1: rpc error: code = InvalidArgument desc = bad value")
+	assert.Equal(t, status.Code(err), codes.Aborted)
+}
+
+func TestManager_WaitForNetworkConfigClosesWatches(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockNetworkChangesStore := mockstore.NewMockNetworkChangesStore(ctrl)
+	mockDeviceChangesStore := mockstore.NewMockDeviceChangesStore(ctrl)
+	mgrTest := &Manager{
+		NetworkChangesStore: mockNetworkChangesStore,
+		DeviceChangesStore:  mockDeviceChangesStore,
+	}
+
+	change1 := devicechange.Change{
+		DeviceID:      device1,
+		DeviceVersion: deviceVersion1,
+	}
+	completeChange := &networkchange.NetworkChange{
+		ID:      networkChange1,
+		Changes: []*devicechange.Change{&change1},
+		Refs:    []*networkchange.DeviceChangeRef{{DeviceChangeID: deviceChange1}},
+		Status: changetypes.Status{
+			Phase: changetypes.Phase_CHANGE,
+			State: changetypes.State_COMPLETE,
+		},
+	}
+
+	mockNetworkChangesStore.EXPECT().Watch(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(c chan<- stream.Event, opts ...networkstore.WatchOption) (stream.Context, error) {
+			go func() {
+				c <- stream.Event{Type: stream.None, Object: completeChange}
+			}()
+			return stream.NewContext(func() {}), nil
+		})
+
+	// The device change watch gets no more events once the change is complete
+	closed := make(chan struct{})
+	mockDeviceChangesStore.EXPECT().Watch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(id devicetype.VersionedID, c chan<- stream.Event, opts ...devicestore.WatchOption) (stream.Context, error) {
+			return stream.NewContext(func() {
+				close(closed)
+				close(c)
+			}), nil
+		})
+
+	err := mgrTest.WaitForNetworkConfig(context.Background(), networkChange1, 5*time.Second)
+	assert.NilError(t, err)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("device change watch was not closed")
+	}
+}
+
+func TestManager_WaitForNetworkConfigTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockNetworkChangesStore := mockstore.NewMockNetworkChangesStore(ctrl)
+	mgrTest := &Manager{
+		NetworkChangesStore: mockNetworkChangesStore,
+	}
+
+	mockNetworkChangesStore.EXPECT().Watch(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(c chan<- stream.Event, opts ...networkstore.WatchOption) (stream.Context, error) {
+			go func() {
+				c <- stream.Event{Type: stream.None, Object: &networkchange.NetworkChange{ID: networkChange1}}
+			}()
+			return stream.NewContext(func() {}), nil
+		})
+
+	err := mgrTest.WaitForNetworkConfig(context.Background(), networkChange1, 100*time.Millisecond)
+	assert.Equal(t, status.Code(err), codes.DeadlineExceeded)
+}
+
+func TestManager_WaitForNetworkConfigCanceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockNetworkChangesStore := mockstore.NewMockNetworkChangesStore(ctrl)
+	mgrTest := &Manager{
+		NetworkChangesStore: mockNetworkChangesStore,
+	}
+
+	closed := make(chan struct{})
+	mockNetworkChangesStore.EXPECT().Watch(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(c chan<- stream.Event, opts ...networkstore.WatchOption) (stream.Context, error) {
+			go func() {
+				c <- stream.Event{Type: stream.None, Object: &networkchange.NetworkChange{ID: networkChange1}}
+			}()
+			return stream.NewContext(func() {
+				close(closed)
+			}), nil
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	err := mgrTest.WaitForNetworkConfig(ctx, networkChange1, 5*time.Second)
+	assert.Equal(t, status.Code(err), codes.Canceled)
+	select {
+	case <-closed:
+	default:
+		t.Fatal("network change watch was not closed")
+	}
+}
+
+func TestManager_DiffConfig(t *testing.T) {
+	config := []*devicechange.PathValue{
+		{Path: "/cont1a/cont2a/leaf2a", Value: devicechange.NewTypedValueUint64(13)},
+		{Path: "/cont1a/cont2a/leaf2b", Value: devicechange.NewTypedValueDecimal64(1234, 2)},
+		{Path: "/cont1a/list2a[name=first]/name", Value: devicechange.NewTypedValueString("first")},
+		{Path: "/cont1a/list2a[name=first]/tx-power", Value: devicechange.NewTypedValueUint64(5)},
+		{Path: "/cont1a/leaf1a", Value: devicechange.NewTypedValueString("leaf1a")},
+	}
+	values := []*devicechange.ChangeValue{
+		{Path: "/cont1a/cont2a/leaf2a", Value: devicechange.NewTypedValueUint64(14)},
+		{Path: "/cont1a/cont2a/leaf2b", Value: devicechange.NewTypedValueDecimal64(1234, 2)},
+		{Path: "/cont1a/cont2a/leaf2c", Value: devicechange.NewTypedValueString("new")},
+		{Path: "/cont1a/list2a[name=first]", Removed: true},
+	}
+
+	diffs := DiffConfig(config, ApplyChangeValues(config, values))
+	assert.Equal(t, len(diffs), 4)
+	assert.Equal(t, diffs[0].Path, "/cont1a/cont2a/leaf2a")
+	assert.Equal(t, diffs[0].OldValue.ValueToString(), "13")
+	assert.Equal(t, diffs[0].NewValue.ValueToString(), "14")
+	assert.Equal(t, diffs[1].Path, "/cont1a/cont2a/leaf2c")
+	assert.Assert(t, diffs[1].OldValue == nil)
+	assert.Equal(t, diffs[1].NewValue.ValueToString(), "new")
+	assert.Equal(t, diffs[2].Path, "/cont1a/list2a[name=first]/name")
+	assert.Assert(t, diffs[2].NewValue == nil)
+	assert.Equal(t, diffs[3].Path, "/cont1a/list2a[name=first]/tx-power")
+	assert.Assert(t, diffs[3].NewValue == nil)
+}
+
+func TestManager_ApplyChangeValuesListRemove(t *testing.T) {
+	config := []*devicechange.PathValue{
+		{Path: "/cont1a/cont2a/leaf2a", Value: devicechange.NewTypedValueUint64(13)},
+		{Path: "/cont1a/list2a[name=first]/name", Value: devicechange.NewTypedValueString("first")},
+		{Path: "/cont1a/list2a[name=first]/tx-power", Value: devicechange.NewTypedValueUint64(5)},
+		{Path: "/cont1a/list2a[name=second]/name", Value: devicechange.NewTypedValueString("second")},
+		{Path: "/cont1a/list2ab", Value: devicechange.NewTypedValueString("other")},
+	}
+
+	// Removing the list without keys removes all of its entries
+	newConfig := ApplyChangeValues(config, []*devicechange.ChangeValue{
+		{Path: "/cont1a/list2a", Removed: true},
+	})
+	assert.Equal(t, len(newConfig), 2)
+	assert.Equal(t, newConfig[0].Path, "/cont1a/cont2a/leaf2a")
+	assert.Equal(t, newConfig[1].Path, "/cont1a/list2ab")
+
+	diffs := DiffConfig(config, newConfig)
+	assert.Equal(t, len(diffs), 3)
+	for _, diff := range diffs {
+		assert.Assert(t, strings.HasPrefix(diff.Path, "/cont1a/list2a["))
+		assert.Assert(t, diff.NewValue == nil)
+	}
+}
+
+func TestManager_GetTargetConfigAt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDeviceSnapshotStore := mockstore.NewMockDeviceSnapshotStore(ctrl)
+	mockDeviceChangesStore := mockstore.NewMockDeviceChangesStore(ctrl)
+	mgrTest := &Manager{
+		DeviceSnapshotStore: mockDeviceSnapshotStore,
+		DeviceChangesStore:  mockDeviceChangesStore,
+	}
+
+	now := time.Now()
+	mockDeviceSnapshotStore.EXPECT().Load(gomock.Any()).Return(&devicesnapshot.Snapshot{
+		DeviceID:      device1,
+		DeviceVersion: deviceVersion1,
+		SnapshotID:    "snapshot-1",
+		Values: []*devicechange.PathValue{
+			{Path: test1Cont1ACont2ALeaf2A, Value: devicechange.NewTypedValueUint64(1)},
+		},
+	}, nil).AnyTimes()
+	mockDeviceSnapshotStore.EXPECT().Get(devicesnapshot.ID("snapshot-1")).Return(&devicesnapshot.DeviceSnapshot{
+		ID:                    "snapshot-1",
+		MaxNetworkChangeIndex: 2,
+	}, nil).AnyTimes()
+
+	newDeviceChange := func(index networkchange.Index, path string, value *devicechange.TypedValue,
+		phase changetypes.Phase, updated time.Time) *devicechange.DeviceChange {
+		return &devicechange.DeviceChange{
+			NetworkChange: devicechange.NetworkChangeRef{Index: types.Index(index)},
+			Change: &devicechange.Change{
+				DeviceID:      device1,
+				DeviceVersion: deviceVersion1,
+				Values:        []*devicechange.ChangeValue{{Path: path, Value: value}},
+			},
+			Status:  changetypes.Status{Phase: phase, State: changetypes.State_COMPLETE},
+			Updated: updated,
+		}
+	}
+	deviceChanges := []*devicechange.DeviceChange{
+		// Already in the snapshot
+		newDeviceChange(2, test1Cont1ACont2ALeaf2A, devicechange.NewTypedValueUint64(2), changetypes.Phase_CHANGE, now),
+		newDeviceChange(3, test1Cont1ACont2ALeaf2B, devicechange.NewTypedValueUint64(3), changetypes.Phase_CHANGE, now),
+		// Rolled back before the change at index 5 was applied
+		newDeviceChange(4, test1Cont1ACont2ALeaf2C, devicechange.NewTypedValueUint64(4), changetypes.Phase_ROLLBACK,
+			now.Add(-time.Minute)),
+		newDeviceChange(5, test1Cont1ACont2ALeaf2A, devicechange.NewTypedValueUint64(5), changetypes.Phase_CHANGE, now),
+		// After the change at index 5
+		newDeviceChange(6, test1Cont1ACont2ALeaf2A, devicechange.NewTypedValueUint64(6), changetypes.Phase_CHANGE, now),
+	}
+	mockDeviceChangesStore.EXPECT().List(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(id devicetype.VersionedID, ch chan<- *devicechange.DeviceChange) (stream.Context, error) {
+			go func() {
+				for _, deviceChange := range deviceChanges {
+					ch <- deviceChange
+				}
+				close(ch)
+			}()
+			return stream.NewContext(func() {}), nil
+		}).AnyTimes()
+
+	networkChange := &networkchange.NetworkChange{
+		ID:      networkChange1,
+		Index:   5,
+		Status:  changetypes.Status{Phase: changetypes.Phase_CHANGE, State: changetypes.State_COMPLETE},
+		Updated: now.Add(-time.Second),
+	}
+	configValues, err := mgrTest.GetTargetConfigAt(device1, deviceVersion1, "/cont1a/*", networkChange)
+	assert.NilError(t, err)
+	assert.Equal(t, len(configValues), 2)
+	assert.Equal(t, configValues[0].Path, test1Cont1ACont2ALeaf2A)
+	assert.Equal(t, configValues[0].Value.ValueToString(), "5")
+	assert.Equal(t, configValues[1].Path, test1Cont1ACont2ALeaf2B)
+	assert.Equal(t, configValues[1].Value.ValueToString(), "3")
+
+	// Before the snapshot
+	networkChange.Index = 1
+	_, err = mgrTest.GetTargetConfigAt(device1, deviceVersion1, "/cont1a/*", networkChange)
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition)
+
+	networkChange.Index = 5
+	networkChange.Status.State = changetypes.State_PENDING
+	_, err = mgrTest.GetTargetConfigAt(device1, deviceVersion1, "/cont1a/*", networkChange)
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition)
+}
+
+func TestManager_ComputeNetworkChangeDiff(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockNetworkChangesStore := mockstore.NewMockNetworkChangesStore(ctrl)
+	mockDeviceChangesStore := mockstore.NewMockDeviceChangesStore(ctrl)
+	mockDeviceSnapshotStore := mockstore.NewMockDeviceSnapshotStore(ctrl)
+	mgrTest := &Manager{
+		NetworkChangesStore: mockNetworkChangesStore,
+		DeviceChangesStore:  mockDeviceChangesStore,
+		DeviceSnapshotStore: mockDeviceSnapshotStore,
+	}
+
+	newChange := func(path string, value *devicechange.TypedValue, removed bool) *devicechange.Change {
+		return &devicechange.Change{
+			DeviceID:      device1,
+			DeviceVersion: deviceVersion1,
+			Values:        []*devicechange.ChangeValue{{Path: path, Value: value, Removed: removed}},
+		}
+	}
+	changes := []*devicechange.Change{
+		newChange(test1Cont1ACont2ALeaf2A, devicechange.NewTypedValueUint64(1), false),
+		newChange(test1Cont1ACont2ALeaf2B, devicechange.NewTypedValueUint64(2), false),
+		newChange(test1Cont1ACont2ALeaf2A, devicechange.NewTypedValueUint64(3), false),
+		newChange(test1Cont1ACont2ALeaf2B, nil, true),
+		newChange(test1Cont1ACont2ALeaf2C, devicechange.NewTypedValueUint64(4), false),
+	}
+	networkChanges := make([]*networkchange.NetworkChange, 0, len(changes))
+	deviceChanges := make([]*devicechange.DeviceChange, 0, len(changes))
+	for i, change := range changes {
+		networkChanges = append(networkChanges, &networkchange.NetworkChange{
+			ID:      networkchange.ID(fmt.Sprintf("change-%d", i)),
+			Index:   networkchange.Index(i),
+			Changes: []*devicechange.Change{change},
+			Status:  changetypes.Status{Phase: changetypes.Phase_CHANGE, State: changetypes.State_COMPLETE},
+		})
+		deviceChanges = append(deviceChanges, &devicechange.DeviceChange{
+			NetworkChange: devicechange.NetworkChangeRef{Index: types.Index(i)},
+			Change:        change,
+			Status:        changetypes.Status{Phase: changetypes.Phase_CHANGE, State: changetypes.State_COMPLETE},
+		})
+	}
+	mockNetworkChangesStore.EXPECT().List(gomock.Any()).DoAndReturn(
+		func(ch chan<- *networkchange.NetworkChange) (stream.Context, error) {
+			go func() {
+				for _, networkChange := range networkChanges {
+					ch <- networkChange
+				}
+				close(ch)
+			}()
+			return stream.NewContext(func() {}), nil
+		}).AnyTimes()
+	mockDeviceChangesStore.EXPECT().List(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(id devicetype.VersionedID, ch chan<- *devicechange.DeviceChange) (stream.Context, error) {
+			go func() {
+				for _, deviceChange := range deviceChanges {
+					ch <- deviceChange
+				}
+				close(ch)
+			}()
+			return stream.NewContext(func() {}), nil
+		}).AnyTimes()
+
+	var snapshot *devicesnapshot.Snapshot
+	mockDeviceSnapshotStore.EXPECT().Load(gomock.Any()).DoAndReturn(
+		func(id devicetype.VersionedID) (*devicesnapshot.Snapshot, error) {
+			return snapshot, nil
+		}).AnyTimes()
+	mockDeviceSnapshotStore.EXPECT().Get(devicesnapshot.ID("snapshot-1")).Return(&devicesnapshot.DeviceSnapshot{
+		ID:                    "snapshot-1",
+		MaxNetworkChangeIndex: 2,
+	}, nil).AnyTimes()
+
+	deviceDiffs, err := mgrTest.ComputeNetworkChangeDiff(networkChanges[1], networkChanges[4], "")
+	assert.NilError(t, err)
+	assert.Equal(t, len(deviceDiffs), 1)
+	diffs := deviceDiffs[devicetype.NewVersionedID(device1, deviceVersion1)]
+	assert.Equal(t, len(diffs), 3)
+	assert.Equal(t, diffs[0].Path, test1Cont1ACont2ALeaf2A)
+	assert.Equal(t, diffs[0].OldValue.ValueToString(), "1")
+	assert.Equal(t, diffs[0].NewValue.ValueToString(), "3")
+	assert.Equal(t, diffs[1].Path, test1Cont1ACont2ALeaf2B)
+	assert.Equal(t, diffs[1].OldValue.ValueToString(), "2")
+	assert.Assert(t, diffs[1].NewValue == nil)
+	assert.Equal(t, diffs[2].Path, test1Cont1ACont2ALeaf2C)
+	assert.Assert(t, diffs[2].OldValue == nil)
+	assert.Equal(t, diffs[2].NewValue.ValueToString(), "4")
+
+	// Reversing the changes reverses the differences
+	deviceDiffs, err = mgrTest.ComputeNetworkChangeDiff(networkChanges[4], networkChanges[1], "")
+	assert.NilError(t, err)
+	diffs = deviceDiffs[devicetype.NewVersionedID(device1, deviceVersion1)]
+	assert.Equal(t, len(diffs), 3)
+	assert.Equal(t, diffs[0].OldValue.ValueToString(), "3")
+	assert.Equal(t, diffs[0].NewValue.ValueToString(), "1")
+
+	// No change to another device
+	deviceDiffs, err = mgrTest.ComputeNetworkChangeDiff(networkChanges[1], networkChanges[4], "Device2")
+	assert.NilError(t, err)
+	assert.Equal(t, len(deviceDiffs), 0)
+
+	// The changes up to index 2 have been compacted in to a snapshot
+	snapshot = &devicesnapshot.Snapshot{
+		DeviceID:      device1,
+		DeviceVersion: deviceVersion1,
+		SnapshotID:    "snapshot-1",
+		Values: []*devicechange.PathValue{
+			{Path: test1Cont1ACont2ALeaf2A, Value: devicechange.NewTypedValueUint64(3)},
+			{Path: test1Cont1ACont2ALeaf2B, Value: devicechange.NewTypedValueUint64(2)},
+		},
+	}
+	deviceDiffs, err = mgrTest.ComputeNetworkChangeDiff(networkChanges[2], networkChanges[4], "")
+	assert.NilError(t, err)
+	diffs = deviceDiffs[devicetype.NewVersionedID(device1, deviceVersion1)]
+	assert.Equal(t, len(diffs), 2)
+	assert.Equal(t, diffs[0].Path, test1Cont1ACont2ALeaf2B)
+	assert.Assert(t, diffs[0].NewValue == nil)
+	assert.Equal(t, diffs[1].Path, test1Cont1ACont2ALeaf2C)
+	assert.Equal(t, diffs[1].NewValue.ValueToString(), "4")
+
+	_, err = mgrTest.ComputeNetworkChangeDiff(networkChanges[1], networkChanges[4], "")
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition)
+}
+
+func TestManager_ValidateNetworkConfigPreconditions(t *testing.T) {
+	mgrTest, _ := setUp(t)
+
+	updates := make(devicechange.TypedValueMap)
+	updates[test1Cont1ACont2ALeaf2B] = devicechange.NewTypedValueDecimal64(1234, 3)
+	storedValue := devicechange.NewTypedValueFloat(valueLeaf2B159).ValueToString()
+
+	err := mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, nil, 0, []*Precondition{
+		{Path: test1Cont1ACont2ALeaf2A, Value: storedValue},
+		{Path: test1Cont1ACont2ALeaf2B, Absent: true},
+	})
+	assert.NilError(t, err)
+
+	err = mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, nil, 0, []*Precondition{
+		{Path: test1Cont1ACont2ALeaf2A, Value: "1.0"},
+	})
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition)
+
+	err = mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, nil, 0, []*Precondition{
+		{Path: test1Cont1ACont2ALeaf2A, Absent: true},
+	})
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition)
+
+	err = mgrTest.ValidateNetworkConfig(device1, deviceVersion1, deviceTypeTd, updates, nil, 0, []*Precondition{
+		{Path: test1Cont1ACont2ALeaf2C, Value: "abc"},
+	})
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition)
+}
+
+func TestManager_RemediationPolicy(t *testing.T) {
+	_, err := NewRemediationPolicy(nil, nil, []string{"/cont1a/cont2a["}, false)
+	assert.Assert(t, err != nil)
+
+	policy, err := NewRemediationPolicy([]string{device1}, []string{"Devicesim"},
+		[]string{test1Cont1ACont2ALeaf2C, "/cont1a/list2a[name=*]/tx-power"}, false)
+	assert.NilError(t, err)
+	assert.Assert(t, policy.Applies(device1, deviceTypeTd))
+	assert.Assert(t, policy.Applies("Device2", "Devicesim"))
+	assert.Assert(t, !policy.Applies("Device2", deviceTypeTd))
+
+	assert.Assert(t, policy.Excludes(test1Cont1ACont2ALeaf2C))
+	assert.Assert(t, policy.Excludes("/cont1a/list2a[name=first]/tx-power"))
+	assert.Assert(t, !policy.Excludes(test1Cont1ACont2ALeaf2A))
+	assert.Assert(t, !policy.Excludes("/cont1a/list2a[name=first]/name"))
+}
+
+func TestManager_RemediateDeviceDrift(t *testing.T) {
+	mgrTest, _ := setUp(t)
+
+	policy, err := NewRemediationPolicy([]string{device1}, nil, []string{test1Cont1ACont2ALeaf2C}, true)
+	assert.NilError(t, err)
+
+	audited := time.Now()
+	drift := &synchronizer.DeviceDrift{
+		DeviceID:   devicetype.NewVersionedID(device1, deviceVersion1),
+		DeviceType: deviceTypeTd,
+		Audited:    audited,
+		Drifts: []*synchronizer.PathDrift{
+			{
+				Path:     test1Cont1ACont2ALeaf2A,
+				Intended: devicechange.NewTypedValueFloat(valueLeaf2B159),
+				Actual:   devicechange.NewTypedValueFloat(valueLeaf2B314),
+			},
+			{
+				Path:     test1Cont1ACont2ALeaf2C,
+				Intended: devicechange.NewTypedValueString("abc"),
+				Actual:   devicechange.NewTypedValueString("def"),
+			},
+		},
+		Unmanaged: []*devicechange.PathValue{
+			{
+				Path:  test1Cont1ACont2ALeaf2B,
+				Value: devicechange.NewTypedValueFloat(valueLeaf2D123),
+			},
+		},
+	}
+
+	change, err := mgrTest.RemediateDeviceDrift(drift, policy)
+	assert.NilError(t, err)
+	assert.Assert(t, change != nil)
+	assert.Assert(t, strings.HasPrefix(string(change.ID), "remediation-"))
+
+	stored, err := mgrTest.NetworkChangesStore.Get(change.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, stored, change)
+
+	assert.Equal(t, len(change.Changes), 1)
+	assert.Equal(t, change.Changes[0].DeviceID, devicetype.ID(device1))
+	assert.Equal(t, change.Changes[0].DeviceType, devicetype.Type(deviceTypeTd))
+	values := make(map[string]*devicechange.ChangeValue)
+	for _, value := range change.Changes[0].Values {
+		values[value.Path] = value
+	}
+	assert.Equal(t, len(values), 2)
+	assert.Equal(t, values[test1Cont1ACont2ALeaf2A].Value.ValueToString(),
+		devicechange.NewTypedValueFloat(valueLeaf2B159).ValueToString())
+	assert.Assert(t, !values[test1Cont1ACont2ALeaf2A].Removed)
+	assert.Assert(t, values[test1Cont1ACont2ALeaf2B].Removed)
+
+	remediation := change.Remediation
+	assert.Assert(t, remediation != nil)
+	assert.Equal(t, remediation.DeviceID, devicetype.ID(device1))
+	assert.Equal(t, remediation.DeviceVersion, devicetype.Version(deviceVersion1))
+	assert.Equal(t, remediation.Audited, audited)
+	assert.Equal(t, len(remediation.Overwritten), 2)
+	assert.Equal(t, remediation.Overwritten[0].Path, test1Cont1ACont2ALeaf2A)
+	assert.Equal(t, remediation.Overwritten[0].Value.ValueToString(),
+		devicechange.NewTypedValueFloat(valueLeaf2B314).ValueToString())
+	assert.Equal(t, remediation.Overwritten[1].Path, test1Cont1ACont2ALeaf2B)
+	assert.Equal(t, remediation.Overwritten[1].Value.ValueToString(),
+		devicechange.NewTypedValueFloat(valueLeaf2D123).ValueToString())
+	assert.DeepEqual(t, remediation.Excluded, []string{test1Cont1ACont2ALeaf2C})
+
+	// Unmanaged paths are left as they are unless the policy removes them
+	policy, err = NewRemediationPolicy([]string{device1}, nil, []string{test1Cont1ACont2ALeaf2C}, false)
+	assert.NilError(t, err)
+	change, err = mgrTest.RemediateDeviceDrift(drift, policy)
+	assert.NilError(t, err)
+	assert.Assert(t, change != nil)
+	assert.Equal(t, len(change.Changes[0].Values), 1)
+	assert.Equal(t, change.Changes[0].Values[0].Path, test1Cont1ACont2ALeaf2A)
+	assert.Assert(t, !change.Changes[0].Values[0].Removed)
+	assert.Equal(t, len(change.Remediation.Overwritten), 1)
+	assert.DeepEqual(t, change.Remediation.Excluded, []string{test1Cont1ACont2ALeaf2C})
+
+	// Nothing to remediate when only excluded paths have drifted
+	drift.Drifts = drift.Drifts[1:]
+	change, err = mgrTest.RemediateDeviceDrift(drift, policy)
+	assert.NilError(t, err)
+	assert.Assert(t, change == nil)
+}
+
+func TestManager_IncompleteDeviceChanges(t *testing.T) {
+	mgrTest, _ := setUp(t)
+	deviceID := devicetype.NewVersionedID(device1, deviceVersion1)
+
+	incomplete, err := mgrTest.incompleteDeviceChanges(deviceID)
+	assert.NilError(t, err)
+	assert.Equal(t, len(incomplete), 0)
+
+	pending := &devicechange.DeviceChange{
+		ID: "pending-" + device1,
+		Change: &devicechange.Change{
+			DeviceID:      device1,
+			DeviceVersion: deviceVersion1,
+		},
+		Status: changetypes.Status{State: changetypes.State_PENDING},
+	}
+	assert.NilError(t, mgrTest.DeviceChangesStore.Create(pending))
+
+	incomplete, err = mgrTest.incompleteDeviceChanges(deviceID)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, incomplete, []devicechange.ID{pending.ID})
+}
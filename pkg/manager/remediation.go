@@ -0,0 +1,203 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"github.com/docker/docker/pkg/namesgenerator"
+	changetypes "github.com/onosproject/onos-config/api/types/change"
+	devicechange "github.com/onosproject/onos-config/api/types/change/device"
+	networkchange "github.com/onosproject/onos-config/api/types/change/network"
+	devicetype "github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-config/pkg/southbound/synchronizer"
+	"github.com/onosproject/onos-config/pkg/utils"
+)
+
+// RemediationPolicy sets which devices have their configuration drift remediated automatically,
+// which paths are left as they are on those devices, and whether the paths that are not stored
+// are removed from them
+type RemediationPolicy struct {
+	devices         map[devicetype.ID]bool
+	deviceTypes     map[devicetype.Type]bool
+	excluded        []*utils.PathMatcher
+	removeUnmanaged bool
+}
+
+// NewRemediationPolicy creates a policy for the given devices and types of device. The excluded
+// paths may contain the * and ... wildcards, and exclude everything under them. The paths on the
+// devices that are not stored are only removed if removeUnmanaged is set
+func NewRemediationPolicy(devices []string, deviceTypes []string, excluded []string, removeUnmanaged bool) (*RemediationPolicy, error) {
+	policy := &RemediationPolicy{
+		devices:         make(map[devicetype.ID]bool),
+		deviceTypes:     make(map[devicetype.Type]bool),
+		excluded:        make([]*utils.PathMatcher, 0, len(excluded)),
+		removeUnmanaged: removeUnmanaged,
+	}
+	for _, device := range devices {
+		policy.devices[devicetype.ID(device)] = true
+	}
+	for _, deviceType := range deviceTypes {
+		policy.deviceTypes[devicetype.Type(deviceType)] = true
+	}
+	for _, path := range excluded {
+		matcher, err := utils.ParsePathMatcher(path)
+		if err != nil {
+			return nil, err
+		}
+		policy.excluded = append(policy.excluded, matcher)
+	}
+	return policy, nil
+}
+
+// Applies returns true if the device, or its type, is opted in to remediation
+func (p *RemediationPolicy) Applies(deviceID devicetype.ID, deviceType devicetype.Type) bool {
+	return p.devices[deviceID] || p.deviceTypes[deviceType]
+}
+
+// Excludes returns true if the path is to be left as it is on the device
+func (p *RemediationPolicy) Excludes(path string) bool {
+	for _, matcher := range p.excluded {
+		if matcher.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// remediateDrift remediates the drift found by the audits of the devices the policy applies to,
+// as the drift monitor gives it. Drift that does not change is not given again, so a remediation
+// that fails, or is skipped while changes to the device have not completed, is not retried until
+// the drift of the device changes
+func (m *Manager) remediateDrift(policy *RemediationPolicy) {
+	driftCh := make(chan *synchronizer.DeviceDrift, 10)
+	m.DriftMonitor.Watch(driftCh)
+	remediations := make(map[devicetype.ID]networkchange.ID)
+	for drift := range driftCh {
+		deviceID := drift.DeviceID.GetID()
+		if !policy.Applies(deviceID, drift.DeviceType) ||
+			(len(drift.Drifts) == 0 && (!policy.removeUnmanaged || len(drift.Unmanaged) == 0)) {
+			continue
+		}
+		// The devices may not have caught up with the last remediation yet
+		if changeID, ok := remediations[deviceID]; ok {
+			change, err := m.NetworkChangesStore.Get(changeID)
+			if err == nil && change != nil && change.Status.State == changetypes.State_PENDING {
+				log.Infof("Not remediating %s while %s is pending", deviceID, changeID)
+				continue
+			}
+		}
+		// Neither remediate what other changes are about to set nor undo what they have set
+		incomplete, err := m.incompleteDeviceChanges(drift.DeviceID)
+		if err != nil {
+			log.Errorf("Unable to list the changes of %s %v", deviceID, err)
+			continue
+		} else if len(incomplete) > 0 {
+			log.Infof("Not remediating %s while %v have not completed", deviceID, incomplete)
+			continue
+		}
+		change, err := m.RemediateDeviceDrift(drift, policy)
+		if err != nil {
+			log.Errorf("Unable to remediate drift of %s %v", deviceID, err)
+		} else if change != nil {
+			remediations[deviceID] = change.ID
+		}
+	}
+}
+
+// incompleteDeviceChanges gives the changes to the device that have not completed
+func (m *Manager) incompleteDeviceChanges(deviceID devicetype.VersionedID) ([]devicechange.ID, error) {
+	changeCh := make(chan *devicechange.DeviceChange)
+	ctx, err := m.DeviceChangesStore.List(deviceID, changeCh)
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+
+	incomplete := make([]devicechange.ID, 0)
+	for deviceChange := range changeCh {
+		if deviceChange.Status.State != changetypes.State_COMPLETE {
+			incomplete = append(incomplete, deviceChange.ID)
+		}
+	}
+	return incomplete, nil
+}
+
+// RemediateDeviceDrift creates a network change that sets the intended values of the paths that
+// drifted on the device and, if the policy removes unmanaged paths, removes the paths that are not
+// intended for it. Paths excluded by the policy are left as they are. The change records the values it overwrites as its remediation. No change is created
+// if every path is left as it is
+func (m *Manager) RemediateDeviceDrift(drift *synchronizer.DeviceDrift, policy *RemediationPolicy) (*networkchange.NetworkChange, error) {
+	remediation := &networkchange.Remediation{
+		DeviceID:      drift.DeviceID.GetID(),
+		DeviceVersion: drift.DeviceID.GetVersion(),
+		Audited:       drift.Audited,
+		Overwritten:   make([]*devicechange.PathValue, 0, len(drift.Drifts)),
+		Excluded:      make([]string, 0),
+	}
+	updates := make(devicechange.TypedValueMap)
+	removes := make([]string, 0)
+	for _, pathDrift := range drift.Drifts {
+		if policy.Excludes(pathDrift.Path) {
+			remediation.Excluded = append(remediation.Excluded, pathDrift.Path)
+			continue
+		}
+		updates[pathDrift.Path] = pathDrift.Intended
+		remediation.Overwritten = append(remediation.Overwritten, &devicechange.PathValue{
+			Path:  pathDrift.Path,
+			Value: pathDrift.Actual,
+		})
+	}
+	if policy.removeUnmanaged {
+		for _, unmanaged := range drift.Unmanaged {
+			if policy.Excludes(unmanaged.Path) {
+				remediation.Excluded = append(remediation.Excluded, unmanaged.Path)
+				continue
+			}
+			removes = append(removes, unmanaged.Path)
+			remediation.Overwritten = append(remediation.Overwritten, unmanaged)
+		}
+	}
+	if len(remediation.Overwritten) == 0 {
+		log.Infof("Drift of %s is only on paths left as they are %v", drift.DeviceID, remediation.Excluded)
+		return nil, nil
+	}
+
+	change, err := m.ComputeDeviceChange(remediation.DeviceID, remediation.DeviceVersion, drift.DeviceType,
+		updates, removes, "remediation")
+	if err != nil {
+		return nil, err
+	}
+	networkChange, err := networkchange.NewNetworkChange("remediation-"+namesgenerator.GetRandomName(0),
+		[]*devicechange.Change{change})
+	if err != nil {
+		return nil, err
+	}
+	networkChange.Remediation = remediation
+	if err := m.NetworkChangesStore.Create(networkChange); err != nil {
+		return nil, err
+	}
+	for _, overwritten := range remediation.Overwritten {
+		log.Warnf("Remediation %s of %s overwrites %s %s", networkChange.ID, drift.DeviceID,
+			overwritten.Path, valueString(overwritten.Value))
+	}
+	return networkChange, nil
+}
+
+// valueString gives a value for the log, which is not given for a path missing from the device
+func valueString(value *devicechange.TypedValue) string {
+	if value == nil {
+		return "(missing)"
+	}
+	return "(" + value.Type.String() + ") " + value.ValueToString()
+}
@@ -15,23 +15,43 @@
 package manager
 
 import (
+	"context"
 	"fmt"
+	changetypes "github.com/onosproject/onos-config/api/types/change"
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
 	networkchange "github.com/onosproject/onos-config/api/types/change/network"
 	devicetype "github.com/onosproject/onos-config/api/types/device"
 	"github.com/onosproject/onos-config/pkg/store"
+	devicechangestore "github.com/onosproject/onos-config/pkg/store/change/device"
+	networkchangestore "github.com/onosproject/onos-config/pkg/store/change/network"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
+	"github.com/onosproject/onos-config/pkg/store/stream"
 	"github.com/onosproject/onos-config/pkg/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"sort"
+	"strings"
+	"time"
 )
 
 // SetConfigAlreadyApplied is a string constant for "Already applied:"
 const SetConfigAlreadyApplied = "Already applied:"
 
+// Precondition is a condition on the stored value of a path that has to hold for a change to be made
+type Precondition struct {
+	Path string
+	// Value is the value the path must have, in the form given by TypedValue.ValueToString()
+	Value string
+	// Absent requires the path to have no value instead
+	Absent bool
+}
+
 // ValidateNetworkConfig validates the given updates and deletes, according to the path on the configuration
-// for the specified target (Atomix Based)
+// for the specified target (Atomix Based). The preconditions are checked against the same configuration,
+// failing with FailedPrecondition if any does not hold
 func (m *Manager) ValidateNetworkConfig(deviceName devicetype.ID, version devicetype.Version,
-	deviceType devicetype.Type, updates devicechange.TypedValueMap, deletes []string, lastWrite networkchange.Revision) error {
+	deviceType devicetype.Type, updates devicechange.TypedValueMap, deletes []string, lastWrite networkchange.Revision,
+	preconditions []*Precondition) error {
 
 	chg, err := m.ComputeDeviceChange(deviceName, version, deviceType, updates, deletes, "Generated for validation")
 	if err != nil {
@@ -44,13 +64,21 @@ func (m *Manager) ValidateNetworkConfig(deviceName devicetype.ID, version device
 		if !mgr.allowUnvalidatedConfig {
 			return fmt.Errorf("no model %s available as a plugin", modelName)
 		}
-		return nil
+		if len(preconditions) == 0 {
+			return nil
+		}
 	}
 
 	configValues, err := m.DeviceStateStore.Get(devicetype.NewVersionedID(deviceName, version), lastWrite)
 	if err != nil {
 		return err
 	}
+	if err := checkPreconditions(deviceName, configValues, preconditions); err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
 
 	pathValues := make(map[string]*devicechange.TypedValue)
 	for _, configValue := range configValues {
@@ -96,9 +124,47 @@ func (m *Manager) ValidateNetworkConfig(deviceName devicetype.ID, version device
 	return nil
 }
 
+// checkPreconditions checks the preconditions against the configuration of the device, failing with
+// FailedPrecondition on the first one that does not hold
+func checkPreconditions(deviceName devicetype.ID, configValues []*devicechange.PathValue, preconditions []*Precondition) error {
+	if len(preconditions) == 0 {
+		return nil
+	}
+	pathValues := make(map[string]*devicechange.TypedValue)
+	for _, configValue := range configValues {
+		pathValues[configValue.Path] = configValue.Value
+	}
+	for _, precondition := range preconditions {
+		value, ok := pathValues[precondition.Path]
+		if precondition.Absent {
+			if ok {
+				return status.Errorf(codes.FailedPrecondition, "precondition failed on %s: %s is set to '%s'",
+					deviceName, precondition.Path, value.ValueToString())
+			}
+		} else if !ok {
+			return status.Errorf(codes.FailedPrecondition, "precondition failed on %s: %s is not set",
+				deviceName, precondition.Path)
+		} else if value.ValueToString() != precondition.Value {
+			return status.Errorf(codes.FailedPrecondition, "precondition failed on %s: %s is '%s' not '%s'",
+				deviceName, precondition.Path, value.ValueToString(), precondition.Value)
+		}
+	}
+	return nil
+}
+
 // SetNetworkConfig creates and stores a new netork config for the given updates and deletes and targets
 func (m *Manager) SetNetworkConfig(targetUpdates map[string]devicechange.TypedValueMap,
 	targetRemoves map[string][]string, deviceInfo map[devicetype.ID]cache.Info, netcfgchangename string) (*networkchange.NetworkChange, error) {
+	return m.SetNetworkConfigWithOfflinePolicy(targetUpdates, targetRemoves, deviceInfo, netcfgchangename,
+		networkchange.OfflinePolicy_WAIT, 0)
+}
+
+// SetNetworkConfigWithOfflinePolicy sets the configuration like SetNetworkConfig, for a network change
+// that applies the given policy while devices it changes are offline. The timeout is only used by the
+// WAIT_TIMEOUT policy
+func (m *Manager) SetNetworkConfigWithOfflinePolicy(targetUpdates map[string]devicechange.TypedValueMap,
+	targetRemoves map[string][]string, deviceInfo map[devicetype.ID]cache.Info, netcfgchangename string,
+	offlinePolicy networkchange.OfflinePolicy, offlineTimeout time.Duration) (*networkchange.NetworkChange, error) {
 	//TODO evaluate need of user and add it back if need be.
 	allDeviceChanges, errChanges := m.computeNetworkConfig(targetUpdates, targetRemoves, deviceInfo, netcfgchangename)
 	if errChanges != nil {
@@ -108,6 +174,8 @@ func (m *Manager) SetNetworkConfig(targetUpdates map[string]devicechange.TypedVa
 	if errNetChange != nil {
 		return nil, errNetChange
 	}
+	newNetworkConfig.OfflinePolicy = offlinePolicy
+	newNetworkConfig.OfflineTimeout = offlineTimeout
 	//Writing to the atomix backed store too
 	errStoreChange := m.NetworkChangesStore.Create(newNetworkConfig)
 	if errStoreChange != nil {
@@ -116,6 +184,26 @@ func (m *Manager) SetNetworkConfig(targetUpdates map[string]devicechange.TypedVa
 	return newNetworkConfig, nil
 }
 
+// CheckNoChangeSince checks that no network change after the given index has changed the device.
+// It fails with Aborted if one has - the caller's view of the device configuration is out of date
+func (m *Manager) CheckNoChangeSince(deviceID devicetype.ID, index networkchange.Index) error {
+	for {
+		next, err := m.NetworkChangesStore.GetNext(index)
+		if err != nil {
+			return err
+		} else if next == nil {
+			return nil
+		}
+		for _, change := range next.Changes {
+			if change.DeviceID == deviceID {
+				return status.Errorf(codes.Aborted, "device %s has been changed by network change %s (index %d) since index %d",
+					deviceID, next.ID, next.Index, index)
+			}
+		}
+		index = next.Index
+	}
+}
+
 //computeNetworkConfig computes each device change
 func (m *Manager) computeNetworkConfig(targetUpdates map[string]devicechange.TypedValueMap,
 	targetRemoves map[string][]string, deviceInfo map[devicetype.ID]cache.Info,
@@ -152,3 +240,122 @@ func (m *Manager) computeNetworkConfig(targetUpdates map[string]devicechange.Typ
 	}
 	return deviceChanges, nil
 }
+
+// WaitForNetworkConfig blocks until the given network change has been applied to all of its devices (COMPLETE)
+// or has failed on any of them (FAILED), or until the timeout expires or the context is canceled. The error
+// returned is a gRPC status error carrying the status messages of the device changes that failed.
+func (m *Manager) WaitForNetworkConfig(ctx context.Context, changeID networkchange.ID, timeout time.Duration) error {
+	networkChan := make(chan stream.Event)
+	watchCtx, errWatch := m.NetworkChangesStore.Watch(networkChan,
+		networkchangestore.WithChangeID(changeID), networkchangestore.WithReplay())
+	if errWatch != nil {
+		return status.Errorf(codes.Internal, "can't wait for change %s due to %s", changeID, errWatch)
+	}
+	defer watchCtx.Close()
+
+	// Device changes are failed without the network change being updated (it is rolled back and retried),
+	// so the device changes have to be watched too once the network change has created them
+	done := make(chan struct{})
+	defer close(done)
+	deviceChan := make(chan *devicechange.DeviceChange)
+	var change *networkchange.NetworkChange
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case changeEvent, ok := <-networkChan:
+			if !ok {
+				return status.Errorf(codes.Unavailable, "watch for change %s was closed", changeID)
+			}
+			if change == nil || len(change.Refs) == 0 {
+				if err := m.watchDeviceChanges(changeEvent.Object.(*networkchange.NetworkChange), deviceChan, done); err != nil {
+					return status.Errorf(codes.Internal, "can't wait for change %s due to %s", changeID, err)
+				}
+			}
+			change = changeEvent.Object.(*networkchange.NetworkChange)
+			log.Infof("Waiting on change ID %s, phase %s, state %s", change.ID,
+				change.Status.Phase, change.Status.State)
+			if change.Status.Phase == changetypes.Phase_ROLLBACK {
+				return status.Errorf(codes.Aborted, "change %s is being rolled back", changeID)
+			}
+			switch change.Status.State {
+			case changetypes.State_COMPLETE:
+				return nil
+			case changetypes.State_FAILED:
+				return status.Errorf(codes.Aborted, "change %s failed %s", changeID, m.deviceChangeFailures(change))
+			}
+		case deviceChange := <-deviceChan:
+			if deviceChange.Status.Phase == changetypes.Phase_CHANGE &&
+				deviceChange.Status.State == changetypes.State_FAILED &&
+				deviceChange.Status.Incarnation == change.Status.Incarnation {
+				return status.Errorf(codes.Aborted, "change %s failed %s", changeID, m.deviceChangeFailures(change))
+			}
+		case <-timer.C:
+			failures := ""
+			if change != nil {
+				failures = m.deviceChangeFailures(change)
+			}
+			return status.Errorf(codes.DeadlineExceeded, "change %s not applied after %s %s", changeID, timeout, failures)
+		case <-ctx.Done():
+			return status.Errorf(codes.Canceled, "wait for change %s was canceled", changeID)
+		}
+	}
+}
+
+// watchDeviceChanges forwards the updates of the device changes created for the network change, if any
+func (m *Manager) watchDeviceChanges(change *networkchange.NetworkChange,
+	deviceChan chan<- *devicechange.DeviceChange, done <-chan struct{}) error {
+	for i, ref := range change.Refs {
+		eventChan := make(chan stream.Event)
+		ctx, err := m.DeviceChangesStore.Watch(change.Changes[i].GetVersionedDeviceID(), eventChan,
+			devicechangestore.WithChangeID(ref.DeviceChangeID), devicechangestore.WithReplay())
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer func() {
+				ctx.Close()
+				// The store closes the channel once it has stopped watching
+				for range eventChan {
+				}
+			}()
+			for {
+				select {
+				case event, ok := <-eventChan:
+					if !ok {
+						return
+					}
+					deviceChange, ok := event.Object.(*devicechange.DeviceChange)
+					if !ok {
+						continue
+					}
+					select {
+					case deviceChan <- deviceChange:
+					case <-done:
+						return
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// deviceChangeFailures returns the status messages of the failed device changes of the network change
+func (m *Manager) deviceChangeFailures(change *networkchange.NetworkChange) string {
+	messages := make([]string, 0)
+	for _, ref := range change.Refs {
+		deviceChange, err := m.DeviceChangesStore.Get(ref.DeviceChangeID)
+		if err != nil || deviceChange == nil {
+			log.Warnf("Unable to get device change %s %v", ref.DeviceChangeID, err)
+			continue
+		}
+		if deviceChange.Status.State == changetypes.State_FAILED {
+			messages = append(messages, fmt.Sprintf("%s: %s", deviceChange.Change.DeviceID, deviceChange.Status.Message))
+		}
+	}
+	return fmt.Sprintf("[%s]", strings.Join(messages, ", "))
+}
@@ -0,0 +1,147 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modelregistry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// QualifyJSONIETF renames the members of a JSON tree (as built by store.BuildTree) to the
+// "module:name" form of RFC 7951. A member is qualified where the YANG module that defines it
+// differs from the module of its parent, so every top level member is qualified. The values of
+// 64 bit integer leafs are given as strings, as RFC 7951 requires.
+// Members that are not found in the schema are left as they are
+func QualifyJSONIETF(deviceEntry *yang.Entry, jsonTree []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(jsonTree))
+	// Keep the numbers as they are - a float64 cannot hold every uint64
+	decoder.UseNumber()
+	var tree map[string]interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, fmt.Errorf("unable to decode JSON tree %v", err)
+	}
+
+	qualified := qualifyMembers(tree, deviceEntry, "", entryModules(deviceEntry))
+	return json.MarshalIndent(qualified, "", "  ")
+}
+
+// entryModules maps the prefix of each YANG module to its name. The name is taken from the
+// schema path of the top level nodes, which always start with the module that defines them.
+// Modules that only augment other modules, or only give groupings, cannot be found this way
+func entryModules(deviceEntry *yang.Entry) map[string]string {
+	modules := make(map[string]string)
+	for _, entry := range deviceEntry.Dir {
+		if entry.Prefix == nil {
+			continue
+		}
+		schemaPath, ok := entry.Annotation["schemapath"].(string)
+		if !ok {
+			continue
+		}
+		if pathElems := utils.SplitPath(schemaPath); len(pathElems) > 0 {
+			modules[entry.Prefix.Name] = pathElems[0]
+		}
+	}
+	return modules
+}
+
+// entryModule gives the name of the YANG module whose namespace the entry is in. Where the schema
+// was parsed from YANG this is found from the namespace of the entry itself, which is the one of
+// the module that augments it in, if any. The schemas of model plugins only keep the prefix of
+// each entry, which is looked up in the modules - an entry whose prefix is not the one of a top
+// level module is taken to be in the module of its parent
+func entryModule(entry *yang.Entry, parentModule string, modules map[string]string) string {
+	if entry.Node != nil {
+		root := entry
+		for root.Parent != nil {
+			root = root.Parent
+		}
+		if _, ok := root.Node.(*yang.Module); ok {
+			if module, err := entry.InstantiatingModule(); err == nil {
+				return module
+			}
+		}
+		if module := yang.RootNode(entry.Node); module != nil {
+			if module.BelongsTo != nil {
+				return module.BelongsTo.Name
+			}
+			return module.Name
+		}
+	}
+	if entry.Prefix != nil {
+		if module, ok := modules[entry.Prefix.Name]; ok {
+			return module
+		}
+	}
+	return parentModule
+}
+
+func qualifyMembers(node interface{}, entry *yang.Entry, module string, modules map[string]string) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		qualified := make(map[string]interface{}, len(n))
+		for name, child := range n {
+			var childEntry *yang.Entry
+			if entry != nil {
+				childEntry = entry.Dir[name]
+			}
+			if childEntry == nil {
+				qualified[name] = child
+				continue
+			}
+			childModule := entryModule(childEntry, module, modules)
+			member := name
+			if childModule != module {
+				member = fmt.Sprintf("%s:%s", childModule, name)
+			}
+			qualified[member] = qualifyMembers(child, childEntry, childModule, modules)
+		}
+		return qualified
+	case []interface{}:
+		// The entries of a list, or the values of a leaf list
+		for i, item := range n {
+			n[i] = qualifyMembers(item, entry, module, modules)
+		}
+		return n
+	case json.Number:
+		if entry != nil && is64BitInteger(entry.Type) {
+			return n.String()
+		}
+		return n
+	default:
+		return node
+	}
+}
+
+// is64BitInteger returns true if the type is int64 or uint64, or a union of types including them
+func is64BitInteger(yangType *yang.YangType) bool {
+	if yangType == nil {
+		return false
+	}
+	switch yangType.Kind {
+	case yang.Yint64, yang.Yuint64:
+		return true
+	case yang.Yunion:
+		for _, memberType := range yangType.Type {
+			if is64BitInteger(memberType) {
+				return true
+			}
+		}
+	}
+	return false
+}
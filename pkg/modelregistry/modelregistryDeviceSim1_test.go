@@ -227,3 +227,36 @@ func Test_SchemaDeviceSim(t *testing.T) {
 	assert.Equal(t, logSvrSelFacName.ValueType, devicechange.ValueType_STRING, "expected /system/logging/remote-servers/remote-server[host=*]/selectors/selector[facility=*][severity=*]/config/facility to be STRING")
 
 }
+
+// Test_QualifyJSONIETFDeviceSim tests qualifying the members of a model made of many modules, where
+// members come from the groupings of modules that have no top level members
+func Test_QualifyJSONIETFDeviceSim(t *testing.T) {
+	ds1Schema, _ := ds1.UnzipSchema()
+
+	jsonTree := []byte(`{"system":{"aaa":{"config":{}},"config":{"hostname":"sim"}},` +
+		`"components":{"component":[{"name":"c1","state":{"temperature":{"instant":25.5}}}]}}`)
+	qualified, err := QualifyJSONIETF(ds1Schema["Device"], jsonTree)
+	assert.NilError(t, err)
+	assert.Equal(t, string(qualified), `{
+  "openconfig-platform:components": {
+    "component": [
+      {
+        "name": "c1",
+        "state": {
+          "temperature": {
+            "instant": 25.5
+          }
+        }
+      }
+    ]
+  },
+  "openconfig-system:system": {
+    "aaa": {
+      "config": {}
+    },
+    "config": {
+      "hostname": "sim"
+    }
+  }
+}`)
+}
@@ -149,3 +149,112 @@ func Test_SchemaTestDevice1(t *testing.T) {
 	assert.Assert(t, leafTopLevelOk, "expected to get /leafAtTopLevel")
 	assert.Equal(t, leafTopLevel.ValueType, devicechange.ValueType_STRING, "expected /leafAtTopLevel to be STRING")
 }
+
+func Test_QualifyJSONIETFTestDevice1(t *testing.T) {
+	td1Schema, _ := td1.UnzipSchema()
+
+	jsonTree := []byte(`{"cont1a":{"cont2a":{"leaf2a":13,"leaf2e":[-1,2]},` +
+		`"list2a":[{"name":"first","tx-power":5}]},"not-in-model":{"leaf":1}}`)
+	qualified, err := QualifyJSONIETF(td1Schema["Device"], jsonTree)
+	assert.NilError(t, err)
+	assert.Equal(t, string(qualified), `{
+  "not-in-model": {
+    "leaf": 1
+  },
+  "test1:cont1a": {
+    "cont2a": {
+      "leaf2a": 13,
+      "leaf2e": [
+        -1,
+        2
+      ]
+    },
+    "list2a": [
+      {
+        "name": "first",
+        "tx-power": 5
+      }
+    ]
+  }
+}`)
+}
+
+func Test_QualifyJSONIETF64BitIntegers(t *testing.T) {
+	module := &yang.Module{Name: "test64", Prefix: &yang.Value{Name: "t64"}}
+	counters := &yang.Entry{
+		Name:   "counters",
+		Prefix: module.Prefix,
+		Dir: map[string]*yang.Entry{
+			"in-octets": {Name: "in-octets", Type: &yang.YangType{Kind: yang.Yuint64}},
+			"offset":    {Name: "offset", Type: &yang.YangType{Kind: yang.Yint64}},
+			"samples":   {Name: "samples", Type: &yang.YangType{Kind: yang.Yuint64}, ListAttr: &yang.ListAttr{}},
+			"either": {Name: "either", Type: &yang.YangType{Kind: yang.Yunion,
+				Type: []*yang.YangType{{Kind: yang.Ystring}, {Kind: yang.Yint64}}}},
+			"count": {Name: "count", Type: &yang.YangType{Kind: yang.Yuint32}},
+		},
+		Annotation: map[string]interface{}{"schemapath": "/test64/counters"},
+	}
+	device := &yang.Entry{Name: "Device", Dir: map[string]*yang.Entry{"counters": counters}}
+
+	jsonTree := []byte(`{"counters":{"in-octets":18446744073709551615,"offset":-9223372036854775808,` +
+		`"samples":[1,2],"either":3,"count":4}}`)
+	qualified, err := QualifyJSONIETF(device, jsonTree)
+	assert.NilError(t, err)
+	assert.Equal(t, string(qualified), `{
+  "test64:counters": {
+    "count": 4,
+    "either": "3",
+    "in-octets": "18446744073709551615",
+    "offset": "-9223372036854775808",
+    "samples": [
+      "1",
+      "2"
+    ]
+  }
+}`)
+}
+
+// Test_QualifyJSONIETFAugmented tests that members augmented in by another module are qualified with
+// the name of that module, while members from the groupings of other modules are not
+func Test_QualifyJSONIETFAugmented(t *testing.T) {
+	modules := yang.NewModules()
+	for name, source := range map[string]string{
+		"base.yang": `module base {
+			namespace "urn:base"; prefix b;
+			import groupings { prefix g; }
+			container top { leaf name { type string; } uses g:shared; }
+		}`,
+		"groupings.yang": `module groupings {
+			namespace "urn:groupings"; prefix g;
+			grouping shared { leaf shared { type string; } }
+		}`,
+		"augments.yang": `module augments {
+			namespace "urn:augments"; prefix a;
+			import base { prefix b; }
+			augment "/b:top" {
+				leaf extra { type string; }
+				container more { leaf count { type uint64; } }
+			}
+		}`,
+	} {
+		assert.NilError(t, modules.Parse(source, name))
+	}
+	errs := modules.Process()
+	assert.Equal(t, len(errs), 0, "%v", errs)
+	base, err := modules.FindModuleByPrefix("b")
+	assert.NilError(t, err)
+
+	jsonTree := []byte(`{"top":{"name":"x","shared":"y","extra":"z","more":{"count":5}}}`)
+	qualified, err := QualifyJSONIETF(yang.ToEntry(base), jsonTree)
+	assert.NilError(t, err)
+	assert.Equal(t, string(qualified), `{
+  "base:top": {
+    "augments:extra": "z",
+    "augments:more": {
+      "count": "5"
+    },
+    "name": "x",
+    "shared": "y"
+  }
+}`)
+}
@@ -16,14 +16,18 @@
 package diags
 
 import (
+	"context"
 	"fmt"
+	"sort"
 
 	"github.com/onosproject/onos-config/api/admin"
 	"github.com/onosproject/onos-config/api/diags"
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
 	networkchange "github.com/onosproject/onos-config/api/types/change/network"
 	devicetype "github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-config/pkg/dispatcher"
 	"github.com/onosproject/onos-config/pkg/manager"
+	"github.com/onosproject/onos-config/pkg/southbound/synchronizer"
 	"github.com/onosproject/onos-config/pkg/store/change/device"
 	"github.com/onosproject/onos-config/pkg/store/change/network"
 	streams "github.com/onosproject/onos-config/pkg/store/stream"
@@ -32,6 +36,8 @@ import (
 	"github.com/onosproject/onos-lib-go/pkg/northbound"
 	topodevice "github.com/onosproject/onos-topo/api/device"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var log = logging.GetLogger("northbound", "diags")
@@ -74,21 +80,13 @@ func (s Server) GetOpState(r *diags.OpStateRequest, stream diags.OpStateDiags_Ge
 	}
 
 	if r.Subscribe {
-		streamID := fmt.Sprintf("diags-%p", stream)
-		listener, err := manager.GetManager().Dispatcher.RegisterOpState(streamID)
+		streamID, listener := manager.GetManager().Dispatcher.RegisterOpStateSubscription(
+			dispatcher.OpStateFilter{Targets: []string{r.DeviceId}})
 		defer manager.GetManager().Dispatcher.UnregisterOperationalState(streamID)
-		if err != nil {
-			log.Warnf("Failed setting up a listener for OpState events on %s", r.DeviceId)
-			return err
-		}
 		log.Infof("NBI Diags OpState started on %s for %s", streamID, r.DeviceId)
 		for {
 			select {
 			case opStateEvent := <-listener:
-				if opStateEvent.Subject() != r.DeviceId {
-					// If the event is not for this device then ignore it
-					continue
-				}
 				log.Infof("Event received NBI Diags OpState subscribe channel %s for %s",
 					streamID, r.DeviceId)
 
@@ -98,7 +96,7 @@ func (s Server) GetOpState(r *diags.OpStateRequest, stream diags.OpStateDiags_Ge
 				}
 
 				msg := &diags.OpStateResponse{Type: admin.Type_ADDED, Pathvalue: pathValue}
-				err = stream.SendMsg(msg)
+				err := stream.SendMsg(msg)
 				if err != nil {
 					log.Warnf("Error sending message on stream %s. Closing. %v",
 						streamID, msg)
@@ -307,6 +305,132 @@ func (s Server) ListDeviceChanges(r *diags.ListDeviceChangeRequest, stream diags
 	return nil
 }
 
+// GetConfigDiff returns the added, removed and modified leaves of each device between two network changes
+func (s Server) GetConfigDiff(ctx context.Context, r *diags.ConfigDiffRequest) (*diags.ConfigDiffResponse, error) {
+	log.Infof("GetConfigDiff called from %s to %s for device '%s'", r.FromChange, r.ToChange, r.DeviceID)
+	mgr := manager.GetManager()
+	fromChange, err := mgr.FindNetworkChange(r.FromChange)
+	if err != nil {
+		return nil, err
+	}
+	toChange, err := mgr.FindNetworkChange(r.ToChange)
+	if err != nil {
+		return nil, err
+	}
+
+	deviceDiffs, err := mgr.ComputeNetworkChangeDiff(fromChange, toChange, r.DeviceID)
+	if err != nil {
+		log.Errorf("Error computing config diff %s", err)
+		return nil, err
+	}
+
+	response := &diags.ConfigDiffResponse{
+		Devices: make([]*diags.DeviceConfigDiff, 0, len(deviceDiffs)),
+	}
+	for deviceID, configDiffs := range deviceDiffs {
+		deviceDiff := &diags.DeviceConfigDiff{
+			DeviceID:      deviceID.GetID(),
+			DeviceVersion: deviceID.GetVersion(),
+			Diffs:         make([]*diags.PathDiff, 0, len(configDiffs)),
+		}
+		for _, configDiff := range configDiffs {
+			pathDiff := &diags.PathDiff{
+				Path:     configDiff.Path,
+				Type:     diags.Type_UPDATED,
+				OldValue: configDiff.OldValue,
+				NewValue: configDiff.NewValue,
+			}
+			if configDiff.OldValue == nil {
+				pathDiff.Type = diags.Type_ADDED
+			} else if configDiff.NewValue == nil {
+				pathDiff.Type = diags.Type_REMOVED
+			}
+			deviceDiff.Diffs = append(deviceDiff.Diffs, pathDiff)
+		}
+		response.Devices = append(response.Devices, deviceDiff)
+	}
+	sort.Slice(response.Devices, func(i, j int) bool {
+		return response.Devices[i].DeviceID < response.Devices[j].DeviceID
+	})
+	return response, nil
+}
+
+// GetDrift returns the configuration drift of a device found by its latest audit, or by an audit
+// made now if asked. If subscribe is true the drift found by the audits that follow is streamed
+// whenever it changes, until the client hangs up
+func (s Server) GetDrift(r *diags.DriftRequest, stream diags.ChangeService_GetDriftServer) error {
+	log.Infof("GetDrift called for %s. Audit %v Subscribe %v", r.DeviceID, r.Audit, r.Subscribe)
+	if r.DeviceID == "" {
+		return status.Error(codes.InvalidArgument, "no device given")
+	}
+	driftMonitor := manager.GetManager().DriftMonitor
+
+	var driftCh chan *synchronizer.DeviceDrift
+	if r.Subscribe {
+		driftCh = make(chan *synchronizer.DeviceDrift, 10)
+		driftMonitor.Watch(driftCh)
+		defer driftMonitor.Unwatch(driftCh)
+	}
+
+	var drift *synchronizer.DeviceDrift
+	if r.Audit {
+		var err error
+		drift, err = driftMonitor.Audit(topodevice.ID(r.DeviceID))
+		if err != nil {
+			log.Errorf("Error auditing %s %s", r.DeviceID, err)
+			return err
+		}
+	} else {
+		var ok bool
+		drift, ok = driftMonitor.Get(topodevice.ID(r.DeviceID))
+		if !ok && !r.Subscribe {
+			return status.Errorf(codes.NotFound, "device %s has not been audited", r.DeviceID)
+		}
+	}
+	if drift != nil {
+		if err := stream.Send(newDriftResponse(drift)); err != nil {
+			return err
+		}
+	}
+	if !r.Subscribe {
+		return nil
+	}
+
+	for {
+		select {
+		case event := <-driftCh:
+			// The audit made for this request has been sent already
+			if event == drift || event.DeviceID.GetID() != r.DeviceID {
+				continue
+			}
+			if err := stream.Send(newDriftResponse(event)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			log.Infof("NBI Diags drift subscription for %s closed", r.DeviceID)
+			return nil
+		}
+	}
+}
+
+func newDriftResponse(drift *synchronizer.DeviceDrift) *diags.DriftResponse {
+	response := &diags.DriftResponse{
+		DeviceID:      drift.DeviceID.GetID(),
+		DeviceVersion: drift.DeviceID.GetVersion(),
+		Audited:       drift.Audited,
+		Drifts:        make([]*diags.PathDrift, 0, len(drift.Drifts)),
+		Unmanaged:     drift.Unmanaged,
+	}
+	for _, pathDrift := range drift.Drifts {
+		response.Drifts = append(response.Drifts, &diags.PathDrift{
+			Path:          pathDrift.Path,
+			IntendedValue: pathDrift.Intended,
+			ActualValue:   pathDrift.Actual,
+		})
+	}
+	return response
+}
+
 func streamTypeToResponseType(eventType streams.EventType) diags.Type {
 	switch eventType {
 	case streams.Created:
@@ -30,6 +30,8 @@ import (
 	mockcache "github.com/onosproject/onos-config/pkg/test/mocks/store/cache"
 	topodevice "github.com/onosproject/onos-topo/api/device"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"gotest.tools/assert"
 	"io"
@@ -359,3 +361,25 @@ func generateDeviceChangeData(count int) []*devicechange.DeviceChange {
 
 	return networkChanges
 }
+
+func Test_GetDriftNotAudited(t *testing.T) {
+	_, conn, client, server := setUpServer(t)
+	defer server.Stop()
+	defer conn.Close()
+
+	stream, err := client.GetDrift(context.Background(), &diags.DriftRequest{DeviceID: "device-1"})
+	assert.NilError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, status.Code(err), codes.NotFound)
+
+	// An audit needs the device to be connected
+	stream, err = client.GetDrift(context.Background(), &diags.DriftRequest{DeviceID: "device-1", Audit: true})
+	assert.NilError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, status.Code(err), codes.Unavailable)
+
+	stream, err = client.GetDrift(context.Background(), &diags.DriftRequest{})
+	assert.NilError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
@@ -0,0 +1,162 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"sync"
+	"time"
+)
+
+// aliasPrefix starts the name of every alias
+const aliasPrefix = "#"
+
+// aliasTable holds the path aliases of a Subscribe stream - those defined by the client and, if the
+// client allows them, those defined by the target. An aliased path is sent as the alias in the prefix
+// of a notification, with the rest of the path in the updates
+type aliasTable struct {
+	mu sync.Mutex
+	// targetDefined is set once the client allows the target to define aliases
+	targetDefined bool
+	// clientAliases are the paths aliased by the client
+	clientAliases []*gnmi.Alias
+	// aliases maps each path aliased by the target to its alias
+	aliases map[string]string
+	// names holds the aliases in use
+	names     map[string]bool
+	nextAlias int
+}
+
+func newAliasTable() *aliasTable {
+	return &aliasTable{
+		aliases: make(map[string]string),
+		names:   make(map[string]bool),
+	}
+}
+
+// addClientAliases adds the aliases defined by the client in an aliases request
+func (a *aliasTable) addClientAliases(aliasList *gnmi.AliasList) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, alias := range aliasList.GetAlias() {
+		if !strings.HasPrefix(alias.GetAlias(), aliasPrefix) || len(alias.GetAlias()) == len(aliasPrefix) {
+			return status.Errorf(codes.InvalidArgument, "alias '%s' does not start with %s", alias.GetAlias(), aliasPrefix)
+		}
+		if alias.GetPath() == nil || len(alias.GetPath().GetElem()) == 0 {
+			return status.Errorf(codes.InvalidArgument, "no path given for alias %s", alias.GetAlias())
+		}
+		if a.names[alias.GetAlias()] {
+			return status.Errorf(codes.InvalidArgument, "alias %s is already defined", alias.GetAlias())
+		}
+		a.clientAliases = append(a.clientAliases, alias)
+		a.names[alias.GetAlias()] = true
+	}
+	return nil
+}
+
+// allowTargetDefined lets the target define aliases for the rest of the stream
+func (a *aliasTable) allowTargetDefined() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.targetDefined = true
+}
+
+// aliasPath returns the aliased prefix of the path of an update, and the rest of the path under it.
+// The longest path aliased by the client is used, or else the target defines an alias for the parent
+// of the path if allowed to, sending its definition on the stream first. A nil prefix is returned if the
+// path is not aliased
+func (a *aliasTable) aliasPath(path *gnmi.Path, stream gnmi.GNMI_SubscribeServer) (*gnmi.Path, *gnmi.Path, error) {
+	if a == nil {
+		return nil, path, nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var clientAlias *gnmi.Alias
+	for _, alias := range a.clientAliases {
+		aliasPath := alias.GetPath()
+		if (aliasPath.GetTarget() == "" || aliasPath.GetTarget() == path.GetTarget()) &&
+			isElemPrefix(aliasPath.GetElem(), path.GetElem()) &&
+			(clientAlias == nil || len(aliasPath.GetElem()) > len(clientAlias.GetPath().GetElem())) {
+			clientAlias = alias
+		}
+	}
+	if clientAlias != nil {
+		prefix, relativePath := aliasedPaths(clientAlias.GetAlias(), path, len(clientAlias.GetPath().GetElem()))
+		return prefix, relativePath, nil
+	}
+
+	if !a.targetDefined || len(path.GetElem()) < 2 {
+		return nil, path, nil
+	}
+	parentElems := path.GetElem()[:len(path.GetElem())-1]
+	key := aliasKey(path.GetTarget(), parentElems)
+	alias, ok := a.aliases[key]
+	if !ok {
+		for alias == "" || a.names[alias] {
+			a.nextAlias++
+			alias = fmt.Sprintf("%s%d", aliasPrefix, a.nextAlias)
+		}
+		definition := &gnmi.SubscribeResponse{
+			Response: &gnmi.SubscribeResponse_Update{
+				Update: &gnmi.Notification{
+					Timestamp: time.Now().Unix(),
+					Prefix:    &gnmi.Path{Target: path.GetTarget(), Elem: parentElems},
+					Alias:     alias,
+				},
+			},
+		}
+		if err := sendResponse(definition, stream); err != nil {
+			return nil, nil, err
+		}
+		a.aliases[key] = alias
+		a.names[alias] = true
+	}
+	prefix, relativePath := aliasedPaths(alias, path, len(parentElems))
+	return prefix, relativePath, nil
+}
+
+// aliasedPaths splits the path into the alias of its first elements and the rest of the path
+func aliasedPaths(alias string, path *gnmi.Path, aliasedElems int) (*gnmi.Path, *gnmi.Path) {
+	prefix := &gnmi.Path{Elem: []*gnmi.PathElem{{Name: alias}}}
+	return prefix, &gnmi.Path{Elem: path.GetElem()[aliasedElems:]}
+}
+
+// isElemPrefix returns true if the path elements start with the prefix elements
+func isElemPrefix(prefix []*gnmi.PathElem, elems []*gnmi.PathElem) bool {
+	if len(prefix) > len(elems) {
+		return false
+	}
+	for i, elem := range prefix {
+		if elem.GetName() != elems[i].GetName() || len(elem.GetKey()) != len(elems[i].GetKey()) {
+			return false
+		}
+		for key, value := range elem.GetKey() {
+			if elems[i].GetKey()[key] != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func aliasKey(target string, elems []*gnmi.PathElem) string {
+	return target + utils.StrPathElem(elems)
+}
@@ -0,0 +1,212 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"context"
+	"github.com/golang/mock/gomock"
+	"github.com/onosproject/onos-config/pkg/store/device/cache"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// gNMISubscribeServerRequestsFake receives the requests sent on its requests channel in turn
+type gNMISubscribeServerRequestsFake struct {
+	gNMISubscribeServerSampleFake
+	requests chan *gnmi.SubscribeRequest
+}
+
+func (x gNMISubscribeServerRequestsFake) Recv() (*gnmi.SubscribeRequest, error) {
+	request, ok := <-x.requests
+	if !ok {
+		return nil, io.EOF
+	}
+	return request, nil
+}
+
+// startAliasSubscription starts a Subscribe stream receiving the requests - its error is sent on the error channel
+func startAliasSubscription(t *testing.T, requests ...*gnmi.SubscribeRequest) (chan *gnmi.SubscribeResponse, chan error, func()) {
+	server, mgr, mocks := setUp(t)
+	setUpChangesMock(mocks)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Version:  "1.0.0",
+			Type:     "Stratum",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	responsesChan := make(chan *gnmi.SubscribeResponse, 10)
+	serverFake := gNMISubscribeServerRequestsFake{
+		gNMISubscribeServerSampleFake: gNMISubscribeServerSampleFake{
+			gNMISubscribeServerFake: gNMISubscribeServerFake{
+				Responses: responsesChan,
+			},
+			ctx: ctx,
+		},
+		requests: make(chan *gnmi.SubscribeRequest, len(requests)),
+	}
+	for _, request := range requests {
+		serverFake.requests <- request
+	}
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- server.Subscribe(serverFake)
+	}()
+	return responsesChan, errChan, func() {
+		cancel()
+		var wg sync.WaitGroup
+		tearDown(mgr, &wg)
+	}
+}
+
+func nextResponse(t *testing.T, responsesChan chan *gnmi.SubscribeResponse) *gnmi.SubscribeResponse {
+	select {
+	case response := <-responsesChan:
+		return response
+	case <-time.After(time.Second):
+		t.Fatal("Expected a response")
+		return nil
+	}
+}
+
+// Test_SubscribeTargetDefinedAliases tests that the target defines an alias for the path of the
+// changes when the client allows it, and uses it from then on
+func Test_SubscribeTargetDefinedAliases(t *testing.T) {
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a"})
+	assert.NilError(t, err)
+	path.Target = "Device1"
+	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	request.GetSubscribe().UpdatesOnly = true
+	request.GetSubscribe().UseAliases = true
+
+	responsesChan, _, stop := startAliasSubscription(t, request)
+	defer stop()
+	assertSyncResponse(responsesChan, t)
+
+	// The alias is defined before it is first used
+	definition := nextResponse(t, responsesChan).GetUpdate()
+	assert.Equal(t, definition.Alias, "#1")
+	assert.Equal(t, definition.Prefix.Target, "Device1")
+	assert.Equal(t, utils.StrPath(definition.Prefix), "/cont1a/cont2a")
+	assert.Equal(t, len(definition.Update), 0)
+
+	update := nextResponse(t, responsesChan).GetUpdate()
+	assert.Equal(t, utils.StrPath(update.Prefix), "/#1")
+	assert.Equal(t, len(update.Update), 1)
+	assert.Equal(t, utils.StrPath(update.Update[0].Path), "/leaf4a")
+	assert.Equal(t, update.Update[0].Val.GetUintVal(), uint64(14))
+
+	// The alias is reused for the next change under the same path
+	deletion := nextResponse(t, responsesChan).GetUpdate()
+	assert.Equal(t, utils.StrPath(deletion.Prefix), "/#1")
+	assert.Equal(t, len(deletion.Delete), 1)
+	assert.Equal(t, utils.StrPath(deletion.Delete[0]), "/leaf2a")
+}
+
+// Test_SubscribeClientDefinedAliases tests that an alias defined by the client is used for the paths under it
+func Test_SubscribeClientDefinedAliases(t *testing.T) {
+	aliasPath, err := utils.ParseGNMIElements([]string{"cont1a"})
+	assert.NilError(t, err)
+	aliasPath.Target = "Device1"
+	aliasRequest := &gnmi.SubscribeRequest{
+		Request: &gnmi.SubscribeRequest_Aliases{
+			Aliases: &gnmi.AliasList{
+				Alias: []*gnmi.Alias{{Path: aliasPath, Alias: "#cont1a"}},
+			},
+		},
+	}
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf4a"})
+	assert.NilError(t, err)
+	path.Target = "Device1"
+	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	request.GetSubscribe().UpdatesOnly = true
+
+	responsesChan, _, stop := startAliasSubscription(t, aliasRequest, request)
+	defer stop()
+	assertSyncResponse(responsesChan, t)
+
+	update := nextResponse(t, responsesChan).GetUpdate()
+	assert.Equal(t, update.Alias, "")
+	assert.Equal(t, utils.StrPath(update.Prefix), "/#cont1a")
+	assert.Equal(t, len(update.Update), 1)
+	assert.Equal(t, utils.StrPath(update.Update[0].Path), "/cont2a/leaf4a")
+}
+
+// Test_SubscribeSampleClientDefinedAliases tests that the initial values and the samples of a SAMPLE
+// subscription use an alias defined by the client
+func Test_SubscribeSampleClientDefinedAliases(t *testing.T) {
+	aliasPath, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a"})
+	assert.NilError(t, err)
+	aliasPath.Target = "Device1"
+	aliasRequest := &gnmi.SubscribeRequest{
+		Request: &gnmi.SubscribeRequest_Aliases{
+			Aliases: &gnmi.AliasList{
+				Alias: []*gnmi.Alias{{Path: aliasPath, Alias: "#cont2a"}},
+			},
+		},
+	}
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	assert.NilError(t, err)
+	path.Target = "Device1"
+	request := buildSampleRequest(path, 20*time.Millisecond, false, 0)
+
+	responsesChan, _, stop := startAliasSubscription(t, aliasRequest, request)
+	defer stop()
+
+	assertAliasedUpdate := func(update *gnmi.Notification) {
+		assert.Equal(t, utils.StrPath(update.Prefix), "/#cont2a")
+		assert.Equal(t, len(update.Update), 1)
+		assert.Equal(t, utils.StrPath(update.Update[0].Path), "/leaf2a")
+		assert.Equal(t, update.Update[0].Val.GetUintVal(), uint64(13))
+	}
+	// The initial value, then the samples
+	assertAliasedUpdate(nextResponse(t, responsesChan).GetUpdate())
+	assertSyncResponse(responsesChan, t)
+	for i := 0; i < 2; i++ {
+		assertAliasedUpdate(nextResponse(t, responsesChan).GetUpdate())
+	}
+}
+
+// Test_SubscribeInvalidAlias tests that an alias must start with #
+func Test_SubscribeInvalidAlias(t *testing.T) {
+	aliasPath, err := utils.ParseGNMIElements([]string{"cont1a"})
+	assert.NilError(t, err)
+	aliasRequest := &gnmi.SubscribeRequest{
+		Request: &gnmi.SubscribeRequest_Aliases{
+			Aliases: &gnmi.AliasList{
+				Alias: []*gnmi.Alias{{Path: aliasPath, Alias: "cont1a"}},
+			},
+		},
+	}
+
+	_, errChan, stop := startAliasSubscription(t, aliasRequest)
+	defer stop()
+	select {
+	case err := <-errChan:
+		assert.Equal(t, status.Code(err), codes.InvalidArgument, "%v", err)
+	case <-time.After(time.Second):
+		t.Fatal("Expected Subscribe to fail")
+	}
+}
@@ -14,7 +14,10 @@
 
 package gnmi
 
-import "github.com/onosproject/onos-lib-go/pkg/logging"
+import (
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"time"
+)
 
 var log = logging.GetLogger("northbound", "gnmi")
 
@@ -33,4 +36,40 @@ const (
 	// was requested for one or more device which is currently not connected.
 	// Not Connected devices are included in the message.
 	GnmiExtensionDevicesNotConnected = 103
+
+	// GnmiExtensionSynchronous is used in SetRequest to wait until the network change has been applied
+	// to all of its devices (or has failed) before responding. The message optionally gives the maximum
+	// time to wait as a duration e.g. "10s"
+	GnmiExtensionSynchronous = 104
+
+	// GnmiExtensionDryRun is used in SetRequest to run the change through decomposition and validation
+	// without storing it. The SetResponse carries the same extension with the per target results as JSON
+	GnmiExtensionDryRun = 105
+
+	// GnmiExtensionLeafUpdates is used in GetRequest to get a separate Update for each leaf, with its
+	// full keyed path, instead of a JSON tree
+	GnmiExtensionLeafUpdates = 106
+
+	// GnmiExtensionAtChange is used in GetRequest to get the configuration as it was right after a
+	// NetworkChange completed. The change is given by its ID, its index or a timestamp
+	GnmiExtensionAtChange = 107
+
+	// GnmiExtensionExpectedIndex is used in SetRequest to only make the change if no NetworkChange after
+	// the expected index has changed the targets, failing with Aborted otherwise. The message gives the
+	// index for all targets e.g. "42" and/or for single targets e.g. "device-1=42,device-2=40"
+	GnmiExtensionExpectedIndex = 108
+
+	// GnmiExtensionPreconditions is used in SetRequest to only make the change if paths of its targets
+	// currently have given values, failing with FailedPrecondition otherwise. The message is a JSON array
+	// of SetPrecondition
+	GnmiExtensionPreconditions = 109
+
+	// GnmiExtensionOfflinePolicy is used in SetRequest to choose what the network change does while devices it
+	// changes are not connected. The message is "wait" (the default) to wait for them, "wait-timeout=<duration>"
+	// e.g. "wait-timeout=5m" to fail the change if they are not all connected in time, or "apply-reachable" to
+	// apply the change to the connected devices straight away and to each of the others once it is connected
+	GnmiExtensionOfflinePolicy = 110
 )
+
+// defaultSyncTimeout is the time a synchronous Set waits if extension 104 gives no timeout
+const defaultSyncTimeout = 30 * time.Second
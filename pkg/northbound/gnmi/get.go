@@ -18,8 +18,10 @@ import (
 	"context"
 	"fmt"
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
+	networkchange "github.com/onosproject/onos-config/api/types/change/network"
 	devicetype "github.com/onosproject/onos-config/api/types/device"
 	"github.com/onosproject/onos-config/pkg/manager"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
 	"github.com/onosproject/onos-config/pkg/store"
 	"github.com/onosproject/onos-config/pkg/utils"
 	"github.com/onosproject/onos-config/pkg/utils/values"
@@ -35,19 +37,31 @@ func (s *Server) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetRespon
 
 	prefix := req.GetPrefix()
 
-	version, err := extractGetVersion(req)
+	ext, err := extractGetExtensions(req)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkEncoding(req.GetEncoding()); err != nil {
+		return nil, err
+	}
+	if ext.atChange != "" {
+		if req.GetType() == gnmi.GetRequest_STATE || req.GetType() == gnmi.GetRequest_OPERATIONAL {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"no history is kept of %s values - extension %d cannot be used", req.GetType(), GnmiExtensionAtChange)
+		}
+		if ext.networkChange, err = manager.GetManager().FindNetworkChange(ext.atChange); err != nil {
+			return nil, err
+		}
+	}
 
 	for _, path := range req.GetPath() {
-		update, err := s.getUpdate(version, prefix, path)
+		updates, err := s.getUpdates(ext, req.GetType(), req.GetEncoding(), prefix, path)
 		if err != nil {
-			return nil, status.Error(codes.Internal, err.Error())
+			return nil, statusError(err)
 		}
 		notification := &gnmi.Notification{
 			Timestamp: time.Now().Unix(),
-			Update:    []*gnmi.Update{update},
+			Update:    updates,
 			Prefix:    prefix,
 		}
 
@@ -55,13 +69,13 @@ func (s *Server) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetRespon
 	}
 	// Alternatively - if there's only the prefix
 	if len(req.GetPath()) == 0 {
-		update, err := s.getUpdate(version, prefix, nil)
+		updates, err := s.getUpdates(ext, req.GetType(), req.GetEncoding(), prefix, nil)
 		if err != nil {
-			return nil, status.Error(codes.Internal, err.Error())
+			return nil, statusError(err)
 		}
 		notification := &gnmi.Notification{
 			Timestamp: time.Now().Unix(),
-			Update:    []*gnmi.Update{update},
+			Update:    updates,
 			Prefix:    prefix,
 		}
 
@@ -74,8 +88,14 @@ func (s *Server) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetRespon
 	return &response, nil
 }
 
-// getUpdate utility method for getting an Update for a given path
-func (s *Server) getUpdate(version devicetype.Version, prefix *gnmi.Path, path *gnmi.Path) (*gnmi.Update, error) {
+// getUpdates utility method for getting the Updates for a given path in the given encoding, or one
+// Update per leaf if requested in the extensions
+// The data type decides where the values are taken from - CONFIG gives only the stored configuration,
+// STATE gives the stored configuration overlaid with the operational state cache of the device, where the
+// reported value of a leaf replaces its stored one, and OPERATIONAL gives only those values of the
+// operational state cache that are read only in the model of the device. ALL gives both config and state
+func (s *Server) getUpdates(ext *getExtensions, dataType gnmi.GetRequest_DataType,
+	encoding gnmi.Encoding, prefix *gnmi.Path, path *gnmi.Path) ([]*gnmi.Update, error) {
 	if (path == nil || path.Target == "") && (prefix == nil || prefix.Target == "") {
 		return nil, fmt.Errorf("Invalid request - Path %s has no target", utils.StrPath(path))
 	}
@@ -104,13 +124,39 @@ func (s *Server) getUpdate(version devicetype.Version, prefix *gnmi.Path, path *
 			Path: &allDevicesPath,
 			Val:  &gnmi.TypedValue{Value: &typedVal},
 		}
-		return update, nil
+		return []*gnmi.Update{update}, nil
+	}
+
+	configValues, deviceType, version, err := s.getPathValues(ext, dataType, target, prefix, path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case encoding == gnmi.Encoding_PROTO || ext.leafUpdates:
+		return buildLeafUpdates(prefix, path, configValues)
+	case encoding == gnmi.Encoding_JSON_IETF:
+		update, err := buildUpdateIetf(prefix, path, configValues, deviceType, version)
+		if err != nil {
+			return nil, err
+		}
+		return []*gnmi.Update{update}, nil
+	default:
+		update, err := buildUpdate(prefix, path, configValues)
+		if err != nil {
+			return nil, err
+		}
+		return []*gnmi.Update{update}, nil
 	}
+}
 
-	_, version, errTypeVersion := manager.GetManager().CheckCacheForDevice(devicetype.ID(target), "", version)
+// getPathValues gets the values of the target under the path, with the type and version of the target
+func (s *Server) getPathValues(ext *getExtensions, dataType gnmi.GetRequest_DataType, target string,
+	prefix *gnmi.Path, path *gnmi.Path) ([]*devicechange.PathValue, devicetype.Type, devicetype.Version, error) {
+	deviceType, version, errTypeVersion := manager.GetManager().CheckCacheForDevice(devicetype.ID(target), "", ext.version)
 	if errTypeVersion != nil {
 		log.Errorf("Error while extracting type and version for target %s with err %v", target, errTypeVersion)
-		return nil, status.Error(codes.InvalidArgument, errTypeVersion.Error())
+		return nil, "", "", status.Error(codes.InvalidArgument, errTypeVersion.Error())
 	}
 
 	pathAsString := utils.StrPath(path)
@@ -122,18 +168,86 @@ func (s *Server) getUpdate(version devicetype.Version, prefix *gnmi.Path, path *
 	revision := s.lastWrite
 	s.mu.RUnlock()
 
-	configValues, errGetTargetCfg := manager.GetManager().GetTargetConfig(
-		devicetype.ID(target), version, pathAsString, revision)
-	if errGetTargetCfg != nil {
-		log.Error("Error while extracting config", errGetTargetCfg)
-		return nil, errGetTargetCfg
+	configValues := make([]*devicechange.PathValue, 0)
+	if ext.networkChange != nil {
+		var errGetTargetCfg error
+		configValues, errGetTargetCfg = manager.GetManager().GetTargetConfigAt(
+			devicetype.ID(target), version, pathAsString, ext.networkChange)
+		if errGetTargetCfg != nil {
+			log.Error("Error while extracting config", errGetTargetCfg)
+			return nil, "", "", errGetTargetCfg
+		}
+	} else if dataType != gnmi.GetRequest_OPERATIONAL {
+		var errGetTargetCfg error
+		configValues, errGetTargetCfg = manager.GetManager().GetTargetConfig(
+			devicetype.ID(target), version, pathAsString, revision)
+		if errGetTargetCfg != nil {
+			log.Error("Error while extracting config", errGetTargetCfg)
+			return nil, "", "", errGetTargetCfg
+		}
 	}
 
-	stateValues := manager.GetManager().GetTargetState(target, pathAsString)
-	//Merging the two results
-	configValues = append(configValues, stateValues...)
+	// The operational state of the past is not kept
+	if dataType != gnmi.GetRequest_CONFIG && ext.networkChange == nil {
+		stateValues := manager.GetManager().GetTargetState(target, pathAsString)
+		switch dataType {
+		case gnmi.GetRequest_OPERATIONAL:
+			configValues = filterOperational(deviceType, version, stateValues)
+		case gnmi.GetRequest_STATE:
+			configValues = overlayState(configValues, stateValues)
+		default:
+			//Merging the two results
+			configValues = append(configValues, stateValues...)
+		}
+	}
+	return configValues, deviceType, version, nil
+}
 
-	return buildUpdate(prefix, path, configValues)
+// checkEncoding checks the encoding is one of those advertised in the Capabilities
+func checkEncoding(encoding gnmi.Encoding) error {
+	for _, supported := range supportedEncodings {
+		if encoding == supported {
+			return nil
+		}
+	}
+	return status.Errorf(codes.Unimplemented, "unsupported encoding %s", encoding)
+}
+
+// overlayState adds the state values to the config values. Where the device reports the applied value
+// of a config leaf, its value replaces the stored one
+func overlayState(configValues []*devicechange.PathValue, stateValues []*devicechange.PathValue) []*devicechange.PathValue {
+	reported := make(map[string]bool, len(stateValues))
+	for _, stateValue := range stateValues {
+		reported[stateValue.Path] = true
+	}
+	values := make([]*devicechange.PathValue, 0, len(configValues)+len(stateValues))
+	for _, configValue := range configValues {
+		if !reported[configValue.Path] {
+			values = append(values, configValue)
+		}
+	}
+	return append(values, stateValues...)
+}
+
+// filterOperational keeps only the values at paths that are read only in the model of the device
+func filterOperational(deviceType devicetype.Type, version devicetype.Version,
+	stateValues []*devicechange.PathValue) []*devicechange.PathValue {
+	modelreg := manager.GetManager().ModelRegistry
+	modelRoPaths, okRo := modelreg.ModelReadOnlyPaths[utils.ToModelName(deviceType, version)]
+	modelRwPaths, okRw := modelreg.ModelReadWritePaths[utils.ToModelName(deviceType, version)]
+	if !okRo || !okRw {
+		log.Warnf("Cannot filter operational values for %s %s because "+
+			"Model Plugin not available - continuing", deviceType, version)
+		return stateValues
+	}
+
+	operationalValues := make([]*devicechange.PathValue, 0, len(stateValues))
+	for _, stateValue := range stateValues {
+		if _, _, _, ok := matchRoPath(stateValue.Path, modelRoPaths, modelRwPaths); ok {
+			operationalValues = append(operationalValues, stateValue)
+		}
+	}
+	return operationalValues
 }
 
 func buildUpdate(prefix *gnmi.Path, path *gnmi.Path, configValues []*devicechange.PathValue) (*gnmi.Update, error) {
@@ -166,15 +280,99 @@ func buildUpdate(prefix *gnmi.Path, path *gnmi.Path, configValues []*devicechang
 	}, nil
 }
 
-func extractGetVersion(req *gnmi.GetRequest) (devicetype.Version, error) {
-	var version devicetype.Version
-	for _, ext := range req.GetExtension() {
-		if ext.GetRegisteredExt().GetId() == GnmiExtensionVersion {
-			version = devicetype.Version(ext.GetRegisteredExt().GetMsg())
-		} else {
-			return "", status.Error(codes.InvalidArgument, fmt.Errorf("unexpected extension %d = '%s' in Set()",
-				ext.GetRegisteredExt().GetId(), ext.GetRegisteredExt().GetMsg()).Error())
+// buildUpdateIetf builds an Update like buildUpdate, except that a JSON tree is given in the
+// JSON_IETF encoding, with its members qualified by the module names of the model of the device
+func buildUpdateIetf(prefix *gnmi.Path, path *gnmi.Path, configValues []*devicechange.PathValue,
+	deviceType devicetype.Type, version devicetype.Version) (*gnmi.Update, error) {
+	if len(configValues) <= 1 {
+		return buildUpdate(prefix, path, configValues)
+	}
+	// RFC 7951 gives decimal64 values as strings
+	json, err := store.BuildTree(configValues, true)
+	if err != nil {
+		return nil, err
+	}
+
+	modelPlugin, ok := manager.GetManager().ModelRegistry.ModelPlugins[utils.ToModelName(deviceType, version)]
+	if !ok {
+		log.Warnf("Cannot qualify JSON_IETF names for %s %s because "+
+			"Model Plugin not available - continuing", deviceType, version)
+	} else {
+		schema, err := modelPlugin.Schema()
+		if err != nil {
+			return nil, err
+		}
+		json, err = modelregistry.QualifyJSONIETF(schema["Device"], json)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return &gnmi.Update{
+		Path: path,
+		Val: &gnmi.TypedValue{
+			Value: &gnmi.TypedValue_JsonIetfVal{
+				JsonIetfVal: json,
+			},
+		},
+	}, nil
+}
+
+// buildLeafUpdates builds an Update with a scalar value for each leaf. The paths of the updates
+// are relative to the prefix, as the prefix is given in the Notification
+func buildLeafUpdates(prefix *gnmi.Path, path *gnmi.Path, configValues []*devicechange.PathValue) ([]*gnmi.Update, error) {
+	if len(configValues) == 0 {
+		update, err := buildUpdate(prefix, path, configValues)
+		if err != nil {
+			return nil, err
+		}
+		return []*gnmi.Update{update}, nil
+	}
+
+	updates := make([]*gnmi.Update, 0, len(configValues))
+	for _, configValue := range configValues {
+		leafPath, err := utils.ParseGNMIElements(utils.SplitPath(configValue.Path))
+		if err != nil {
+			return nil, err
+		}
+		if prefix != nil && len(prefix.Elem) <= len(leafPath.Elem) {
+			leafPath.Elem = leafPath.Elem[len(prefix.Elem):]
+		}
+		leafPath.Target = path.GetTarget()
+		value, err := values.NativeTypeToGnmiTypedValue(configValue.GetValue())
+		if err != nil {
+			log.Warn("Unable to convert native value to gnmi", err)
+			return nil, err
+		}
+		updates = append(updates, &gnmi.Update{
+			Path: leafPath,
+			Val:  value,
+		})
+	}
+	return updates, nil
+}
+
+// getExtensions holds the values given in the extensions of a GetRequest
+type getExtensions struct {
+	version       devicetype.Version           // May be specified as 101 in extension
+	leafUpdates   bool                         // May be specified as 106 in extension
+	atChange      string                       // May be specified as 107 in extension
+	networkChange *networkchange.NetworkChange // The change given by 107
+}
+
+func extractGetExtensions(req *gnmi.GetRequest) (*getExtensions, error) {
+	ext := &getExtensions{}
+	for _, regExt := range req.GetExtension() {
+		switch regExt.GetRegisteredExt().GetId() {
+		case GnmiExtensionVersion:
+			ext.version = devicetype.Version(regExt.GetRegisteredExt().GetMsg())
+		case GnmiExtensionLeafUpdates:
+			ext.leafUpdates = true
+		case GnmiExtensionAtChange:
+			ext.atChange = string(regExt.GetRegisteredExt().GetMsg())
+		default:
+			return nil, status.Error(codes.InvalidArgument, fmt.Errorf("unexpected extension %d = '%s' in Get()",
+				regExt.GetRegisteredExt().GetId(), regExt.GetRegisteredExt().GetMsg()).Error())
 		}
 	}
-	return version, nil
+	return ext, nil
 }
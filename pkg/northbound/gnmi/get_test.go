@@ -17,11 +17,17 @@ package gnmi
 import (
 	"context"
 	"github.com/golang/mock/gomock"
+	td1 "github.com/onosproject/config-models/modelplugin/testdevice-1.0.0/testdevice_1_0_0"
+	changetypes "github.com/onosproject/onos-config/api/types/change"
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
 	devicetype "github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
+	"github.com/onosproject/onos-config/pkg/store/stream"
 	"github.com/onosproject/onos-config/pkg/utils"
 	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/proto/gnmi_ext"
+	"github.com/openconfig/goyang/pkg/yang"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"gotest.tools/assert"
@@ -282,3 +288,248 @@ func Test_pathDoesNotExist(t *testing.T) {
 		"/leaf2w")
 	assert.Assert(t, result.Notification[0].Update[0].Val == nil)
 }
+
+// Test_getDataType shows which values are returned for each of the data types of a GetRequest
+func Test_getDataType(t *testing.T) {
+	server, mgr, mocks := setUp(t)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(devicetype.ID("Device1")).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Type:     "TestDevice",
+			Version:  "1.0.0",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+	mocks.MockStores.DeviceStateStore.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]*devicechange.PathValue{
+		{Path: cont1aCont2aLeaf2a, Value: devicechange.NewTypedValueUint64(13)},
+	}, nil).AnyTimes()
+
+	td1Schema, _ := td1.UnzipSchema()
+	readOnlyPathsTd1, readWritePathsTd1 := modelregistry.ExtractPaths(td1Schema["Device"], yang.TSUnset, "", "")
+	mgr.ModelRegistry.ModelReadOnlyPaths["TestDevice-1.0.0"] = readOnlyPathsTd1
+	mgr.ModelRegistry.ModelReadWritePaths["TestDevice-1.0.0"] = readWritePathsTd1
+
+	// The device reports the applied value of a config leaf along with its state
+	mgr.OperationalStateCacheLock.Lock()
+	mgr.OperationalStateCache["Device1"] = devicechange.TypedValueMap{
+		cont1aCont2aLeaf2a: devicechange.NewTypedValueUint64(13),
+		cont1aCont2aLeaf2c: devicechange.NewTypedValueString("state"),
+	}
+	mgr.OperationalStateCacheLock.Unlock()
+
+	prefixPath, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a"})
+	assert.NilError(t, err)
+	prefixPath.Target = "Device1"
+
+	request := gnmi.GetRequest{
+		Prefix: prefixPath,
+		Type:   gnmi.GetRequest_CONFIG,
+	}
+	result, err := server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	assert.Equal(t, result.Notification[0].Update[0].GetVal().GetUintVal(), uint64(13))
+
+	request.Type = gnmi.GetRequest_OPERATIONAL
+	result, err = server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	assert.Equal(t, result.Notification[0].Update[0].GetVal().GetStringVal(), "state")
+
+	request.Type = gnmi.GetRequest_STATE
+	result, err = server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	stateJSON := string(result.Notification[0].Update[0].GetVal().GetJsonVal())
+	assert.Assert(t, strings.Contains(stateJSON, `"leaf2a": 13`), stateJSON)
+	assert.Assert(t, strings.Contains(stateJSON, `"leaf2c": "state"`), stateJSON)
+
+	// The stored config is given for the leafs the device does not report, and the applied value
+	// for those it does
+	mgr.OperationalStateCacheLock.Lock()
+	mgr.OperationalStateCache["Device1"] = devicechange.TypedValueMap{
+		cont1aCont2aLeaf2c: devicechange.NewTypedValueString("state"),
+	}
+	mgr.OperationalStateCacheLock.Unlock()
+	result, err = server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	stateJSON = string(result.Notification[0].Update[0].GetVal().GetJsonVal())
+	assert.Assert(t, strings.Contains(stateJSON, `"leaf2a": 13`), stateJSON)
+	assert.Assert(t, strings.Contains(stateJSON, `"leaf2c": "state"`), stateJSON)
+
+	mgr.OperationalStateCacheLock.Lock()
+	mgr.OperationalStateCache["Device1"] = devicechange.TypedValueMap{
+		cont1aCont2aLeaf2a: devicechange.NewTypedValueUint64(14),
+		cont1aCont2aLeaf2c: devicechange.NewTypedValueString("state"),
+	}
+	mgr.OperationalStateCacheLock.Unlock()
+	result, err = server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	stateJSON = string(result.Notification[0].Update[0].GetVal().GetJsonVal())
+	assert.Assert(t, strings.Contains(stateJSON, `"leaf2a": 14`), stateJSON)
+	assert.Assert(t, !strings.Contains(stateJSON, `"leaf2a": 13`), stateJSON)
+
+	request.Type = gnmi.GetRequest_ALL
+	result, err = server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	assert.Assert(t, result.Notification[0].Update[0].GetVal().GetJsonVal() != nil)
+}
+
+// Test_getEncodings shows the same values given in each of the supported encodings
+func Test_getEncodings(t *testing.T) {
+	server, mgr, mocks := setUp(t)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(devicetype.ID("Device1")).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Type:     "TestDevice",
+			Version:  "1.0.0",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+	mocks.MockStores.DeviceStateStore.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]*devicechange.PathValue{
+		{Path: cont1aCont2aLeaf2a, Value: devicechange.NewTypedValueUint64(13)},
+		{Path: cont1aCont2aLeaf2b, Value: devicechange.NewTypedValueDecimal64(1234, 2)},
+	}, nil).AnyTimes()
+	mgr.ModelRegistry.ModelPlugins["TestDevice-1.0.0"] = MockModelPlugin{
+		schemaFn: td1.UnzipSchema,
+	}
+
+	prefixPath, err := utils.ParseGNMIElements([]string{"cont1a"})
+	assert.NilError(t, err)
+	prefixPath.Target = "Device1"
+
+	request := gnmi.GetRequest{
+		Prefix:   prefixPath,
+		Encoding: gnmi.Encoding_JSON,
+	}
+	result, err := server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Notification[0].Update), 1)
+	assert.Assert(t, strings.Contains(string(result.Notification[0].Update[0].GetVal().GetJsonVal()), `"cont1a": {`))
+
+	request.Encoding = gnmi.Encoding_JSON_IETF
+	result, err = server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Notification[0].Update), 1)
+	ietfJSON := string(result.Notification[0].Update[0].GetVal().GetJsonIetfVal())
+	assert.Assert(t, strings.Contains(ietfJSON, `"test1:cont1a": {`), ietfJSON)
+	assert.Assert(t, strings.Contains(ietfJSON, `"leaf2b": "12.34"`), ietfJSON)
+
+	request.Encoding = gnmi.Encoding_PROTO
+	result, err = server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Notification[0].Update), 2)
+	for _, update := range result.Notification[0].Update {
+		switch utils.StrPath(update.Path) {
+		case "/cont2a/leaf2a":
+			assert.Equal(t, update.GetVal().GetUintVal(), uint64(13))
+		case "/cont2a/leaf2b":
+			assert.Equal(t, update.GetVal().GetDecimalVal().GetDigits(), int64(1234))
+		default:
+			t.Errorf("Unexpected update path %s", utils.StrPath(update.Path))
+		}
+	}
+
+	request.Encoding = gnmi.Encoding_BYTES
+	_, err = server.Get(context.TODO(), &request)
+	assert.Equal(t, status.Code(err), codes.Unimplemented)
+}
+
+// Test_getLeafUpdates shows extension 106 giving an Update for each leaf instead of a JSON tree
+func Test_getLeafUpdates(t *testing.T) {
+	server, _, mocks := setUp(t)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(devicetype.ID("Device1")).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Type:     "TestDevice",
+			Version:  "1.0.0",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+	mocks.MockStores.DeviceStateStore.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]*devicechange.PathValue{
+		{Path: "/cont1a/list2a[name=0123]/name", Value: devicechange.NewTypedValueString("0123")},
+		{Path: "/cont1a/list2a[name=0123]/tx-power", Value: devicechange.NewTypedValueUint64(5)},
+	}, nil).AnyTimes()
+
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "list2a[name=*]"})
+	assert.NilError(t, err)
+	path.Target = "Device1"
+
+	request := gnmi.GetRequest{
+		Path: []*gnmi.Path{path},
+		Extension: []*gnmi_ext.Extension{{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id: GnmiExtensionLeafUpdates,
+				},
+			},
+		}},
+	}
+	result, err := server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Notification), 1)
+	assert.Equal(t, len(result.Notification[0].Update), 2)
+
+	nameUpdate := result.Notification[0].Update[0]
+	assert.Equal(t, utils.StrPath(nameUpdate.Path), "/cont1a/list2a[name=0123]/name")
+	assert.Equal(t, nameUpdate.Path.Target, "Device1")
+	assert.Equal(t, nameUpdate.Path.Elem[1].Key["name"], "0123")
+	assert.Equal(t, nameUpdate.GetVal().GetStringVal(), "0123")
+
+	txPowerUpdate := result.Notification[0].Update[1]
+	assert.Equal(t, utils.StrPath(txPowerUpdate.Path), "/cont1a/list2a[name=0123]/tx-power")
+	assert.Equal(t, txPowerUpdate.GetVal().GetUintVal(), uint64(5))
+
+	// The JSON tree remains the default
+	request.Extension = nil
+	result, err = server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Notification[0].Update), 1)
+	assert.Assert(t, result.Notification[0].Update[0].GetVal().GetJsonVal() != nil)
+}
+
+// Test_getAtChange shows extension 107 giving the configuration as it was after a network change
+func Test_getAtChange(t *testing.T) {
+	server, mocks := setUpForGetSetTests(t)
+	mocks.MockStores.DeviceSnapshotStore.EXPECT().Load(gomock.Any()).Return(nil, nil).AnyTimes()
+	mocks.MockStores.DeviceChangesStore.EXPECT().List(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(device devicetype.VersionedID, c chan<- *devicechange.DeviceChange) (stream.Context, error) {
+			go func() {
+				c <- &devicechange.DeviceChange{
+					NetworkChange: devicechange.NetworkChangeRef{ID: networkChange1},
+					Change: &devicechange.Change{
+						Values: []*devicechange.ChangeValue{
+							{Path: cont1aCont2aLeaf2a, Value: devicechange.NewTypedValueUint64(7)},
+						},
+					},
+					Status: changetypes.Status{Phase: changetypes.Phase_CHANGE, State: changetypes.State_COMPLETE},
+				}
+				close(c)
+			}()
+			return stream.NewContext(func() {}), nil
+		}).AnyTimes()
+
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	assert.NilError(t, err)
+	path.Target = "Device1"
+
+	atChangeExt := &gnmi_ext.RegisteredExtension{
+		Id:  GnmiExtensionAtChange,
+		Msg: []byte(networkChange1),
+	}
+	request := gnmi.GetRequest{
+		Path: []*gnmi.Path{path},
+		Extension: []*gnmi_ext.Extension{{
+			Ext: &gnmi_ext.Extension_RegisteredExt{RegisteredExt: atChangeExt},
+		}},
+	}
+	result, err := server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	assert.Equal(t, result.Notification[0].Update[0].GetVal().GetUintVal(), uint64(7))
+
+	request.Type = gnmi.GetRequest_STATE
+	_, err = server.Get(context.TODO(), &request)
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+
+	request.Type = gnmi.GetRequest_ALL
+	atChangeExt.Msg = []byte("no-such-change")
+	_, err = server.Get(context.TODO(), &request)
+	assert.Equal(t, status.Code(err), codes.NotFound)
+}
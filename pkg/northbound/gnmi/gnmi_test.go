@@ -209,6 +209,15 @@ func setUpChangesMock(mocks *AllMocks) {
 
 // setUp should not depend on any global variables
 func setUp(t *testing.T) (*Server, *manager.Manager, *AllMocks) {
+	server, mgr, allMocks := setUpWithoutWatchMock(t)
+	setUpWatchMock(allMocks)
+	log.Info("Finished setUp()")
+
+	return server, mgr, allMocks
+}
+
+// setUpWithoutWatchMock is setUp leaving the watches of the changes to the test
+func setUpWithoutWatchMock(t *testing.T) (*Server, *manager.Manager, *AllMocks) {
 	var server = &Server{}
 	var allMocks AllMocks
 
@@ -247,9 +256,6 @@ func setUp(t *testing.T) (*Server, *manager.Manager, *AllMocks) {
 	go listenToTopoLoading(mgr.TopoChannel)
 	//go mgr.Dispatcher.Listen(mgr.ChangesChannel)
 
-	setUpWatchMock(&allMocks)
-	log.Info("Finished setUp()")
-
 	return server, mgr, &allMocks
 }
 
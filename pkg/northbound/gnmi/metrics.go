@@ -0,0 +1,30 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	prometheus.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: "onos_config",
+		Subsystem: "gnmi",
+		Name:      "subscribe_dropped_updates_total",
+		Help:      "The number of updates the streaming subscriptions dropped because their client did not keep up",
+	}, func() float64 {
+		return float64(DroppedUpdates())
+	}))
+}
@@ -0,0 +1,170 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	changetypes "github.com/onosproject/onos-config/api/types/change"
+	networkchange "github.com/onosproject/onos-config/api/types/change/network"
+	"github.com/onosproject/onos-config/pkg/manager"
+	streams "github.com/onosproject/onos-config/pkg/store/stream"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+// NetworkChangesTarget is the virtual target under which the status of the network changes can be
+// subscribed to. Like the "*" target of Get it does not stand for a device
+const NetworkChangesTarget = "@network-changes"
+
+// networkChangeElem is the list of network changes under the network changes target, keyed by change ID
+const networkChangeElem = "network-change"
+
+// checkNetworkChangesSubscription checks a subscription to the network changes target can be served -
+// the status of the network changes is only sent on change
+func checkNetworkChangesSubscription(subscribe *gnmi.SubscriptionList) error {
+	for _, sub := range subscribe.Subscription {
+		if subscriptionTarget(subscribe.Prefix, sub.Path) == NetworkChangesTarget &&
+			sub.Mode == gnmi.SubscriptionMode_SAMPLE {
+			return status.Errorf(codes.InvalidArgument, "target %s cannot be sampled", NetworkChangesTarget)
+		}
+	}
+	return nil
+}
+
+// sendNetworkChanges sends the current status of each network change under the path
+func sendNetworkChanges(stream gnmi.GNMI_SubscribeServer, prefix *gnmi.Path, path *gnmi.Path) error {
+	subs := []*utils.PathMatcher{subscriptionMatcher(prefix, path)}
+	changeCh := make(chan *networkchange.NetworkChange)
+	ctx, err := manager.GetManager().NetworkChangesStore.List(changeCh)
+	if err != nil {
+		return err
+	}
+	defer ctx.Close()
+	for change := range changeCh {
+		response := buildNetworkChangeResponse(change, subs)
+		if response == nil {
+			continue
+		}
+		if err := sendResponse(response, stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listenForNetworkChanges watches the network changes for the paths subscribed to under the network changes target
+func listenForNetworkChanges(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manager, subs []*utils.PathMatcher,
+	synced chan struct{}, resChan chan result) {
+	eventCh := make(chan streams.Event)
+	ctx, err := mgr.NetworkChangesStore.Watch(eventCh)
+	if err != nil {
+		log.Errorf("Cant watch for network changes. error %s", err.Error())
+		sendResult(resChan, result{success: false, err: err})
+		return
+	}
+	go listenForNetworkChangeUpdates(stream, eventCh, ctx, subs, synced, resChan)
+}
+
+// listenForNetworkChangeUpdates sends the status of a network change each time its phase or state changes,
+// until the stream is done
+func listenForNetworkChangeUpdates(stream gnmi.GNMI_SubscribeServer, eventCh chan streams.Event,
+	ctx streams.Context, subs []*utils.PathMatcher, synced chan struct{}, resChan chan result) {
+	defer closeWatch(ctx, eventCh)
+	select {
+	case <-synced:
+	case <-stream.Context().Done():
+		return
+	}
+	lastStatus := make(map[networkchange.ID]changetypes.Status)
+	for {
+		var event streams.Event
+		select {
+		case e, ok := <-eventCh:
+			if !ok {
+				return
+			}
+			event = e
+		case <-stream.Context().Done():
+			return
+		}
+		change, ok := event.Object.(*networkchange.NetworkChange)
+		if !ok {
+			log.Error("Could not convert event to NetworkChange")
+			continue
+		}
+		if event.Type == streams.Deleted {
+			delete(lastStatus, change.ID)
+			continue
+		}
+		last, ok := lastStatus[change.ID]
+		if ok && last.Phase == change.Status.Phase && last.State == change.Status.State {
+			continue
+		}
+		lastStatus[change.ID] = change.Status
+
+		response := buildNetworkChangeResponse(change, subs)
+		if response == nil {
+			continue
+		}
+		if err := sendResponse(response, stream); err != nil {
+			log.Error("Error in sending network change status ", err)
+			sendResult(resChan, result{success: false, err: err})
+			return
+		}
+	}
+}
+
+// buildNetworkChangeResponse builds a notification with the ID, phase, state and message of the network change,
+// leaving out those not under the paths subscribed to. It returns nil if none is
+func buildNetworkChangeResponse(change *networkchange.NetworkChange, subs []*utils.PathMatcher) *gnmi.SubscribeResponse {
+	prefix := &gnmi.Path{
+		Target: NetworkChangesTarget,
+		Elem: []*gnmi.PathElem{
+			{Name: networkChangeElem, Key: map[string]string{"id": string(change.ID)}},
+		},
+	}
+	leaves := []struct {
+		name  string
+		value string
+	}{
+		{name: "id", value: string(change.ID)},
+		{name: "phase", value: change.Status.Phase.String()},
+		{name: "state", value: change.Status.State.String()},
+		{name: "message", value: change.Status.Message},
+	}
+
+	notification := &gnmi.Notification{
+		Timestamp: time.Now().Unix(),
+		Prefix:    prefix,
+	}
+	for _, leaf := range leaves {
+		path := &gnmi.Path{Elem: []*gnmi.PathElem{{Name: leaf.name}}}
+		if !matchPaths(&gnmi.Path{Elem: []*gnmi.PathElem{prefix.Elem[0], path.Elem[0]}}, subs) {
+			continue
+		}
+		notification.Update = append(notification.Update, &gnmi.Update{
+			Path: path,
+			Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_StringVal{StringVal: leaf.value}},
+		})
+	}
+	if len(notification.Update) == 0 {
+		return nil
+	}
+	return &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{Update: notification},
+	}
+}
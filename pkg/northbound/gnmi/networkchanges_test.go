@@ -0,0 +1,170 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"github.com/golang/mock/gomock"
+	changetypes "github.com/onosproject/onos-config/api/types/change"
+	networkchange "github.com/onosproject/onos-config/api/types/change/network"
+	networkchangestore "github.com/onosproject/onos-config/pkg/store/change/network"
+	"github.com/onosproject/onos-config/pkg/store/stream"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startNetworkChangesSubscription subscribes to the network changes target - one change is stored already,
+// and the status of a new change goes from PENDING to COMPLETE
+func startNetworkChangesSubscription(t *testing.T, request *gnmi.SubscribeRequest) (chan *gnmi.SubscribeResponse, func()) {
+	server, mgr, mocks := setUpWithoutWatchMock(t)
+	mocks.MockStores.NetworkChangesStore.EXPECT().List(gomock.Any()).DoAndReturn(
+		func(ch chan<- *networkchange.NetworkChange) (stream.Context, error) {
+			go func() {
+				ch <- &networkchange.NetworkChange{
+					ID:     "change-1",
+					Status: changetypes.Status{State: changetypes.State_COMPLETE},
+				}
+				close(ch)
+			}()
+			return stream.NewContext(func() {}), nil
+		}).AnyTimes()
+	mocks.MockStores.NetworkChangesStore.EXPECT().Watch(gomock.Any()).DoAndReturn(
+		func(ch chan<- stream.Event, opts ...networkchangestore.WatchOption) (stream.Context, error) {
+			go func() {
+				for _, event := range []stream.Event{
+					{Type: stream.Created, Object: &networkchange.NetworkChange{ID: "change-2"}},
+					// The status has not changed
+					{Type: stream.Updated, Object: &networkchange.NetworkChange{ID: "change-2", Revision: 2}},
+					{Type: stream.Updated, Object: &networkchange.NetworkChange{
+						ID:     "change-2",
+						Status: changetypes.Status{State: changetypes.State_COMPLETE, Message: "applied"},
+					}},
+				} {
+					ch <- event
+				}
+				close(ch)
+			}()
+			return stream.NewContext(func() {}), nil
+		}).AnyTimes()
+
+	responsesChan := make(chan *gnmi.SubscribeResponse, 10)
+	serverFake := gNMISubscribeServerFake{
+		Request:   request,
+		Responses: responsesChan,
+		Signal:    make(chan struct{}),
+	}
+	var wg sync.WaitGroup
+	go func() {
+		err := server.Subscribe(serverFake)
+		assert.NilError(t, err, "Unexpected error doing Subscribe")
+	}()
+	serverFake.Signal <- struct{}{}
+	return responsesChan, func() {
+		// No operational state listener is needed for the network changes
+		assert.Equal(t, len(mgr.Dispatcher.GetListeners()), 0)
+		tearDown(mgr, &wg)
+	}
+}
+
+func assertNetworkChangeResponse(t *testing.T, responsesChan chan *gnmi.SubscribeResponse, id string,
+	expected map[string]string) {
+	select {
+	case response := <-responsesChan:
+		notification := response.GetUpdate()
+		assert.Assert(t, notification != nil, "Update should not be nil")
+		assert.Equal(t, notification.Prefix.Target, NetworkChangesTarget)
+		assert.Equal(t, utils.StrPath(notification.Prefix), "/network-change[id="+id+"]")
+		values := make(map[string]string)
+		for _, update := range notification.Update {
+			values[utils.StrPath(update.Path)] = update.Val.GetStringVal()
+		}
+		assert.DeepEqual(t, values, expected)
+	case <-time.After(time.Second):
+		t.Fatal("Expected network change response")
+	}
+}
+
+// Test_SubscribeNetworkChanges tests that the status of the network changes is sent on each transition
+func Test_SubscribeNetworkChanges(t *testing.T) {
+	request := buildRequest(&gnmi.Path{Target: NetworkChangesTarget}, gnmi.SubscriptionList_STREAM)
+	responsesChan, stop := startNetworkChangesSubscription(t, request)
+	defer stop()
+
+	assertNetworkChangeResponse(t, responsesChan, "change-1", map[string]string{
+		"/id": "change-1", "/phase": "CHANGE", "/state": "COMPLETE", "/message": "",
+	})
+	assertSyncResponse(responsesChan, t)
+	assertNetworkChangeResponse(t, responsesChan, "change-2", map[string]string{
+		"/id": "change-2", "/phase": "CHANGE", "/state": "PENDING", "/message": "",
+	})
+	assertNetworkChangeResponse(t, responsesChan, "change-2", map[string]string{
+		"/id": "change-2", "/phase": "CHANGE", "/state": "COMPLETE", "/message": "applied",
+	})
+	select {
+	case response := <-responsesChan:
+		t.Fatalf("Unexpected response %v", response)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// Test_SubscribeNetworkChangeState tests that only the paths subscribed to are sent
+func Test_SubscribeNetworkChangeState(t *testing.T) {
+	path, err := utils.ParseGNMIElements([]string{"network-change[id=change-2]", "state"})
+	assert.NilError(t, err)
+	path.Target = NetworkChangesTarget
+	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	request.GetSubscribe().UpdatesOnly = true
+	responsesChan, stop := startNetworkChangesSubscription(t, request)
+	defer stop()
+
+	assertSyncResponse(responsesChan, t)
+	assertNetworkChangeResponse(t, responsesChan, "change-2", map[string]string{"/state": "PENDING"})
+	assertNetworkChangeResponse(t, responsesChan, "change-2", map[string]string{"/state": "COMPLETE"})
+}
+
+// Test_SubscribeNetworkChangesOnce tests that the current status of the network changes is sent once
+func Test_SubscribeNetworkChangesOnce(t *testing.T) {
+	request := buildRequest(&gnmi.Path{Target: NetworkChangesTarget}, gnmi.SubscriptionList_ONCE)
+	responsesChan, stop := startNetworkChangesSubscription(t, request)
+	defer stop()
+
+	assertNetworkChangeResponse(t, responsesChan, "change-1", map[string]string{
+		"/id": "change-1", "/phase": "CHANGE", "/state": "COMPLETE", "/message": "",
+	})
+	assertSyncResponse(responsesChan, t)
+}
+
+// Test_SubscribeNetworkChangesSample tests that the network changes cannot be sampled
+func Test_SubscribeNetworkChangesSample(t *testing.T) {
+	server, mgr, _ := setUp(t)
+	var wg sync.WaitGroup
+	defer tearDown(mgr, &wg)
+
+	serverFake := gNMISubscribeServerFake{
+		Request:   buildSampleRequest(&gnmi.Path{Target: NetworkChangesTarget}, time.Second, false, 0),
+		Responses: make(chan *gnmi.SubscribeResponse, 1),
+		Signal:    make(chan struct{}),
+	}
+	go func() {
+		serverFake.Signal <- struct{}{}
+	}()
+	err := server.Subscribe(serverFake)
+	assert.Equal(t, status.Code(err), codes.InvalidArgument, "%v", err)
+}
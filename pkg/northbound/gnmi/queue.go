@@ -0,0 +1,275 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"fmt"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultSubscribeQueueSize is the number of responses a streaming subscription holds for a slow client
+const DefaultSubscribeQueueSize = 1000
+
+// OverflowPolicy is what a streaming subscription does when its queue of responses is full
+type OverflowPolicy string
+
+const (
+	// OverflowDropOldest drops the oldest update in the queue to make room for the new one
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+	// OverflowCoalesce replaces an update queued for the same paths with the new one, and drops the
+	// oldest update if there is none
+	OverflowCoalesce OverflowPolicy = "coalesce"
+	// OverflowDisconnect ends the subscription with a ResourceExhausted error
+	OverflowDisconnect OverflowPolicy = "disconnect"
+)
+
+// ParseOverflowPolicy returns the overflow policy of the given name. An empty name is the default
+// policy, which drops the oldest update
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(name); policy {
+	case "":
+		return OverflowDropOldest, nil
+	case OverflowDropOldest, OverflowCoalesce, OverflowDisconnect:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown overflow policy %s - expecting %s, %s or %s",
+			name, OverflowDropOldest, OverflowCoalesce, OverflowDisconnect)
+	}
+}
+
+// droppedUpdates counts the updates dropped by all the subscriptions
+var droppedUpdates uint64
+
+// DroppedUpdates returns the number of updates the streaming subscriptions have dropped because
+// their client did not keep up
+func DroppedUpdates() uint64 {
+	return atomic.LoadUint64(&droppedUpdates)
+}
+
+// queuedResponse is a response waiting in a subscription queue. Its key identifies the paths it
+// updates, for coalescing
+type queuedResponse struct {
+	response *gnmi.SubscribeResponse
+	key      string
+	// update is true for the notifications that may be dropped - those other than alias definitions
+	update bool
+}
+
+// subscriptionQueue is a Subscribe stream that queues the responses sent on it, so that the
+// subscription never waits for its client. The responses are sent on the underlying stream in
+// the order they were queued. The size of the queue bounds the updates it holds, and a full queue
+// applies the overflow policy. Sync responses and alias definitions are always queued
+type subscriptionQueue struct {
+	gnmi.GNMI_SubscribeServer
+	size   int
+	policy OverflowPolicy
+
+	mu        sync.Mutex
+	responses []queuedResponse
+	updates   int
+	dropped   uint64
+	// err is the error the subscription failed with, returned by all the sends that follow
+	err    error
+	closed bool
+	ready  chan struct{}
+	done   chan struct{}
+}
+
+// newSubscriptionQueue starts sending the responses queued for the stream
+func newSubscriptionQueue(stream gnmi.GNMI_SubscribeServer, size int, policy OverflowPolicy) *subscriptionQueue {
+	if size <= 0 {
+		size = DefaultSubscribeQueueSize
+	}
+	if policy == "" {
+		policy = OverflowDropOldest
+	}
+	queue := &subscriptionQueue{
+		GNMI_SubscribeServer: stream,
+		size:                 size,
+		policy:               policy,
+		ready:                make(chan struct{}, 1),
+		done:                 make(chan struct{}),
+	}
+	go queue.drain()
+	return queue
+}
+
+// Send queues the response to be sent to the client. It only fails once the subscription has failed
+func (q *subscriptionQueue) Send(response *gnmi.SubscribeResponse) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err != nil {
+		return q.err
+	} else if q.closed {
+		return nil
+	}
+
+	// A sync response just after another one tells the client nothing more
+	if response.GetSyncResponse() && len(q.responses) > 0 &&
+		q.responses[len(q.responses)-1].response.GetSyncResponse() {
+		return nil
+	}
+
+	queued := queuedResponse{
+		response: response,
+		key:      coalesceKey(response),
+		update:   response.GetUpdate() != nil && response.GetUpdate().GetAlias() == "",
+	}
+	if queued.update && q.updates >= q.size {
+		switch q.policy {
+		case OverflowDisconnect:
+			q.responses = nil
+			q.updates = 0
+			q.err = status.Errorf(codes.ResourceExhausted,
+				"client too slow, the queue of %d subscription responses is full", q.size)
+			log.Warn("Disconnecting subscription ", q.err)
+			return q.err
+		case OverflowCoalesce:
+			if q.coalesce(queued) {
+				return nil
+			}
+		}
+		q.dropOldest()
+	}
+	q.responses = append(q.responses, queued)
+	if queued.update {
+		q.updates++
+	}
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// coalesce replaces the response queued for the same paths, if any
+func (q *subscriptionQueue) coalesce(queued queuedResponse) bool {
+	if queued.key == "" {
+		return false
+	}
+	for i, r := range q.responses {
+		if r.key == queued.key {
+			q.responses[i] = queued
+			q.countDropped()
+			return true
+		}
+	}
+	return false
+}
+
+// dropOldest drops the oldest update, along with the sync response that follows it if another one
+// comes just before it
+func (q *subscriptionQueue) dropOldest() {
+	for i, r := range q.responses {
+		if !r.update {
+			continue
+		}
+		next := i + 1
+		if next < len(q.responses) && q.responses[next].response.GetSyncResponse() &&
+			i > 0 && q.responses[i-1].response.GetSyncResponse() {
+			next++
+		}
+		q.responses = append(q.responses[:i], q.responses[next:]...)
+		q.updates--
+		q.countDropped()
+		return
+	}
+}
+
+func (q *subscriptionQueue) countDropped() {
+	if q.dropped == 0 {
+		log.Warnf("Subscription client too slow, dropping updates (%s)", q.policy)
+	}
+	q.dropped++
+	atomic.AddUint64(&droppedUpdates, 1)
+}
+
+// drain sends the queued responses on the stream until the queue is closed or a send fails
+func (q *subscriptionQueue) drain() {
+	for {
+		select {
+		case <-q.done:
+			return
+		case <-q.ready:
+		}
+		for {
+			q.mu.Lock()
+			if len(q.responses) == 0 || q.err != nil || q.closed {
+				q.mu.Unlock()
+				break
+			}
+			queued := q.responses[0]
+			q.responses[0] = queuedResponse{}
+			q.responses = q.responses[1:]
+			if queued.update {
+				q.updates--
+			}
+			q.mu.Unlock()
+
+			if err := q.GNMI_SubscribeServer.Send(queued.response); err != nil {
+				log.Warn("Error in sending response to client ", err)
+				q.mu.Lock()
+				q.err = status.Error(codes.Internal, err.Error())
+				q.mu.Unlock()
+				return
+			}
+		}
+	}
+}
+
+// close stops sending the queued responses. Responses sent afterwards are discarded
+func (q *subscriptionQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	q.responses = nil
+	q.updates = 0
+	if q.dropped > 0 {
+		log.Warnf("Subscription ended having dropped %d updates", q.dropped)
+	}
+	close(q.done)
+}
+
+// coalesceKey identifies the paths updated or deleted by a notification. Alias definitions and
+// other responses have no key and are never coalesced
+func coalesceKey(response *gnmi.SubscribeResponse) string {
+	notification := response.GetUpdate()
+	if notification == nil || notification.GetAlias() != "" {
+		return ""
+	}
+	paths := make([]string, 0, len(notification.GetUpdate())+len(notification.GetDelete()))
+	for _, update := range notification.GetUpdate() {
+		paths = append(paths, update.GetPath().GetTarget()+utils.StrPath(update.GetPath()))
+	}
+	for _, path := range notification.GetDelete() {
+		paths = append(paths, path.GetTarget()+utils.StrPath(path))
+	}
+	if len(paths) == 0 {
+		return ""
+	}
+	sort.Strings(paths)
+	prefix := notification.GetPrefix()
+	return prefix.GetTarget() + utils.StrPath(prefix) + "|" + strings.Join(paths, ",")
+}
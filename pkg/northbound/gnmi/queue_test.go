@@ -0,0 +1,155 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+	"testing"
+	"time"
+)
+
+// startSlowQueue returns a queue whose client only receives a response when it is read from the
+// channel. A first response is sent and held by the client, so that the next ones stay queued
+func startSlowQueue(t *testing.T, size int, policy OverflowPolicy) (*subscriptionQueue, chan *gnmi.SubscribeResponse) {
+	responsesChan := make(chan *gnmi.SubscribeResponse)
+	queue := newSubscriptionQueue(gNMISubscribeServerFake{Responses: responsesChan}, size, policy)
+	assert.NilError(t, queue.Send(buildLeafResponse("leaf0", 0)))
+	for {
+		queue.mu.Lock()
+		queued := len(queue.responses)
+		queue.mu.Unlock()
+		if queued == 0 {
+			return queue, responsesChan
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func buildLeafResponse(leaf string, value uint64) *gnmi.SubscribeResponse {
+	path, _ := utils.ParseGNMIElements([]string{"cont1a", leaf})
+	path.Target = "Device1"
+	return &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Update: []*gnmi.Update{{
+					Path: path,
+					Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: value}},
+				}},
+			},
+		},
+	}
+}
+
+// assertLeafResponses asserts that the client receives the responses for the leaves in turn
+func assertLeafResponses(t *testing.T, responsesChan chan *gnmi.SubscribeResponse, leaves ...string) {
+	for _, leaf := range leaves {
+		select {
+		case response := <-responsesChan:
+			update := response.GetUpdate().GetUpdate()
+			assert.Equal(t, len(update), 1)
+			assert.Equal(t, utils.StrPath(update[0].Path), "/cont1a/"+leaf)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a response for %s", leaf)
+		}
+	}
+}
+
+func Test_QueueDropOldest(t *testing.T) {
+	dropped := DroppedUpdates()
+	queue, responsesChan := startSlowQueue(t, 2, OverflowDropOldest)
+	defer queue.close()
+
+	assert.NilError(t, queue.Send(buildLeafResponse("leaf1", 1)))
+	assert.NilError(t, queue.Send(buildLeafResponse("leaf2", 2)))
+	assert.NilError(t, queue.Send(buildLeafResponse("leaf3", 3)))
+	assert.Equal(t, DroppedUpdates()-dropped, uint64(1))
+	assertLeafResponses(t, responsesChan, "leaf0", "leaf2", "leaf3")
+}
+
+func Test_QueueCoalesce(t *testing.T) {
+	queue, responsesChan := startSlowQueue(t, 2, OverflowCoalesce)
+	defer queue.close()
+
+	assert.NilError(t, queue.Send(buildLeafResponse("leaf1", 1)))
+	assert.NilError(t, queue.Send(buildLeafResponse("leaf2", 2)))
+	// The queue is full - the queued update of leaf1 is replaced
+	assert.NilError(t, queue.Send(buildLeafResponse("leaf1", 3)))
+	assert.Equal(t, queue.dropped, uint64(1))
+	// Nothing to coalesce with - the oldest is dropped
+	assert.NilError(t, queue.Send(buildLeafResponse("leaf3", 4)))
+	assert.Equal(t, queue.dropped, uint64(2))
+
+	assertLeafResponses(t, responsesChan, "leaf0", "leaf2", "leaf3")
+}
+
+func Test_QueueDisconnect(t *testing.T) {
+	queue, _ := startSlowQueue(t, 1, OverflowDisconnect)
+	defer queue.close()
+
+	assert.NilError(t, queue.Send(buildLeafResponse("leaf1", 1)))
+	err := queue.Send(buildLeafResponse("leaf2", 2))
+	assert.Equal(t, status.Code(err), codes.ResourceExhausted, "%v", err)
+	// The subscription has failed for good
+	err = queue.Send(buildSyncResponse())
+	assert.Equal(t, status.Code(err), codes.ResourceExhausted, "%v", err)
+}
+
+func Test_QueueKeepsAliasDefinitions(t *testing.T) {
+	queue, responsesChan := startSlowQueue(t, 1, OverflowDropOldest)
+	defer queue.close()
+
+	definition := &gnmi.SubscribeResponse{
+		Response: &gnmi.SubscribeResponse_Update{
+			Update: &gnmi.Notification{
+				Prefix: &gnmi.Path{Target: "Device1", Elem: []*gnmi.PathElem{{Name: "cont1a"}}},
+				Alias:  "#1",
+			},
+		},
+	}
+	assert.NilError(t, queue.Send(definition))
+	assert.NilError(t, queue.Send(buildLeafResponse("leaf1", 1)))
+	assert.NilError(t, queue.Send(buildSyncResponse()))
+	// The queue is full - the update of leaf1 is dropped but not the alias definition
+	assert.NilError(t, queue.Send(buildLeafResponse("leaf2", 2)))
+	// Consecutive sync responses are sent once
+	assert.NilError(t, queue.Send(buildSyncResponse()))
+	assert.NilError(t, queue.Send(buildSyncResponse()))
+
+	assertLeafResponses(t, responsesChan, "leaf0")
+	assert.Equal(t, (<-responsesChan).GetUpdate().GetAlias(), "#1")
+	assert.Assert(t, (<-responsesChan).GetSyncResponse())
+	assertLeafResponses(t, responsesChan, "leaf2")
+	assert.Assert(t, (<-responsesChan).GetSyncResponse())
+	select {
+	case response := <-responsesChan:
+		t.Fatalf("Unexpected response %v", response)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func Test_ParseOverflowPolicy(t *testing.T) {
+	policy, err := ParseOverflowPolicy("")
+	assert.NilError(t, err)
+	assert.Equal(t, policy, OverflowDropOldest)
+	policy, err = ParseOverflowPolicy("coalesce")
+	assert.NilError(t, err)
+	assert.Equal(t, policy, OverflowCoalesce)
+	_, err = ParseOverflowPolicy("drop-newest")
+	assert.ErrorContains(t, err, "unknown overflow policy")
+}
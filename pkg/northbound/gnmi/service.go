@@ -30,22 +30,38 @@ import (
 	"github.com/onosproject/onos-lib-go/pkg/northbound"
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// supportedEncodings are the encodings of values in Get and Subscribe
+var supportedEncodings = []gnmi.Encoding{gnmi.Encoding_JSON, gnmi.Encoding_JSON_IETF, gnmi.Encoding_PROTO}
+
 // Service implements Service for GNMI
 type Service struct {
 	northbound.Service
+	// SubscribeQueueSize is the number of responses a streaming subscription holds for a slow
+	// client. Zero is DefaultSubscribeQueueSize
+	SubscribeQueueSize int
+	// SubscribeOverflowPolicy is applied when the queue of a streaming subscription is full
+	SubscribeOverflowPolicy OverflowPolicy
 }
 
 // Register registers the GNMI server with grpc
 func (s Service) Register(r *grpc.Server) {
-	gnmi.RegisterGNMIServer(r, &Server{})
+	gnmi.RegisterGNMIServer(r, &Server{
+		subscribeQueueSize: s.SubscribeQueueSize,
+		overflowPolicy:     s.SubscribeOverflowPolicy,
+	})
 }
 
 // Server implements the grpc GNMI service
 type Server struct {
-	mu        sync.RWMutex
-	lastWrite networkchange.Revision
+	mu                 sync.RWMutex
+	lastWrite          networkchange.Revision
+	setMu              sync.RWMutex
+	subscribeQueueSize int
+	overflowPolicy     OverflowPolicy
 }
 
 // Capabilities implements gNMI Capabilities
@@ -53,11 +69,19 @@ func (s *Server) Capabilities(ctx context.Context, req *gnmi.CapabilityRequest)
 	v, _ := getGNMIServiceVersion()
 	return &gnmi.CapabilityResponse{
 		SupportedModels:    manager.GetManager().ModelRegistry.Capabilities(),
-		SupportedEncodings: []gnmi.Encoding{gnmi.Encoding_JSON},
+		SupportedEncodings: supportedEncodings,
 		GNMIVersion:        *v,
 	}, nil
 }
 
+// statusError returns the error as it is if it carries a gRPC status, or else as an Internal error
+func statusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
 // getGNMIServiceVersion returns a pointer to the gNMI service version string.
 // The method is non-trivial because of the way it is defined in the proto file.
 func getGNMIServiceVersion() (*string, error) {
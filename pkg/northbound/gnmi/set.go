@@ -16,6 +16,7 @@ package gnmi
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/docker/docker/pkg/namesgenerator"
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
@@ -31,7 +32,10 @@ import (
 	"github.com/openconfig/gnmi/proto/gnmi_ext"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -40,37 +44,31 @@ type mapTargetRemoves map[string][]string
 
 // Set implements gNMI Set
 func (s *Server) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetResponse, error) {
-	// There is only one set of extensions in Set request, regardless of number of
-	// updates
-	var (
-		netCfgChangeName string             // May be specified as 100 in extension
-		version          devicetype.Version // May be specified as 101 in extension
-		deviceType       devicetype.Type    // May be specified as 102 in extension
-	)
-
 	targetUpdates := make(mapTargetUpdates)
 	targetRemoves := make(mapTargetRemoves)
+	targetReplaces := make(mapTargetRemoves)
 
 	log.Infof("gNMI Set Request %v", req)
-	//Update
-	for _, u := range req.GetUpdate() {
+	//Replace
+	for _, u := range req.GetReplace() {
 		target := u.Path.GetTarget()
 		if target == "" { //Try the prefix
 			target = req.GetPrefix().GetTarget()
 		}
 		if target == "" {
-			return nil, status.Error(codes.InvalidArgument, "no target given in update")
+			return nil, status.Errorf(codes.InvalidArgument, "No target given in replace %v", u)
 		}
 		var err error
 		targetUpdates[target], err = s.formatUpdateOrReplace(req.GetPrefix(), u, targetUpdates)
 		if err != nil {
-			log.Warn("Error in update ", err)
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			log.Warn("Error in replace ", err)
+			return nil, status.Errorf(codes.InvalidArgument, "Error in replace of %s %v", utils.StrPath(u.Path), err)
 		}
+		targetReplaces[target] = append(targetReplaces[target], prefixedPath(req.GetPrefix(), u.Path))
 	}
 
-	//Replace
-	for _, u := range req.GetReplace() {
+	//Update
+	for _, u := range req.GetUpdate() {
 		target := u.Path.GetTarget()
 		if target == "" { //Try the prefix
 			target = req.GetPrefix().GetTarget()
@@ -81,8 +79,8 @@ func (s *Server) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespon
 		var err error
 		targetUpdates[target], err = s.formatUpdateOrReplace(req.GetPrefix(), u, targetUpdates)
 		if err != nil {
-			log.Warn("Error in replace", err)
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			log.Warn("Error in update ", err)
+			return nil, status.Errorf(codes.InvalidArgument, "Error in update of %s %v", utils.StrPath(u.Path), err)
 		}
 	}
 
@@ -98,81 +96,122 @@ func (s *Server) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespon
 		targetRemoves[target] = s.doDelete(req.GetPrefix(), u, targetRemoves)
 	}
 
-	netCfgChangeName, version, deviceType, err := extractExtensions(req)
+	// There is only one set of extensions in Set request, regardless of number of
+	// updates
+	ext, err := extractExtensions(req)
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
+	netCfgChangeName := ext.netCfgChangeName
+	version := ext.version
+	deviceType := ext.deviceType
 
 	if netCfgChangeName == "" {
 		netCfgChangeName = namesgenerator.GetRandomName(0)
 	}
 
-	//Temporary map in order to not to modify the original removes but optimize calculations during validation
-	targetRemovesTmp := make(mapTargetRemoves)
-	for k, v := range targetRemoves {
-		targetRemovesTmp[k] = v
+	// A Set with preconditions or expected indexes is validated and stored while no other Set
+	// through this server is, so that they still hold when the change is stored. Other Sets only
+	// exclude those, and go ahead concurrently. Sets through other instances are not excluded
+	var unlockOnce sync.Once
+	var unlock func()
+	if len(ext.preconditions) > 0 || len(ext.expectedIndex) > 0 {
+		s.setMu.Lock()
+		unlock = func() { unlockOnce.Do(s.setMu.Unlock) }
+	} else {
+		s.setMu.RLock()
+		unlock = func() { unlockOnce.Do(s.setMu.RUnlock) }
 	}
+	defer unlock()
 
 	s.mu.RLock()
 	lastWrite := s.lastWrite
 	s.mu.RUnlock()
 
-	mgr := manager.GetManager()
-	deviceInfo := make(map[devicetype.ID]cache.Info)
-	//Checking for wrong configuration against the device models for updates
-	for target, updates := range targetUpdates {
-		deviceType, version, err = mgr.CheckCacheForDevice(devicetype.ID(target), deviceType, version)
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+	for target := range ext.preconditions {
+		if _, ok := targetUpdates[target]; ok {
+			continue
 		}
-		deviceInfo[devicetype.ID(target)] = cache.Info{
-			DeviceID: devicetype.ID(target),
-			Type:     deviceType,
-			Version:  version,
+		if _, ok := targetRemoves[target]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "precondition given for target %s that is not changed", target)
 		}
+	}
 
-		// TODO: Since the change has not been stored yet, we cannot guarantee the change will be validated against
-		//       the same state as will be pushed to the device. Changes must be validated after they're stored
-		//       to achieve this level of consistency.
-		err := validateChange(target, deviceType, version, updates, targetRemoves[target], lastWrite)
+	mgr := manager.GetManager()
+
+	//Replace - every existing leaf under a replaced path that is not in the payload is removed
+	for target, replaces := range targetReplaces {
+		_, targetVersion, err := mgr.CheckCacheForDevice(devicetype.ID(target), deviceType, version)
 		if err != nil {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
-
-		err = s.checkForReadOnly(target, deviceType, version, updates, targetRemoves[target])
+		configValues, err := mgr.DeviceStateStore.Get(devicetype.NewVersionedID(devicetype.ID(target), targetVersion), lastWrite)
 		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+			log.Errorf("Error while extracting config for replace on %s %s", target, err.Error())
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		removes := computeReplaceRemoves(replaces, targetUpdates[target], configValues)
+		if len(removes) > 0 {
+			targetRemoves[target] = append(targetRemoves[target], removes...)
 		}
+	}
+
+	//Temporary map in order to not to modify the original removes but optimize calculations during validation
+	targetRemovesTmp := make(mapTargetRemoves)
+	for k, v := range targetRemoves {
+		targetRemovesTmp[k] = v
+	}
+
+	deviceInfo := make(map[devicetype.ID]cache.Info)
+	// On a dry run the errors are reported per target rather than failing the Set
+	targetErrors := make(map[string]error)
+	//Checking for wrong configuration against the device models for updates
+	for target, updates := range targetUpdates {
+		info, err := s.checkTargetChange(target, deviceType, version, updates, targetRemoves[target], lastWrite,
+			ext.preconditions[target])
 		delete(targetRemovesTmp, target)
+		if err != nil && ext.dryRun {
+			targetErrors[target] = err
+			continue
+		} else if err != nil {
+			return nil, validationError(err)
+		}
+		deviceType, version = info.Type, info.Version
+		deviceInfo[devicetype.ID(target)] = *info
 	}
 	//Checking for wrong configuration against the device models for deletes
 	for target, removes := range targetRemovesTmp {
-		deviceType, version, err = mgr.CheckCacheForDevice(devicetype.ID(target), deviceType, version)
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
-		}
-		deviceInfo[devicetype.ID(target)] = cache.Info{
-			DeviceID: devicetype.ID(target),
-			Type:     deviceType,
-			Version:  version,
+		info, err := s.checkTargetChange(target, deviceType, version, make(devicechange.TypedValueMap), removes, lastWrite,
+			ext.preconditions[target])
+		if err != nil && ext.dryRun {
+			targetErrors[target] = err
+			continue
+		} else if err != nil {
+			return nil, validationError(err)
 		}
+		deviceType, version = info.Type, info.Version
+		deviceInfo[devicetype.ID(target)] = *info
+	}
 
-		// TODO: Since the change has not been stored yet, we cannot guarantee the change will be validated against
-		//       the same state as will be pushed to the device. Changes must be validated after they're stored
-		//       to achieve this level of consistency.
-		err := validateChange(target, deviceType, version, make(devicechange.TypedValueMap), removes, lastWrite)
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+	if ext.dryRun {
+		for deviceID := range deviceInfo {
+			if err := checkExpectedIndex(ext, deviceID); err != nil {
+				targetErrors[string(deviceID)] = err
+				delete(deviceInfo, deviceID)
+			}
 		}
+		return dryRunSet(targetUpdates, targetRemoves, deviceInfo, targetErrors, lastWrite)
+	}
 
-		err = s.checkForReadOnly(target, deviceType, version, make(devicechange.TypedValueMap), removes)
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+	for deviceID := range deviceInfo {
+		if err := checkExpectedIndex(ext, deviceID); err != nil {
+			log.Warnf("Set rejected %s", err.Error())
+			return nil, statusError(err)
 		}
 	}
-
 	// Creating and setting the config on the atomix Store
-	change, errSet := mgr.SetNetworkConfig(targetUpdates, targetRemoves, deviceInfo, netCfgChangeName)
+	change, errSet := mgr.SetNetworkConfigWithOfflinePolicy(targetUpdates, targetRemoves, deviceInfo, netCfgChangeName,
+		ext.offlinePolicy, ext.offlineTimeout)
 
 	if errSet != nil {
 		log.Errorf("Error while setting config in atomix %s", errSet.Error())
@@ -185,10 +224,55 @@ func (s *Server) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespon
 		s.lastWrite = change.Revision
 	}
 	s.mu.Unlock()
+	unlock()
+
+	// Wait for the change to be applied to the devices if a synchronous Set was requested
+	if ext.synchronous {
+		timeout := ext.syncTimeout
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+			timeout = time.Until(deadline)
+		}
+		if err := mgr.WaitForNetworkConfig(ctx, change.ID, timeout); err != nil {
+			log.Warnf("Synchronous set of change %s did not complete %s", change.ID, err.Error())
+			return nil, err
+		}
+	}
 
 	// Build the responses
+	updateResults := buildUpdateResults(change.Changes)
+
+	extensions := []*gnmi_ext.Extension{
+		{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionNetwkChangeID,
+					Msg: []byte(netCfgChangeName),
+				},
+			},
+		},
+	}
+
+	setResponse := &gnmi.SetResponse{
+		Response:  updateResults,
+		Timestamp: time.Now().Unix(),
+		Extension: extensions,
+	}
+
+	return setResponse, nil
+}
+
+// validationError returns a failed precondition as it is, or else the error as InvalidArgument
+func validationError(err error) error {
+	if status.Code(err) == codes.FailedPrecondition {
+		return err
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// buildUpdateResults builds an UpdateResult for each of the values in the device changes
+func buildUpdateResults(changes []*devicechange.Change) []*gnmi.UpdateResult {
 	updateResults := make([]*gnmi.UpdateResult, 0)
-	for _, deviceChange := range change.Changes {
+	for _, deviceChange := range changes {
 		deviceID := deviceChange.DeviceID
 		for _, valueUpdate := range deviceChange.Values {
 			var updateResult *gnmi.UpdateResult
@@ -208,45 +292,295 @@ func (s *Server) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespon
 		}
 	}
 
-	extensions := []*gnmi_ext.Extension{
-		{
-			Ext: &gnmi_ext.Extension_RegisteredExt{
-				RegisteredExt: &gnmi_ext.RegisteredExtension{
-					Id:  GnmiExtensionNetwkChangeID,
-					Msg: []byte(netCfgChangeName),
+	return updateResults
+}
+
+// checkTargetChange resolves the device type and version of the target and validates its updates and
+// removes against the device model and its preconditions against the stored configuration
+func (s *Server) checkTargetChange(target string, deviceType devicetype.Type, version devicetype.Version,
+	updates devicechange.TypedValueMap, removes []string, lastWrite networkchange.Revision,
+	preconditions []*manager.Precondition) (*cache.Info, error) {
+	deviceType, version, err := manager.GetManager().CheckCacheForDevice(devicetype.ID(target), deviceType, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO: Since the change has not been stored yet, we cannot guarantee the change will be validated against
+	//       the same state as will be pushed to the device. Changes must be validated after they're stored
+	//       to achieve this level of consistency.
+	err = validateChange(target, deviceType, version, updates, removes, lastWrite, preconditions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkForReadOnly(target, deviceType, version, updates, removes)
+	if err != nil {
+		return nil, err
+	}
+	return &cache.Info{
+		DeviceID: devicetype.ID(target),
+		Type:     deviceType,
+		Version:  version,
+	}, nil
+}
+
+// DryRunResult is the outcome of a dry run Set for one target. The results for all targets are
+// returned as a JSON array in extension 105 of the SetResponse
+type DryRunResult struct {
+	Target  string         `json:"target"`
+	Type    string         `json:"type,omitempty"`
+	Version string         `json:"version,omitempty"`
+	Changes []*DryRunValue `json:"changes,omitempty"`
+	Diff    []*DryRunDiff  `json:"diff,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// DryRunValue is a value of the device change that would have been created
+type DryRunValue struct {
+	Path    string `json:"path"`
+	Value   string `json:"value,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Removed bool   `json:"removed,omitempty"`
+}
+
+// DryRunDiff is the difference the change would have made to the value of a path in the stored
+// configuration. OldValue is not given for added paths and NewValue is not given for removed paths
+type DryRunDiff struct {
+	Path     string `json:"path"`
+	OldValue string `json:"old_value,omitempty"`
+	NewValue string `json:"new_value,omitempty"`
+}
+
+// dryRunSet computes the device changes a Set would have created, without storing anything
+func dryRunSet(targetUpdates mapTargetUpdates, targetRemoves mapTargetRemoves, deviceInfo map[devicetype.ID]cache.Info,
+	targetErrors map[string]error, lastWrite networkchange.Revision) (*gnmi.SetResponse, error) {
+	mgr := manager.GetManager()
+	results := make([]*DryRunResult, 0)
+	changes := make([]*devicechange.Change, 0)
+	for target, err := range targetErrors {
+		results = append(results, &DryRunResult{
+			Target: target,
+			Error:  err.Error(),
+		})
+	}
+	for deviceID, info := range deviceInfo {
+		result := &DryRunResult{
+			Target:  string(deviceID),
+			Type:    string(info.Type),
+			Version: string(info.Version),
+		}
+		results = append(results, result)
+		change, err := mgr.ComputeDeviceChange(deviceID, info.Version, info.Type,
+			targetUpdates[string(deviceID)], targetRemoves[string(deviceID)], "Generated for dry run")
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		changes = append(changes, change)
+		for _, value := range change.Values {
+			result.Changes = append(result.Changes, &DryRunValue{
+				Path:    value.Path,
+				Value:   value.GetValue().ValueToString(),
+				Type:    value.GetValue().GetType().String(),
+				Removed: value.Removed,
+			})
+		}
+		diffs, err := mgr.ComputeConfigDiff(change, lastWrite)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		for _, diff := range diffs {
+			dryRunDiff := &DryRunDiff{Path: diff.Path}
+			if diff.OldValue != nil {
+				dryRunDiff.OldValue = diff.OldValue.ValueToString()
+			}
+			if diff.NewValue != nil {
+				dryRunDiff.NewValue = diff.NewValue.ValueToString()
+			}
+			result.Diff = append(result.Diff, dryRunDiff)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Target < results[j].Target
+	})
+
+	resultsJSON, err := json.Marshal(results)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &gnmi.SetResponse{
+		Response:  buildUpdateResults(changes),
+		Timestamp: time.Now().Unix(),
+		Extension: []*gnmi_ext.Extension{
+			{
+				Ext: &gnmi_ext.Extension_RegisteredExt{
+					RegisteredExt: &gnmi_ext.RegisteredExtension{
+						Id:  GnmiExtensionDryRun,
+						Msg: resultsJSON,
+					},
 				},
 			},
 		},
+	}, nil
+}
+
+// setExtensions holds the values given in the extensions of a SetRequest
+type setExtensions struct {
+	netCfgChangeName string             // May be specified as 100 in extension
+	version          devicetype.Version // May be specified as 101 in extension
+	deviceType       devicetype.Type    // May be specified as 102 in extension
+	synchronous      bool               // May be specified as 104 in extension
+	syncTimeout      time.Duration      // May be given as the value of 104 in extension
+	dryRun           bool               // May be specified as 105 in extension
+	// The network change index expected for each target, as may be specified as 108 in extension.
+	// The index under the empty target applies to every target not given separately
+	expectedIndex map[string]networkchange.Index
+	// The preconditions of each target, as may be specified as 109 in extension
+	preconditions map[string][]*manager.Precondition
+	offlinePolicy  networkchange.OfflinePolicy // May be specified as 110 in extension
+	offlineTimeout time.Duration               // May be given in the value of 110 in extension
+}
+
+func extractExtensions(req *gnmi.SetRequest) (*setExtensions, error) {
+	ext := &setExtensions{}
+	for _, extension := range req.GetExtension() {
+		switch regExt := extension.GetRegisteredExt(); regExt.GetId() {
+		case GnmiExtensionNetwkChangeID:
+			ext.netCfgChangeName = string(regExt.GetMsg())
+		case GnmiExtensionVersion:
+			ext.version = devicetype.Version(regExt.GetMsg())
+		case GnmiExtensionDeviceType:
+			ext.deviceType = devicetype.Type(regExt.GetMsg())
+		case GnmiExtensionSynchronous:
+			ext.synchronous = true
+			ext.syncTimeout = defaultSyncTimeout
+			if len(regExt.GetMsg()) > 0 {
+				timeout, err := time.ParseDuration(string(regExt.GetMsg()))
+				if err != nil || timeout <= 0 {
+					return nil, status.Error(codes.InvalidArgument, fmt.Errorf("invalid timeout '%s' in extension %d",
+						regExt.GetMsg(), GnmiExtensionSynchronous).Error())
+				}
+				ext.syncTimeout = timeout
+			}
+		case GnmiExtensionDryRun:
+			ext.dryRun = true
+		case GnmiExtensionExpectedIndex:
+			expectedIndex, err := parseExpectedIndex(string(regExt.GetMsg()))
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			ext.expectedIndex = expectedIndex
+		case GnmiExtensionPreconditions:
+			preconditions, err := parsePreconditions(regExt.GetMsg())
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			ext.preconditions = preconditions
+		case GnmiExtensionOfflinePolicy:
+			policy, timeout, err := parseOfflinePolicy(string(regExt.GetMsg()))
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, err.Error())
+			}
+			ext.offlinePolicy, ext.offlineTimeout = policy, timeout
+		default:
+			return nil, status.Error(codes.InvalidArgument, fmt.Errorf("unexpected extension %d = '%s' in Set()",
+				regExt.GetId(), regExt.GetMsg()).Error())
+		}
 	}
+	log.Infof("Set called with extensions; 100: %s, 101: %s, 102: %s, 104: %t %s, 105: %t, 108: %v, 109: %d targets, 110: %s %s",
+		ext.netCfgChangeName, ext.version, ext.deviceType, ext.synchronous, ext.syncTimeout, ext.dryRun, ext.expectedIndex,
+		len(ext.preconditions), ext.offlinePolicy, ext.offlineTimeout)
+	return ext, nil
+}
 
-	setResponse := &gnmi.SetResponse{
-		Response:  updateResults,
-		Timestamp: time.Now().Unix(),
-		Extension: extensions,
+// parseOfflinePolicy parses the message of extension 110 - "wait", "wait-timeout=<duration>" or
+// "apply-reachable"
+func parseOfflinePolicy(msg string) (networkchange.OfflinePolicy, time.Duration, error) {
+	switch {
+	case msg == "wait":
+		return networkchange.OfflinePolicy_WAIT, 0, nil
+	case msg == "apply-reachable":
+		return networkchange.OfflinePolicy_APPLY_REACHABLE, 0, nil
+	case strings.HasPrefix(msg, "wait-timeout="):
+		timeout, err := time.ParseDuration(strings.TrimPrefix(msg, "wait-timeout="))
+		if err != nil || timeout <= 0 {
+			return 0, 0, fmt.Errorf("invalid timeout in '%s' in extension %d", msg, GnmiExtensionOfflinePolicy)
+		}
+		return networkchange.OfflinePolicy_WAIT_TIMEOUT, timeout, nil
+	default:
+		return 0, 0, fmt.Errorf("invalid offline policy '%s' in extension %d - expecting wait, wait-timeout=<duration> or apply-reachable",
+			msg, GnmiExtensionOfflinePolicy)
 	}
+}
 
-	return setResponse, nil
+// parseExpectedIndex parses the message of extension 108 - a comma separated list of either
+// a bare index, applying to every target, or target=index for a single target
+func parseExpectedIndex(msg string) (map[string]networkchange.Index, error) {
+	expectedIndex := make(map[string]networkchange.Index)
+	for _, entry := range strings.Split(msg, ",") {
+		var target string
+		indexStr := strings.TrimSpace(entry)
+		if eq := strings.LastIndex(indexStr, "="); eq >= 0 {
+			target = strings.TrimSpace(indexStr[:eq])
+			indexStr = strings.TrimSpace(indexStr[eq+1:])
+			if target == "" {
+				return nil, fmt.Errorf("no target given in '%s' in extension %d", entry, GnmiExtensionExpectedIndex)
+			}
+		}
+		index, err := strconv.ParseUint(indexStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index '%s' in extension %d", entry, GnmiExtensionExpectedIndex)
+		}
+		if _, ok := expectedIndex[target]; ok {
+			return nil, fmt.Errorf("index of target '%s' given more than once in extension %d", target, GnmiExtensionExpectedIndex)
+		}
+		expectedIndex[target] = networkchange.Index(index)
+	}
+	return expectedIndex, nil
 }
 
-func extractExtensions(req *gnmi.SetRequest) (string, devicetype.Version, devicetype.Type, error) {
-	var netcfgchangename string
-	var version string
-	var deviceType string
-	for _, ext := range req.GetExtension() {
-		if ext.GetRegisteredExt().GetId() == GnmiExtensionNetwkChangeID {
-			netcfgchangename = string(ext.GetRegisteredExt().GetMsg())
-		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionVersion {
-			version = string(ext.GetRegisteredExt().GetMsg())
-		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionDeviceType {
-			deviceType = string(ext.GetRegisteredExt().GetMsg())
-		} else {
-			return "", "", "", status.Error(codes.InvalidArgument, fmt.Errorf("unexpected extension %d = '%s' in Set()",
-				ext.GetRegisteredExt().GetId(), ext.GetRegisteredExt().GetMsg()).Error())
-		}
-	}
-	log.Infof("Set called with extensions; 100: %s, 101: %s, 102: %s",
-		netcfgchangename, version, deviceType)
-	return netcfgchangename, devicetype.Version(version), devicetype.Type(deviceType), nil
+// SetPrecondition is a precondition on the stored value of a path, as given in a JSON array in
+// extension 109 of the SetRequest. If Absent is true the path must not be set, or else it must
+// have the Value
+type SetPrecondition struct {
+	Target string `json:"target"`
+	Path   string `json:"path"`
+	Value  string `json:"value,omitempty"`
+	Absent bool   `json:"absent,omitempty"`
+}
+
+// parsePreconditions parses the message of extension 109 in to the preconditions of each target
+func parsePreconditions(msg []byte) (map[string][]*manager.Precondition, error) {
+	setPreconditions := make([]*SetPrecondition, 0)
+	if err := json.Unmarshal(msg, &setPreconditions); err != nil {
+		return nil, fmt.Errorf("invalid preconditions in extension %d %v", GnmiExtensionPreconditions, err)
+	}
+	preconditions := make(map[string][]*manager.Precondition)
+	for _, p := range setPreconditions {
+		if p.Target == "" || p.Path == "" {
+			return nil, fmt.Errorf("no target or path given in precondition %v in extension %d", *p, GnmiExtensionPreconditions)
+		}
+		preconditions[p.Target] = append(preconditions[p.Target], &manager.Precondition{
+			Path:   p.Path,
+			Value:  p.Value,
+			Absent: p.Absent,
+		})
+	}
+	return preconditions, nil
+}
+
+// checkExpectedIndex fails with Aborted if a network change newer than the index expected for
+// the target has changed it
+func checkExpectedIndex(ext *setExtensions, target devicetype.ID) error {
+	index, ok := ext.expectedIndex[string(target)]
+	if !ok {
+		index, ok = ext.expectedIndex[""]
+	}
+	if !ok {
+		return nil
+	}
+	return manager.GetManager().CheckNoChangeSince(target, index)
 }
 
 // This deals with either a path and a value (simple case) or a path with
@@ -335,6 +669,35 @@ func (s *Server) doDelete(prefix *gnmi.Path, u *gnmi.Path, targetRemoves mapTarg
 
 }
 
+// prefixedPath returns the string form of the path with the prefix, if any, prepended
+func prefixedPath(prefix *gnmi.Path, u *gnmi.Path) string {
+	prefixPath := utils.StrPath(prefix)
+	path := utils.StrPath(u)
+	if prefixPath != "/" {
+		path = fmt.Sprintf("%s%s", prefixPath, path)
+	}
+	return path
+}
+
+// computeReplaceRemoves gives the replaced paths the gNMI Replace semantics - every existing leaf
+// under a replaced path that is not given a value in the updates has to be removed
+func computeReplaceRemoves(replacedPaths []string, updates devicechange.TypedValueMap,
+	configValues []*devicechange.PathValue) []string {
+	removes := make([]string, 0)
+	for _, cv := range configValues {
+		if _, ok := updates[cv.Path]; ok {
+			continue
+		}
+		for _, replacedPath := range replacedPaths {
+			if utils.IsPathUnder(cv.Path, replacedPath) {
+				removes = append(removes, cv.Path)
+				break
+			}
+		}
+	}
+	return removes
+}
+
 // iterate through the updates and check that none of them include a `set` of a
 // readonly attribute - this is done by checking with the relevant model
 func (s *Server) checkForReadOnly(target string, deviceType devicetype.Type, version devicetype.Version,
@@ -380,6 +743,18 @@ func (s *Server) checkForReadOnly(target string, deviceType devicetype.Type, ver
 
 func compareRoPaths(path string, modelRoPaths modelregistry.ReadOnlyPathMap, modelRwPaths modelregistry.ReadWritePathMap) error {
 	log.Infof("Testing %s for read only", path)
+	if ropath, s, fullpath, ok := matchRoPath(path, modelRoPaths, modelRwPaths); ok {
+		return fmt.Errorf("contains a change to a "+
+			"read only path %s. Rejected. %s, %s, %s, %s, %s",
+			path, modelregistry.RemovePathIndices(path), ropath, modelregistry.RemovePathIndices(ropath), s, fullpath)
+	}
+	return nil
+}
+
+// matchRoPath finds the read only path and subpath of the model that the path is an instance of.
+// Paths that are in both config and state (e.g. index of a list) are not matched
+func matchRoPath(path string, modelRoPaths modelregistry.ReadOnlyPathMap,
+	modelRwPaths modelregistry.ReadWritePathMap) (string, string, string, bool) {
 	for ropath, subpaths := range modelRoPaths {
 		// Search through for list indices and replace with generic
 		modelPathNiIdx := modelregistry.RemovePathIndices(path)
@@ -395,17 +770,15 @@ func compareRoPaths(path string, modelRoPaths modelregistry.ReadOnlyPathMap, mod
 					for rwpath := range modelRwPaths {
 						rwpathNoIdx := modelregistry.RemovePathIndices(rwpath)
 						if rwpathNoIdx == modelPathNiIdx {
-							return nil
+							return "", "", "", false
 						}
 					}
-					return fmt.Errorf("contains a change to a "+
-						"read only path %s. Rejected. %s, %s, %s, %s, %s",
-						path, modelPathNiIdx, ropath, ropathNoIdx, s, fullpath)
+					return ropath, s, fullpath, true
 				}
 			}
 		}
 	}
-	return nil
+	return "", "", "", false
 }
 
 func buildUpdateResult(pathStr string, target string, op gnmi.UpdateResult_Operation) (*gnmi.UpdateResult, error) {
@@ -424,13 +797,14 @@ func buildUpdateResult(pathStr string, target string, op gnmi.UpdateResult_Opera
 }
 
 func validateChange(target string, deviceType devicetype.Type, version devicetype.Version,
-	targetUpdates devicechange.TypedValueMap, targetRemoves []string, lastWrite networkchange.Revision) error {
+	targetUpdates devicechange.TypedValueMap, targetRemoves []string, lastWrite networkchange.Revision,
+	preconditions []*manager.Precondition) error {
 	if len(targetUpdates) == 0 && len(targetRemoves) == 0 {
 		return fmt.Errorf("no updates found in change on %s - invalid", target)
 	}
 	log.Infof("Validating change %s:%s:%s", target, deviceType, version)
 	errValidation := manager.GetManager().ValidateNetworkConfig(devicetype.ID(target), version, deviceType,
-		targetUpdates, targetRemoves, lastWrite)
+		targetUpdates, targetRemoves, lastWrite, preconditions)
 	if errValidation != nil {
 		log.Errorf("Error in validating config, updates %s, removes %s for target %s, err: %s", targetUpdates,
 			targetRemoves, target, errValidation)
@@ -16,11 +16,14 @@ package gnmi
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/golang/mock/gomock"
 	td1 "github.com/onosproject/config-models/modelplugin/testdevice-1.0.0/testdevice_1_0_0"
 	td2 "github.com/onosproject/config-models/modelplugin/testdevice-2.0.0/testdevice_2_0_0"
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
+	networkchange "github.com/onosproject/onos-config/api/types/change/network"
 	devicetype "github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-config/pkg/manager"
 	"github.com/onosproject/onos-config/pkg/modelregistry"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	"github.com/onosproject/onos-config/pkg/utils"
@@ -33,6 +36,7 @@ import (
 	"gotest.tools/assert"
 	"strconv"
 	"testing"
+	"time"
 )
 
 const (
@@ -617,3 +621,379 @@ func TestSet_BadDeviceType(t *testing.T) {
 	assert.ErrorContains(t, setError, "type given NotTheSameType does not match expected TestDevice")
 	assert.Assert(t, setResponse == nil)
 }
+
+// TestSet_BadUpdateAndReplace tests that the errors of a Set say whether an update or a replace was wrong
+func TestSet_BadUpdateAndReplace(t *testing.T) {
+	server, mocks := setUpForGetSetTests(t)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(devicetype.ID("NoSuchDevice")).Return(make([]*cache.Info, 0)).AnyTimes()
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a"})
+	typedValue := gnmi.TypedValue_JsonVal{JsonVal: []byte(`{"leaf2a": 5}`)}
+	value := gnmi.TypedValue{Value: &typedValue}
+	noTarget := []*gnmi.Update{{Path: &gnmi.Path{Elem: pathElemsRefs.Elem}, Val: &value}}
+	noDevice := []*gnmi.Update{{Path: &gnmi.Path{Elem: pathElemsRefs.Elem, Target: "NoSuchDevice"}, Val: &value}}
+
+	_, setError := server.Set(context.Background(), &gnmi.SetRequest{Replace: noTarget})
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+	assert.ErrorContains(t, setError, "No target given in replace")
+
+	_, setError = server.Set(context.Background(), &gnmi.SetRequest{Update: noTarget})
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+	assert.ErrorContains(t, setError, "No target given in update")
+
+	_, setError = server.Set(context.Background(), &gnmi.SetRequest{Replace: noDevice})
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+	assert.ErrorContains(t, setError, "Error in replace of /cont1a/cont2a")
+	assert.ErrorContains(t, setError, "device NoSuchDevice is not in DeviceCache")
+
+	_, setError = server.Set(context.Background(), &gnmi.SetRequest{Update: noDevice})
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+	assert.ErrorContains(t, setError, "Error in update of /cont1a/cont2a")
+	assert.ErrorContains(t, setError, "device NoSuchDevice is not in DeviceCache")
+}
+
+// Test_doReplaceSet shows how a Replace removes the existing leaves that are not in the payload
+func Test_doReplaceSet(t *testing.T) {
+	server, mocks := setUpForGetSetTests(t)
+	deletePaths, replacedPaths, updatedPaths := setUpPathsForGetSetTests()
+
+	td1Schema, _ := td1.UnzipSchema()
+	_, readWritePathsTd1 := modelregistry.ExtractPaths(td1Schema["Device"], yang.TSUnset, "", "")
+	manager.GetManager().ModelRegistry.ModelReadWritePaths["TestDevice-1.0.0"] = readWritePathsTd1
+
+	mocks.MockStores.DeviceStateStore.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]*devicechange.PathValue{
+		{Path: cont1aCont2aLeaf2a, Value: devicechange.NewTypedValueUint64(13)},
+		{Path: cont1aCont2aLeaf2b, Value: devicechange.NewTypedValueDecimal64(1234, 2)},
+		{Path: "/cont1a/leaf1a", Value: devicechange.NewTypedValueString("leaf1a")},
+	}, nil)
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a"})
+	typedValue := gnmi.TypedValue_JsonVal{JsonVal: []byte(`{"leaf2a": 5}`)}
+	value := gnmi.TypedValue{Value: &typedValue}
+	replacePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+	replacedPaths = append(replacedPaths, &gnmi.Update{Path: &replacePath, Val: &value})
+
+	var setRequest = gnmi.SetRequest{
+		Delete:  deletePaths,
+		Replace: replacedPaths,
+		Update:  updatedPaths,
+	}
+
+	setResponse, setError := server.Set(context.Background(), &setRequest)
+
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Assert(t, setResponse != nil, "Expected setResponse to have a value")
+	assert.Equal(t, len(setResponse.Response), 2)
+
+	assert.Equal(t, setResponse.Response[0].Op.String(), gnmi.UpdateResult_UPDATE.String())
+	assert.Equal(t, utils.StrPath(setResponse.Response[0].Path), cont1aCont2aLeaf2a)
+
+	// leaf2b is not in the payload so it is removed, leaf1a is outside of the replaced path
+	assert.Equal(t, setResponse.Response[1].Op.String(), gnmi.UpdateResult_DELETE.String())
+	assert.Equal(t, utils.StrPath(setResponse.Response[1].Path), cont1aCont2aLeaf2b)
+}
+
+func Test_computeReplaceRemoves(t *testing.T) {
+	configValues := []*devicechange.PathValue{
+		{Path: "/cont1a/leaf1a", Value: devicechange.NewTypedValueString("leaf1a")},
+		{Path: "/cont1a/list2a[name=first]/name", Value: devicechange.NewTypedValueString("first")},
+		{Path: "/cont1a/list2a[name=first]/tx-power", Value: devicechange.NewTypedValueUint64(5)},
+		{Path: "/cont1a/list2a[name=second]/name", Value: devicechange.NewTypedValueString("second")},
+		{Path: "/cont1a/list2a[name=second]/tx-power", Value: devicechange.NewTypedValueUint64(6)},
+		{Path: "/cont1a/list2abc", Value: devicechange.NewTypedValueString("not under list2a")},
+	}
+
+	updates := devicechange.TypedValueMap{
+		"/cont1a/list2a[name=first]/name": devicechange.NewTypedValueString("first"),
+	}
+	removes := computeReplaceRemoves([]string{"/cont1a/list2a"}, updates, configValues)
+	assert.DeepEqual(t, removes, []string{
+		"/cont1a/list2a[name=first]/tx-power",
+		"/cont1a/list2a[name=second]/name",
+		"/cont1a/list2a[name=second]/tx-power",
+	})
+
+	removes = computeReplaceRemoves([]string{"/cont1a/list2a[name=second]"}, updates, configValues)
+	assert.DeepEqual(t, removes, []string{
+		"/cont1a/list2a[name=second]/name",
+		"/cont1a/list2a[name=second]/tx-power",
+	})
+
+	removes = computeReplaceRemoves([]string{"/"}, updates, configValues)
+	assert.Equal(t, len(removes), 5)
+}
+
+// Test_doSynchronousSet shows how extension 104 makes the Set wait for the change to complete
+func Test_doSynchronousSet(t *testing.T) {
+	server, _ := setUpForGetSetTests(t)
+	deletePaths, replacedPaths, updatedPaths := setUpPathsForGetSetTests()
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	typedValue := gnmi.TypedValue_UintVal{UintVal: 16}
+	value := gnmi.TypedValue{Value: &typedValue}
+	updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+	updatedPaths = append(updatedPaths, &gnmi.Update{Path: &updatePath, Val: &value})
+
+	extSync := gnmi_ext.Extension_RegisteredExt{
+		RegisteredExt: &gnmi_ext.RegisteredExtension{
+			Id:  GnmiExtensionSynchronous,
+			Msg: []byte("5s"),
+		},
+	}
+
+	var setRequest = gnmi.SetRequest{
+		Delete:  deletePaths,
+		Replace: replacedPaths,
+		Update:  updatedPaths,
+		Extension: []*gnmi_ext.Extension{{
+			Ext: &extSync,
+		}},
+	}
+
+	// The mock network changes store completes the change straight away
+	setResponse, setError := server.Set(context.Background(), &setRequest)
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Equal(t, len(setResponse.Response), 1)
+
+	extSync.RegisteredExt.Msg = []byte("not a duration")
+	_, setError = server.Set(context.Background(), &setRequest)
+	assert.ErrorContains(t, setError, "invalid timeout 'not a duration' in extension 104")
+}
+
+// Test_doDryRunSet shows that extension 105 validates the change and reports it without storing it
+func Test_doDryRunSet(t *testing.T) {
+	server, mocks := setUpForGetSetTests(t)
+	deletePaths, replacedPaths, updatedPaths := setUpPathsForGetSetTests()
+
+	mocks.MockStores.DeviceStateStore.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]*devicechange.PathValue{
+		{Path: cont1aCont2aLeaf2a, Value: devicechange.NewTypedValueUint64(13)},
+	}, nil).AnyTimes()
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	typedValue := gnmi.TypedValue_UintVal{UintVal: 16}
+	value := gnmi.TypedValue{Value: &typedValue}
+	updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+	updatedPaths = append(updatedPaths, &gnmi.Update{Path: &updatePath, Val: &value})
+
+	var setRequest = gnmi.SetRequest{
+		Delete:  deletePaths,
+		Replace: replacedPaths,
+		Update:  updatedPaths,
+		Extension: []*gnmi_ext.Extension{{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionNetwkChangeID,
+					Msg: []byte("DryRunChange"),
+				},
+			},
+		}, {
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id: GnmiExtensionDryRun,
+				},
+			},
+		}},
+	}
+
+	setResponse, setError := server.Set(context.Background(), &setRequest)
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Equal(t, len(setResponse.Response), 1)
+	assert.Equal(t, setResponse.Response[0].Op.String(), gnmi.UpdateResult_UPDATE.String())
+	assert.Equal(t, len(setResponse.Extension), 1)
+	dryRunExt := setResponse.Extension[0].GetRegisteredExt()
+	assert.Equal(t, dryRunExt.Id, gnmi_ext.ExtensionID(GnmiExtensionDryRun))
+
+	results := make([]*DryRunResult, 0)
+	assert.NilError(t, json.Unmarshal(dryRunExt.Msg, &results))
+	assert.Equal(t, len(results), 1)
+	assert.Equal(t, results[0].Target, "Device1")
+	assert.Equal(t, results[0].Error, "")
+	assert.DeepEqual(t, results[0].Changes, []*DryRunValue{{Path: cont1aCont2aLeaf2a, Value: "16", Type: "UINT"}})
+	assert.DeepEqual(t, results[0].Diff, []*DryRunDiff{{Path: cont1aCont2aLeaf2a, OldValue: "13", NewValue: "16"}})
+
+	// Nothing is stored
+	change, err := mocks.MockStores.NetworkChangesStore.Get("DryRunChange")
+	assert.NilError(t, err)
+	assert.Assert(t, change == nil, "Unexpected network change stored on dry run")
+
+	// Validation errors are reported per target rather than failing the request
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(devicetype.ID("NoSuchDevice")).Return(nil)
+	mocks.MockStores.DeviceStore.EXPECT().Get(topodevice.ID("NoSuchDevice")).Return(nil, status.Error(codes.NotFound, "device not found"))
+	updatePath.Target = "NoSuchDevice"
+	setResponse, setError = server.Set(context.Background(), &setRequest)
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Equal(t, len(setResponse.Response), 0)
+	results = make([]*DryRunResult, 0)
+	assert.NilError(t, json.Unmarshal(setResponse.Extension[0].GetRegisteredExt().Msg, &results))
+	assert.Equal(t, len(results), 1)
+	assert.Equal(t, results[0].Target, "NoSuchDevice")
+	assert.Assert(t, results[0].Error != "")
+}
+
+// Test_doSetExpectedIndex shows that extension 108 rejects a Set if the target has been changed
+// after the expected network change index
+func Test_doSetExpectedIndex(t *testing.T) {
+	server, mocks := setUpForGetSetTests(t)
+
+	otherDeviceChange := &networkchange.NetworkChange{
+		ID:      "OtherDeviceChange",
+		Index:   1,
+		Changes: []*devicechange.Change{{DeviceID: "Device2", DeviceVersion: "2.0.0"}},
+	}
+	device1Change := &networkchange.NetworkChange{
+		ID:      "Device1Change",
+		Index:   2,
+		Changes: []*devicechange.Change{{DeviceID: device1, DeviceVersion: deviceVersion1}},
+	}
+	mocks.MockStores.NetworkChangesStore.EXPECT().GetNext(networkchange.Index(0)).Return(otherDeviceChange, nil).AnyTimes()
+	mocks.MockStores.NetworkChangesStore.EXPECT().GetNext(networkchange.Index(1)).Return(device1Change, nil).AnyTimes()
+	mocks.MockStores.NetworkChangesStore.EXPECT().GetNext(networkchange.Index(2)).Return(nil, nil).AnyTimes()
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	typedValue := gnmi.TypedValue_UintVal{UintVal: 16}
+	value := gnmi.TypedValue{Value: &typedValue}
+	updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+
+	newSetRequest := func(expectedIndex string) *gnmi.SetRequest {
+		return &gnmi.SetRequest{
+			Update: []*gnmi.Update{{Path: &updatePath, Val: &value}},
+			Extension: []*gnmi_ext.Extension{{
+				Ext: &gnmi_ext.Extension_RegisteredExt{
+					RegisteredExt: &gnmi_ext.RegisteredExtension{
+						Id:  GnmiExtensionExpectedIndex,
+						Msg: []byte(expectedIndex),
+					},
+				},
+			}},
+		}
+	}
+
+	// Device1 has been changed since index 0 and 1
+	_, setError := server.Set(context.Background(), newSetRequest("0"))
+	assert.Equal(t, status.Code(setError), codes.Aborted, "Expected Set to be aborted %v", setError)
+	_, setError = server.Set(context.Background(), newSetRequest("5, Device1=1"))
+	assert.Equal(t, status.Code(setError), codes.Aborted, "Expected Set to be aborted %v", setError)
+
+	// The index given for the target overrides the one for all targets
+	setResponse, setError := server.Set(context.Background(), newSetRequest("0,Device1=2"))
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Equal(t, len(setResponse.Response), 1)
+
+	// The index only applies to the target it is given for
+	_, setError = server.Set(context.Background(), newSetRequest("Device2=0"))
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+
+	_, setError = server.Set(context.Background(), newSetRequest("Device1=abc"))
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+	_, setError = server.Set(context.Background(), newSetRequest("=1"))
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+	_, setError = server.Set(context.Background(), newSetRequest("Device1=1,Device1=2"))
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+}
+
+// Test_doSetPreconditions shows that extension 109 only makes the change if the paths have the given values
+func Test_doSetPreconditions(t *testing.T) {
+	server, mocks := setUpForGetSetTests(t)
+
+	mocks.MockStores.DeviceStateStore.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]*devicechange.PathValue{
+		{Path: cont1aCont2aLeaf2a, Value: devicechange.NewTypedValueUint64(13)},
+	}, nil).AnyTimes()
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2b"})
+	typedValue := gnmi.TypedValue_DecimalVal{DecimalVal: &gnmi.Decimal64{Digits: 1234, Precision: 3}}
+	value := gnmi.TypedValue{Value: &typedValue}
+	updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+
+	newSetRequest := func(preconditions ...*SetPrecondition) *gnmi.SetRequest {
+		preconditionsJSON, err := json.Marshal(preconditions)
+		assert.NilError(t, err)
+		return &gnmi.SetRequest{
+			Update: []*gnmi.Update{{Path: &updatePath, Val: &value}},
+			Extension: []*gnmi_ext.Extension{{
+				Ext: &gnmi_ext.Extension_RegisteredExt{
+					RegisteredExt: &gnmi_ext.RegisteredExtension{
+						Id:  GnmiExtensionPreconditions,
+						Msg: preconditionsJSON,
+					},
+				},
+			}},
+		}
+	}
+
+	_, setError := server.Set(context.Background(), newSetRequest(
+		&SetPrecondition{Target: device1, Path: cont1aCont2aLeaf2a, Value: "12"}))
+	assert.Equal(t, status.Code(setError), codes.FailedPrecondition, "Expected precondition to fail %v", setError)
+
+	_, setError = server.Set(context.Background(), newSetRequest(
+		&SetPrecondition{Target: device1, Path: cont1aCont2aLeaf2a, Absent: true}))
+	assert.Equal(t, status.Code(setError), codes.FailedPrecondition, "Expected precondition to fail %v", setError)
+
+	_, setError = server.Set(context.Background(), newSetRequest(
+		&SetPrecondition{Target: device1, Path: cont1aCont2aLeaf2b, Value: "1.234"}))
+	assert.Equal(t, status.Code(setError), codes.FailedPrecondition, "Expected precondition to fail %v", setError)
+
+	setResponse, setError := server.Set(context.Background(), newSetRequest(
+		&SetPrecondition{Target: device1, Path: cont1aCont2aLeaf2a, Value: "13"},
+		&SetPrecondition{Target: device1, Path: cont1aCont2aLeaf2b, Absent: true}))
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Equal(t, len(setResponse.Response), 1)
+
+	// A precondition can only be given for a target that is changed
+	_, setError = server.Set(context.Background(), newSetRequest(
+		&SetPrecondition{Target: "Device2", Path: cont1aCont2aLeaf2a, Value: "13"}))
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+	_, setError = server.Set(context.Background(), newSetRequest(&SetPrecondition{Target: device1}))
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+}
+
+// Test_doSetOfflinePolicy shows that extension 110 sets the offline policy of the network change
+func Test_doSetOfflinePolicy(t *testing.T) {
+	server, mocks := setUpForGetSetTests(t)
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	typedValue := gnmi.TypedValue_UintVal{UintVal: 16}
+	value := gnmi.TypedValue{Value: &typedValue}
+	updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+
+	newSetRequest := func(changeID string, offlinePolicy string) *gnmi.SetRequest {
+		return &gnmi.SetRequest{
+			Update: []*gnmi.Update{{Path: &updatePath, Val: &value}},
+			Extension: []*gnmi_ext.Extension{
+				{
+					Ext: &gnmi_ext.Extension_RegisteredExt{
+						RegisteredExt: &gnmi_ext.RegisteredExtension{
+							Id:  GnmiExtensionNetwkChangeID,
+							Msg: []byte(changeID),
+						},
+					},
+				},
+				{
+					Ext: &gnmi_ext.Extension_RegisteredExt{
+						RegisteredExt: &gnmi_ext.RegisteredExtension{
+							Id:  GnmiExtensionOfflinePolicy,
+							Msg: []byte(offlinePolicy),
+						},
+					},
+				},
+			},
+		}
+	}
+
+	_, setError := server.Set(context.Background(), newSetRequest("TimeoutChange", "wait-timeout=2m"))
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	change, err := mocks.MockStores.NetworkChangesStore.Get("TimeoutChange")
+	assert.NilError(t, err)
+	assert.Equal(t, change.OfflinePolicy, networkchange.OfflinePolicy_WAIT_TIMEOUT)
+	assert.Equal(t, change.OfflineTimeout, 2*time.Minute)
+
+	_, setError = server.Set(context.Background(), newSetRequest("ReachableChange", "apply-reachable"))
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	change, err = mocks.MockStores.NetworkChangesStore.Get("ReachableChange")
+	assert.NilError(t, err)
+	assert.Equal(t, change.OfflinePolicy, networkchange.OfflinePolicy_APPLY_REACHABLE)
+
+	_, setError = server.Set(context.Background(), newSetRequest("BadPolicyChange", "wait-timeout=never"))
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+	_, setError = server.Set(context.Background(), newSetRequest("BadPolicyChange", "retry"))
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+}
@@ -15,14 +15,13 @@
 package gnmi
 
 import (
-	"crypto/sha1"
 	"fmt"
 	changetypes "github.com/onosproject/onos-config/api/types/change"
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
 	devicetype "github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-config/pkg/dispatcher"
 	"github.com/onosproject/onos-config/pkg/events"
 	"github.com/onosproject/onos-config/pkg/manager"
-	"github.com/onosproject/onos-config/pkg/store"
 	streams "github.com/onosproject/onos-config/pkg/store/stream"
 	"github.com/onosproject/onos-config/pkg/utils"
 	"github.com/onosproject/onos-config/pkg/utils/values"
@@ -33,54 +32,60 @@ import (
 	"google.golang.org/grpc/status"
 	"io"
 	"regexp"
+	"sync"
 	"time"
 )
 
+// defaultSampleInterval is the interval of a SAMPLE subscription that gives no sample interval
+const defaultSampleInterval = 10 * time.Second
+
 //internal struct to handle return of methods
 type result struct {
 	success bool
 	err     error
 }
 
+// sendResult gives the result of the subscription to Subscribe. Only the first result is taken - the
+// results that come after it are dropped, rather than left blocking their sender once Subscribe has returned
+func sendResult(resChan chan result, res result) {
+	select {
+	case resChan <- res:
+	default:
+	}
+}
+
 // Subscribe implements gNMI Subscribe
 func (s *Server) Subscribe(stream gnmi.GNMI_SubscribeServer) error {
-	//updateChan := make(chan *gnmi.Update)
 	var subscribe *gnmi.SubscriptionList
 	mgr := manager.GetManager()
-	//hashing the stream to obtain a unique identifier of the client
-	h := sha1.New()
-	_, err1 := io.WriteString(h, fmt.Sprintf("%v", stream))
-	if err1 != nil {
-		return err1
-	}
-	hash := store.B64(h.Sum(nil))
-	//Registering one listener for opStateChan
-	opStateChan, err := mgr.Dispatcher.RegisterOpState(hash)
-	if err != nil {
-		log.Warn("Subscription present: ", err)
-		return status.Error(codes.AlreadyExists, err.Error())
-	}
-	resChan := make(chan result)
+	// Only the first result is taken, so the channel holds it for Subscribe and never blocks its sender
+	resChan := make(chan result, 1)
 	//Handles each subscribe request coming into the server, blocks until a new request or an error comes in
-	go s.listenOnChannel(stream, mgr, hash, resChan, subscribe, opStateChan)
+	go s.listenOnChannel(stream, mgr, resChan, subscribe)
 
 	res := <-resChan
 
 	if !res.success {
-		return status.Error(codes.Internal, res.err.Error())
+		return statusError(res.err)
 	}
 	return nil
 }
 
-func (s *Server) listenOnChannel(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manager, hash string,
-	resChan chan result, subscribe *gnmi.SubscriptionList, opStateChan chan events.OperationalStateEvent) {
+func (s *Server) listenOnChannel(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manager,
+	resChan chan result, subscribe *gnmi.SubscriptionList) {
+	// Each STREAM subscription request registers its own operational state listener, which is unregistered
+	// when the stream ends or when its listener stops, whichever comes first
+	unregisters := make([]func(), 0)
+	// The aliases apply to all the subscriptions of the stream
+	aliases := newAliasTable()
+	// Streamed responses are queued, so that a slow client holds up neither the dispatcher nor the stores
+	queue := newSubscriptionQueue(stream, s.subscribeQueueSize, s.overflowPolicy)
+	var res result
 	for {
 		in, err := stream.Recv()
 		if err == io.EOF {
 			log.Info("Subscription Terminated EOF")
-			//Ignoring Errors during removal
-			mgr.Dispatcher.UnregisterOperationalState(hash)
-			resChan <- result{success: true, err: nil}
+			res = result{success: true, err: nil}
 			break
 		}
 
@@ -88,17 +93,22 @@ func (s *Server) listenOnChannel(stream gnmi.GNMI_SubscribeServer, mgr *manager.
 			code, ok := status.FromError(err)
 			if ok && code.Code() == codes.Canceled {
 				log.Info("Subscription Terminated, Canceled")
-				mgr.Dispatcher.UnregisterOperationalState(hash)
-				resChan <- result{success: true, err: nil}
+				res = result{success: true, err: nil}
 			} else {
 				log.Error("Error in subscription ", err)
-				//Ignoring Errors during removal
-				mgr.Dispatcher.UnregisterOperationalState(hash)
-				resChan <- result{success: false, err: err}
+				res = result{success: false, err: err}
 			}
 			break
 		}
 
+		if in.GetAliases() != nil {
+			if err := aliases.addClientAliases(in.GetAliases()); err != nil {
+				res = result{success: false, err: err}
+				break
+			}
+			continue
+		}
+
 		var mode gnmi.SubscriptionList_Mode
 
 		if in.GetPoll() != nil {
@@ -111,7 +121,17 @@ func (s *Server) listenOnChannel(stream gnmi.GNMI_SubscribeServer, mgr *manager.
 		//If there are no paths in the request such request is ignored
 		if subscribe.Subscription == nil {
 			log.Error("No subscription paths, ignoring request ", in)
-			resChan <- result{success: false, err: fmt.Errorf("no subscription paths in request")}
+			res = result{success: false, err: fmt.Errorf("no subscription paths in request")}
+			break
+		}
+
+		if err := checkEncoding(subscribe.Encoding); err != nil {
+			res = result{success: false, err: err}
+			break
+		}
+
+		if err := checkNetworkChangesSubscription(subscribe); err != nil {
+			res = result{success: false, err: err}
 			break
 		}
 
@@ -119,89 +139,382 @@ func (s *Server) listenOnChannel(stream gnmi.GNMI_SubscribeServer, mgr *manager.
 		version, err := extractSubscribeVersion(in)
 		if mode != gnmi.SubscriptionList_STREAM {
 			if err != nil {
-				resChan <- result{success: false, err: err}
+				sendResult(resChan, result{success: false, err: err})
 			} else {
 				go s.collector(mgr, version, stream, subscribe, resChan, mode)
 			}
 		} else {
+			if subscribe.UseAliases {
+				aliases.allowTargetDefined()
+			}
 
 			subs := subscribe.Subscription
-			//FAST way to identify if target and subscription is present
-			subsStr := make([]*regexp.Regexp, 0)
+			// The paths of the changes are matched element by element against those subscribed to
+			subsPaths := make([]*utils.PathMatcher, 0)
 			targets := make(map[string]struct{})
+			// Target globs such as "*" also cover the devices added later
+			targetPatterns := make([]*regexp.Regexp, 0)
+			changeSubs := make([]*utils.PathMatcher, 0)
+			// Updates are held back until the initial values and the sync response have been sent
+			synced := make(chan struct{})
 			for _, sub := range subs {
-				subscriptionPathStr := utils.StrPath(sub.Path)
-				subsStr = append(subsStr, utils.MatchWildcardRegexp(subscriptionPathStr))
-				targets[sub.Path.Target] = struct{}{}
+				target := subscriptionTarget(subscribe.Prefix, sub.Path)
+				if target == NetworkChangesTarget {
+					changeSubs = append(changeSubs, subscriptionMatcher(subscribe.Prefix, sub.Path))
+					continue
+				}
+				// Sampled paths are not sent on change
+				if sub.Mode == gnmi.SubscriptionMode_SAMPLE {
+					go s.sampleSubscription(queue, version, subscribe, sub, aliases, synced, resChan)
+					continue
+				}
+				subsPaths = append(subsPaths, subscriptionMatcher(subscribe.Prefix, sub.Path))
+				if utils.IsWildcardTarget(target) {
+					targetPatterns = append(targetPatterns, utils.MatchWildcardTargetRegexp(target))
+				} else {
+					targets[target] = struct{}{}
+				}
+			}
+			if len(targets) > 0 || len(targetPatterns) > 0 {
+				// The dispatcher only sends the operational state events of the targets and paths subscribed to
+				filter := dispatcher.OpStateFilter{Paths: subsPaths, TargetPatterns: targetPatterns}
+				for target := range targets {
+					filter.Targets = append(filter.Targets, target)
+				}
+				subscriptionID, opStateChan := mgr.Dispatcher.RegisterOpStateSubscription(filter)
+				var once sync.Once
+				unregister := func() {
+					once.Do(func() {
+						mgr.Dispatcher.UnregisterOperationalState(subscriptionID)
+					})
+				}
+				unregisters = append(unregisters, unregister)
+				log.Infof("Subscription %s registered for targets %v", subscriptionID, filter.Targets)
+				go listenForOpStateUpdates(opStateChan, queue, aliases, synced, resChan, unregister)
 			}
 			//Each subscription request spawns a go routing listening for related events for the target and the paths
-			go listenForUpdates(stream, mgr, targets, version, subsStr, resChan)
-			go listenForOpStateUpdates(opStateChan, stream, targets, subsStr, resChan)
+			//The changes are watched before the initial values are read, so that no change is missed
+			listenForUpdates(queue, mgr, targets, version, subsPaths, aliases, synced, resChan)
+			if len(targetPatterns) > 0 {
+				listenForTargetPatterns(queue, mgr, targetPatterns, version, subsPaths, aliases, synced, resChan)
+			}
+			if len(changeSubs) > 0 {
+				listenForNetworkChanges(queue, mgr, changeSubs, synced, resChan)
+			}
+			go s.sendInitialValues(queue, version, subscribe, aliases, synced, resChan)
 		}
 	}
+
+	// The dispatcher and the queue are released before Subscribe is, as nothing reads the listeners once it returns
+	for _, unregister := range unregisters {
+		unregister()
+	}
+	queue.close()
+	sendResult(resChan, res)
 }
 
 func (s *Server) collector(mgr *manager.Manager, version devicetype.Version, stream gnmi.GNMI_SubscribeServer, request *gnmi.SubscriptionList, resChan chan result, mode gnmi.SubscriptionList_Mode) {
 	for _, sub := range request.Subscription {
-		_, version, err := mgr.CheckCacheForDevice(devicetype.ID(sub.GetPath().GetTarget()), devicetype.Type(""), version)
-		if err != nil {
-			log.Error("Error while collecting data from device cache ", err)
-			resChan <- result{success: false, err: err}
-		}
-		//We get the stated of the device, for each path we build an update and send it out.
-		update, err := s.getUpdate(version, request.Prefix, sub.Path)
-		if err != nil {
-			log.Error("Error while collecting data for subscribe once or poll ", err)
-			resChan <- result{success: false, err: err}
-		}
-		response, errGet := buildUpdateResponse(update)
-		if errGet != nil {
-			log.Error("Error Retrieving Device", err)
-			resChan <- result{success: false, err: err}
+		if subscriptionTarget(request.Prefix, sub.Path) == NetworkChangesTarget {
+			if err := sendNetworkChanges(stream, request.Prefix, sub.Path); err != nil {
+				log.Error("Error sending network changes ", err)
+				sendResult(resChan, result{success: false, err: err})
+				return
+			}
+			continue
 		}
-		err = sendResponse(response, stream)
-		if err != nil {
-			log.Error("Error sending response ", err)
-			resChan <- result{success: false, err: err}
+		// A target glob is collected from each device it matches
+		for _, path := range expandTarget(request.Prefix, sub.Path) {
+			_, version, err := mgr.CheckCacheForDevice(devicetype.ID(path.GetTarget()), devicetype.Type(""), version)
+			if err != nil {
+				log.Error("Error while collecting data from device cache ", err)
+				sendResult(resChan, result{success: false, err: err})
+			}
+			//We get the stated of the device, for each path we build an update and send it out.
+			updates, err := s.getUpdates(&getExtensions{version: version}, gnmi.GetRequest_ALL, request.Encoding, request.Prefix, path)
+			if err != nil {
+				log.Error("Error while collecting data for subscribe once or poll ", err)
+				sendResult(resChan, result{success: false, err: err})
+			}
+			response, errGet := buildUpdateResponse(updates...)
+			if errGet != nil {
+				log.Error("Error Retrieving Device", errGet)
+				sendResult(resChan, result{success: false, err: errGet})
+			}
+			err = sendResponse(response, stream)
+			if err != nil {
+				log.Error("Error sending response ", err)
+				sendResult(resChan, result{success: false, err: err})
+				return
+			}
 		}
 	}
 	responseSync := buildSyncResponse()
 	err := sendResponse(responseSync, stream)
 	if err != nil {
 		log.Error("Error sending sync response ", err)
-		resChan <- result{success: false, err: err}
+		sendResult(resChan, result{success: false, err: err})
 	} else if mode != gnmi.SubscriptionList_POLL {
 		//Sending only if we need to finish listening because of ONCE
 		// if POLL we need to keep the channel open
-		resChan <- result{success: true, err: nil}
+		sendResult(resChan, result{success: true, err: nil})
+	}
+}
+
+// sendInitialValues sends the current values of all the paths of a STREAM subscription followed by a
+// sync response, or only the sync response if updates only were requested. It then closes the synced
+// channel to release the updates that were held back
+func (s *Server) sendInitialValues(stream gnmi.GNMI_SubscribeServer, version devicetype.Version,
+	subscribe *gnmi.SubscriptionList, aliases *aliasTable, synced chan struct{}, resChan chan result) {
+	defer close(synced)
+	if !subscribe.UpdatesOnly {
+		for _, sub := range subscribe.Subscription {
+			if subscriptionTarget(subscribe.Prefix, sub.Path) == NetworkChangesTarget {
+				if err := sendNetworkChanges(stream, subscribe.Prefix, sub.Path); err != nil {
+					log.Error("Error sending network changes ", err)
+					sendResult(resChan, result{success: false, err: err})
+					return
+				}
+				continue
+			}
+			for _, path := range expandTarget(subscribe.Prefix, sub.Path) {
+				values, err := s.getSample(version, subscribe.Prefix, path)
+				if err != nil {
+					log.Error("Error while collecting initial values ", err)
+					sendResult(resChan, result{success: false, err: err})
+					return
+				}
+				if len(values) == 0 {
+					continue
+				}
+				if err := sendDiffResponses(subscribe.Prefix, path, manager.DiffConfig(nil, values), aliases, stream); err != nil {
+					log.Error("Error sending initial values ", err)
+					sendResult(resChan, result{success: false, err: err})
+					return
+				}
+			}
+		}
+	}
+	if err := sendResponse(buildSyncResponse(), stream); err != nil {
+		log.Error("Error sending sync response ", err)
+		sendResult(resChan, result{success: false, err: err})
+	}
+}
+
+// sampleSubscription sends the values of the path of a SAMPLE subscription every sample interval, until
+// the stream is done. The values at the start are sent with the initial values of the subscription.
+// If redundant samples are suppressed only the values that changed since the last sample are sent,
+// and all values again every heartbeat interval if one is given
+func (s *Server) sampleSubscription(stream gnmi.GNMI_SubscribeServer, version devicetype.Version,
+	subscribe *gnmi.SubscriptionList, sub *gnmi.Subscription, aliases *aliasTable, synced chan struct{}, resChan chan result) {
+	interval := time.Duration(sub.SampleInterval)
+	if interval == 0 {
+		interval = defaultSampleInterval
+	}
+	heartbeatInterval := time.Duration(sub.HeartbeatInterval)
+	<-synced
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// A target glob is sampled from each device it matches at the time
+	lastSamples := make(map[string][]*devicechange.PathValue)
+	if sub.SuppressRedundant && !subscribe.UpdatesOnly {
+		for _, path := range expandTarget(subscribe.Prefix, sub.Path) {
+			sample, err := s.getSample(version, subscribe.Prefix, path)
+			if err != nil {
+				log.Error("Error while sampling ", err)
+				sendResult(resChan, result{success: false, err: err})
+				return
+			}
+			lastSamples[subscriptionTarget(subscribe.Prefix, path)] = sample
+		}
+	}
+	lastHeartbeat := time.Now()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return
+		}
+
+		heartbeat := heartbeatInterval > 0 && time.Since(lastHeartbeat) >= heartbeatInterval
+		if heartbeat {
+			lastHeartbeat = time.Now()
+		}
+		for _, path := range expandTarget(subscribe.Prefix, sub.Path) {
+			target := subscriptionTarget(subscribe.Prefix, path)
+			sample, err := s.getSample(version, subscribe.Prefix, path)
+			if err != nil {
+				log.Error("Error while sampling ", err)
+				sendResult(resChan, result{success: false, err: err})
+				return
+			}
+
+			var diffs []*manager.ConfigDiff
+			if !sub.SuppressRedundant || heartbeat {
+				diffs = manager.DiffConfig(nil, sample)
+			} else {
+				diffs = manager.DiffConfig(lastSamples[target], sample)
+			}
+			lastSamples[target] = sample
+
+			if len(diffs) > 0 {
+				if err := sendDiffResponses(subscribe.Prefix, path, diffs, aliases, stream); err != nil {
+					log.Error("Error sending sample ", err)
+					sendResult(resChan, result{success: false, err: err})
+					return
+				}
+			}
+		}
+	}
+}
+
+// subscriptionTarget returns the target of the path of a subscription, or else the target of its prefix
+func subscriptionTarget(prefix *gnmi.Path, path *gnmi.Path) string {
+	if target := path.GetTarget(); target != "" {
+		return target
+	}
+	return prefix.GetTarget()
+}
+
+// getSample gets the current configuration and state values under the path of the target
+func (s *Server) getSample(version devicetype.Version, prefix *gnmi.Path, path *gnmi.Path) ([]*devicechange.PathValue, error) {
+	target := subscriptionTarget(prefix, path)
+	if target == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "no target given for sampled path %s", utils.StrPath(path))
+	}
+	sample, _, _, err := s.getPathValues(&getExtensions{version: version}, gnmi.GetRequest_ALL, target, prefix, path)
+	return sample, err
+}
+
+// buildDiffResponses builds a notification with an update for each value that was added or changed
+// since the last values sent, and a delete for each value that was removed. The paths are relative to
+// the prefix, except for those under an alias - these are given in a notification for each alias, as
+// the rest of the path under the alias. The definition of any alias the target defines is sent first
+func buildDiffResponses(prefix *gnmi.Path, path *gnmi.Path, diffs []*manager.ConfigDiff, aliases *aliasTable,
+	stream gnmi.GNMI_SubscribeServer) ([]*gnmi.SubscribeResponse, error) {
+	target := subscriptionTarget(prefix, path)
+	notifications := make([]*gnmi.Notification, 0, 1)
+	// The notifications by the path of their prefix
+	prefixNotifications := make(map[string]*gnmi.Notification)
+	notificationFor := func(notificationPrefix *gnmi.Path) *gnmi.Notification {
+		key := utils.StrPath(notificationPrefix)
+		notification, ok := prefixNotifications[key]
+		if !ok {
+			notification = &gnmi.Notification{
+				Timestamp: time.Now().Unix(),
+				Prefix:    notificationPrefix,
+			}
+			prefixNotifications[key] = notification
+			notifications = append(notifications, notification)
+		}
+		return notification
+	}
+
+	for _, diff := range diffs {
+		leafPath, err := utils.ParseGNMIElements(utils.SplitPath(diff.Path))
+		if err != nil {
+			return nil, err
+		}
+		leafPath.Target = target
+		aliasedPrefix, aliasedPath, err := aliases.aliasPath(leafPath, stream)
+		if err != nil {
+			return nil, err
+		}
+		var notification *gnmi.Notification
+		if aliasedPrefix != nil {
+			notification = notificationFor(aliasedPrefix)
+			leafPath = aliasedPath
+		} else {
+			notification = notificationFor(prefix)
+			if prefix != nil && len(prefix.Elem) <= len(leafPath.Elem) {
+				leafPath.Elem = leafPath.Elem[len(prefix.Elem):]
+			}
+			leafPath.Target = path.GetTarget()
+		}
+		if diff.NewValue == nil {
+			notification.Delete = append(notification.Delete, leafPath)
+			continue
+		}
+		value, err := values.NativeTypeToGnmiTypedValue(diff.NewValue)
+		if err != nil {
+			log.Warn("Unable to convert native value to gnmi", err)
+			return nil, err
+		}
+		notification.Update = append(notification.Update, &gnmi.Update{
+			Path: leafPath,
+			Val:  value,
+		})
+	}
+
+	responses := make([]*gnmi.SubscribeResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		response, err := buildSubscribeResponse(notification, target)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, response)
 	}
+	return responses, nil
+}
+
+// sendDiffResponses sends the responses built by buildDiffResponses
+func sendDiffResponses(prefix *gnmi.Path, path *gnmi.Path, diffs []*manager.ConfigDiff, aliases *aliasTable,
+	stream gnmi.GNMI_SubscribeServer) error {
+	responses, err := buildDiffResponses(prefix, path, diffs, aliases, stream)
+	if err != nil {
+		return err
+	}
+	for _, response := range responses {
+		if err := sendResponse(response, stream); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 //For each update coming from the change channel we check if it's for a valid target and path then, if so, we send it NB
 func listenForUpdates(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manager,
-	targets map[string]struct{}, version devicetype.Version, subs []*regexp.Regexp, resChan chan result) {
+	targets map[string]struct{}, version devicetype.Version, subs []*utils.PathMatcher, aliases *aliasTable,
+	synced chan struct{}, resChan chan result) {
 	for target := range targets {
 		_, version, err := mgr.CheckCacheForDevice(devicetype.ID(target), devicetype.Type(""), version)
 		if err != nil {
 			log.Errorf("unable to get version from cache %s", err)
 			return
 		}
-		go listenForDeviceUpdates(stream, mgr, devicetype.ID(target), version, subs, resChan)
+		eventCh := make(chan streams.Event)
+		ctx, errWatch := mgr.DeviceChangesStore.Watch(devicetype.NewVersionedID(devicetype.ID(target), version), eventCh)
+		if errWatch != nil {
+			log.Errorf("Cant watch for changes on device %s. error %s", target, errWatch.Error())
+			sendResult(resChan, result{success: false, err: errWatch})
+			return
+		}
+		go listenForDeviceUpdates(stream, devicetype.ID(target), eventCh, ctx, subs, aliases, synced, resChan)
 	}
 }
 
 //For each update coming from the change channel we check if it's for a valid target and path then, if so, we send it NB
-func listenForDeviceUpdates(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manager,
-	target devicetype.ID, version devicetype.Version, subs []*regexp.Regexp, resChan chan result) {
-	eventCh := make(chan streams.Event)
-	ctx, errWatch := mgr.DeviceChangesStore.Watch(devicetype.NewVersionedID(target, version), eventCh)
-	if errWatch != nil {
-		log.Errorf("Cant watch for changes on device %s. error %s", target, errWatch.Error())
-		resChan <- result{success: false, err: errWatch}
+//The changes are watched until the stream is done
+func listenForDeviceUpdates(stream gnmi.GNMI_SubscribeServer, target devicetype.ID, eventCh chan streams.Event,
+	ctx streams.Context, subs []*utils.PathMatcher, aliases *aliasTable, synced chan struct{}, resChan chan result) {
+	defer closeWatch(ctx, eventCh)
+	select {
+	case <-synced:
+	case <-stream.Context().Done():
 		return
 	}
-	defer ctx.Close()
-	for changeEvent := range eventCh {
+	for {
+		var changeEvent streams.Event
+		select {
+		case event, ok := <-eventCh:
+			if !ok {
+				return
+			}
+			changeEvent = event
+		case <-stream.Context().Done():
+			return
+		}
 		change, ok := changeEvent.Object.(*devicechange.DeviceChange)
 		if !ok {
 			log.Error("Could not convert event to DeviceChange")
@@ -209,17 +522,18 @@ func listenForDeviceUpdates(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manag
 		}
 		if change.Status.State == changetypes.State_COMPLETE {
 			for _, value := range change.Change.Values {
-				if matchRegex(value.Path, subs) {
-					pathGnmi, err := utils.ParseGNMIElements(utils.SplitPath(value.Path))
-					if err != nil {
-						log.Warn("Error in parsing path ", err)
-						continue
-					}
+				pathGnmi, err := utils.ParseGNMIElements(utils.SplitPath(value.Path))
+				if err != nil {
+					log.Warn("Error in parsing path ", err)
+					continue
+				}
+				if matchPaths(pathGnmi, subs) {
 					log.Infof("Subscribe notification for %s on %s with value %s", pathGnmi, target, value.Value)
-					err = buildAndSendUpdate(pathGnmi, string(target), value.Value, value.Removed, stream)
+					err = buildAndSendUpdate(pathGnmi, string(target), value.Value, value.Removed, stream, aliases)
 					if err != nil {
 						log.Error("Error in sending update path ", err)
-						resChan <- result{success: false, err: err}
+						sendResult(resChan, result{success: false, err: err})
+						return
 					}
 				}
 			}
@@ -227,33 +541,98 @@ func listenForDeviceUpdates(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manag
 	}
 }
 
-//For each update coming from the state channel we check if it's for a valid target and path then, if so, we send it NB
+//For each update coming from the state channel we send it NB - the dispatcher only sends the events of the
+//targets and paths of the subscription. The events that come before the initial values have been sent are
+//held here rather than left in the channel, so that the dispatcher is not held up by the initial sync.
+//The listener is unregistered once the stream is done, so that the dispatcher is never left waiting on it
 func listenForOpStateUpdates(opStateChan chan events.OperationalStateEvent, stream gnmi.GNMI_SubscribeServer,
-	targets map[string]struct{}, subs []*regexp.Regexp, resChan chan result) {
-	for opStateChange := range opStateChan {
-		target := opStateChange.Subject()
-		_, targetPresent := targets[target]
-		if targetPresent && matchRegex(opStateChange.Path(), subs) {
-			pathArr := utils.SplitPath(opStateChange.Path())
-			pathGnmi, err := utils.ParseGNMIElements(pathArr)
-			if err != nil {
-				log.Warn("Error in parsing path", err)
-				resChan <- result{success: true, err: nil}
-				continue
+	aliases *aliasTable, synced chan struct{}, resChan chan result, unregister func()) {
+	defer unregister()
+	pending := make([]events.OperationalStateEvent, 0)
+	for pending != nil {
+		select {
+		case opStateChange, ok := <-opStateChan:
+			if !ok {
+				return
+			}
+			pending = append(pending, opStateChange)
+		case <-synced:
+			for _, opStateChange := range pending {
+				if err := sendOpStateUpdate(opStateChange, stream, aliases, resChan); err != nil {
+					return
+				}
 			}
+			pending = nil
+		case <-stream.Context().Done():
+			return
+		}
+	}
+	for {
+		select {
+		case opStateChange, ok := <-opStateChan:
+			if !ok {
+				return
+			}
+			if err := sendOpStateUpdate(opStateChange, stream, aliases, resChan); err != nil {
+				return
+			}
+		case <-stream.Context().Done():
+			return
+		}
+	}
+}
 
-			err = buildAndSendUpdate(pathGnmi, target, opStateChange.Value(), len(opStateChange.Value().Bytes) == 0, stream)
-			if err != nil {
-				log.Error("Error in sending update path ", err)
-				resChan <- result{success: false, err: err}
+// sendOpStateUpdate sends an op state event on the stream. An error is only returned when the stream
+// could not be sent on, after which the listener stops
+func sendOpStateUpdate(opStateChange events.OperationalStateEvent, stream gnmi.GNMI_SubscribeServer,
+	aliases *aliasTable, resChan chan result) error {
+	pathArr := utils.SplitPath(opStateChange.Path())
+	pathGnmi, err := utils.ParseGNMIElements(pathArr)
+	if err != nil {
+		log.Warn("Error in parsing path", err)
+		return nil
+	}
+
+	err = buildAndSendUpdate(pathGnmi, opStateChange.Subject(), opStateChange.Value(), len(opStateChange.Value().Bytes) == 0, stream, aliases)
+	if err != nil {
+		log.Error("Error in sending update path ", err)
+		sendResult(resChan, result{success: false, err: err})
+		return err
+	}
+	return nil
+}
+
+// closeWatch closes the watch of a store, reading its events until it is closed so that the store is never
+// blocked on them
+func closeWatch(ctx streams.Context, eventCh chan streams.Event) {
+	closed := make(chan struct{})
+	go func() {
+		ctx.Close()
+		close(closed)
+	}()
+	for {
+		select {
+		case _, ok := <-eventCh:
+			if !ok {
+				eventCh = nil
 			}
+		case <-closed:
+			return
 		}
 	}
 }
 
-func matchRegex(path string, subs []*regexp.Regexp) bool {
+// subscriptionMatcher returns a matcher for the path of a subscription under its prefix
+func subscriptionMatcher(prefix *gnmi.Path, path *gnmi.Path) *utils.PathMatcher {
+	elems := make([]*gnmi.PathElem, 0, len(prefix.GetElem())+len(path.GetElem()))
+	elems = append(elems, prefix.GetElem()...)
+	elems = append(elems, path.GetElem()...)
+	return utils.NewPathMatcher(&gnmi.Path{Elem: elems})
+}
+
+func matchPaths(path *gnmi.Path, subs []*utils.PathMatcher) bool {
 	for _, s := range subs {
-		if s.MatchString(path) {
+		if s.Match(path) {
 			return true
 		}
 	}
@@ -261,13 +640,20 @@ func matchRegex(path string, subs []*regexp.Regexp) bool {
 }
 
 func buildAndSendUpdate(pathGnmi *gnmi.Path, target string, value *devicechange.TypedValue, removed bool,
-	stream gnmi.GNMI_SubscribeServer) error {
+	stream gnmi.GNMI_SubscribeServer, aliases *aliasTable) error {
 	pathGnmi.Target = target
-	var response *gnmi.SubscribeResponse
-	var errGet error
+	// An aliased path is sent as the alias in the prefix and the rest of the path
+	prefix, pathGnmi, err := aliases.aliasPath(pathGnmi, stream)
+	if err != nil {
+		return err
+	}
+	notification := &gnmi.Notification{
+		Timestamp: time.Now().Unix(),
+		Prefix:    prefix,
+	}
 	//if removed we issue a delete notification
 	if removed {
-		response, errGet = buildDeleteResponse(pathGnmi)
+		notification.Delete = []*gnmi.Path{pathGnmi}
 	} else {
 		valueGnmi, err := values.NativeTypeToGnmiTypedValue(value)
 		if err != nil {
@@ -275,22 +661,17 @@ func buildAndSendUpdate(pathGnmi *gnmi.Path, target string, value *devicechange.
 			return err
 		}
 
-		update := &gnmi.Update{
+		notification.Update = []*gnmi.Update{{
 			Path: pathGnmi,
 			Val:  valueGnmi,
-		}
-		response, errGet = buildUpdateResponse(update)
+		}}
 	}
-	if errGet != nil {
-		return errGet
-	}
-	err := sendResponse(response, stream)
+	response, err := buildSubscribeResponse(notification, target)
 	if err != nil {
 		return err
 	}
-	responseSync := buildSyncResponse()
-	err = sendResponse(responseSync, stream)
-	return err
+	// The sync response has been sent once already, after the initial values
+	return sendResponse(response, stream)
 }
 
 func buildSyncResponse() *gnmi.SubscribeResponse {
@@ -302,23 +683,14 @@ func buildSyncResponse() *gnmi.SubscribeResponse {
 	}
 }
 
-func buildUpdateResponse(update *gnmi.Update) (*gnmi.SubscribeResponse, error) {
+func buildUpdateResponse(updates ...*gnmi.Update) (*gnmi.SubscribeResponse, error) {
 	updateArray := make([]*gnmi.Update, 0)
-	updateArray = append(updateArray, update)
+	updateArray = append(updateArray, updates...)
 	notification := &gnmi.Notification{
 		Timestamp: time.Now().Unix(),
 		Update:    updateArray,
 	}
-	return buildSubscribeResponse(notification, update.Path.Target)
-}
-
-func buildDeleteResponse(delete *gnmi.Path) (*gnmi.SubscribeResponse, error) {
-	deleteArray := []*gnmi.Path{delete}
-	notification := &gnmi.Notification{
-		Timestamp: time.Now().Unix(),
-		Delete:    deleteArray,
-	}
-	return buildSubscribeResponse(notification, delete.Target)
+	return buildSubscribeResponse(notification, updates[0].Path.Target)
 }
 
 func buildSubscribeResponse(notification *gnmi.Notification, target string) (*gnmi.SubscribeResponse, error) {
@@ -352,7 +724,7 @@ func sendResponse(response *gnmi.SubscribeResponse, stream gnmi.GNMI_SubscribeSe
 	err := stream.Send(response)
 	if err != nil {
 		log.Warn("Error in sending response to client ", err)
-		return status.Error(codes.Internal, err.Error())
+		return statusError(err)
 	}
 	return nil
 }
@@ -17,7 +17,10 @@ package gnmi
 import (
 	"context"
 	"github.com/golang/mock/gomock"
+	devicechange "github.com/onosproject/onos-config/api/types/change/device"
+	"github.com/onosproject/onos-config/pkg/events"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
+	"github.com/onosproject/onos-config/pkg/store/stream"
 	"github.com/onosproject/onos-config/pkg/utils"
 	topodevice "github.com/onosproject/onos-topo/api/device"
 	"github.com/openconfig/gnmi/proto/gnmi"
@@ -42,9 +45,6 @@ type gNMISubscribeServerFake struct {
 
 func (x gNMISubscribeServerFake) Send(m *gnmi.SubscribeResponse) error {
 	x.Responses <- m
-	if m.GetSyncResponse() {
-		close(x.Responses)
-	}
 	return nil
 }
 
@@ -53,6 +53,10 @@ func (x gNMISubscribeServerFake) Recv() (*gnmi.SubscribeRequest, error) {
 	return x.Request, nil
 }
 
+func (x gNMISubscribeServerFake) Context() context.Context {
+	return context.Background()
+}
+
 type gNMISubscribeServerPollFake struct {
 	Request     *gnmi.SubscribeRequest
 	PollRequest *gnmi.SubscribeRequest
@@ -148,6 +152,7 @@ func Test_SubscribeLeafStream(t *testing.T) {
 	path.Target = "Device1"
 
 	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	request.GetSubscribe().UpdatesOnly = true
 
 	responsesChan := make(chan *gnmi.SubscribeResponse, 1)
 	serverFake := gNMISubscribeServerFake{
@@ -189,10 +194,10 @@ func Test_SubscribeLeafStream(t *testing.T) {
 	path3Stream := "leaf4a"
 	valueReply := uint(14)
 
+	//Only the sync response is sent first as updates only were requested
+	assertSyncResponse(responsesChan, t)
 	//Expecting 1 Update response
 	assertUpdateResponse(t, responsesChan, device1, path1Stream, path2Stream, path3Stream, valueReply, true)
-	//And one sync response
-	assertSyncResponse(responsesChan, t)
 
 }
 
@@ -282,43 +287,294 @@ func Test_WrongPath(t *testing.T) {
 
 }
 
-func Test_ErrorDoubleSubscription(t *testing.T) {
+func Test_ConcurrentSubscriptions(t *testing.T) {
 	server, mgr, mocks := setUp(t)
-	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found"))
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Version:  "1.0.0",
+			Type:     "Stratum",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
 	var wg sync.WaitGroup
 	defer tearDown(mgr, &wg)
 
-	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf4a"})
+	opStateCh := make(chan events.OperationalStateEvent)
+	defer close(opStateCh)
+	go mgr.Dispatcher.ListenOperationalState(opStateCh)
+
+	// The same client subscribes to the state of two devices on two streams
+	subscribe := func(target string) chan *gnmi.SubscribeResponse {
+		path, err := utils.ParseGNMIElements([]string{"cont1b-state", "cont2c", "leaf3c"})
+		assert.NilError(t, err, "Unexpected error doing parsing")
+		path.Target = target
+		request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+		request.GetSubscribe().UpdatesOnly = true
+
+		responsesChan := make(chan *gnmi.SubscribeResponse, 1)
+		serverFake := gNMISubscribeServerFake{
+			Request:   request,
+			Responses: responsesChan,
+			Signal:    make(chan struct{}),
+		}
+		go func() {
+			err := server.Subscribe(serverFake)
+			assert.NilError(t, err, "Unexpected error doing Subscribe")
+		}()
+		serverFake.Signal <- struct{}{}
+		assertSyncResponse(responsesChan, t)
+		return responsesChan
+	}
+	device1Responses := subscribe("Device1")
+	device2Responses := subscribe("Device2")
+	assert.Equal(t, len(mgr.Dispatcher.GetListeners()), 2)
+
+	// Only the subscription to the device of the event receives it
+	opStateCh <- events.NewOperationalStateEvent("Device2", "/cont1b-state/cont2c/leaf3c",
+		devicechange.NewTypedValueUint64(12), events.EventItemUpdated)
+	assertUpdateResponse(t, device2Responses, "Device2", "cont1b-state", "cont2c", "leaf3c", 12, true)
+
+	// Nor does an event on a path not subscribed to reach either subscription
+	opStateCh <- events.NewOperationalStateEvent("Device1", "/cont1b-state/cont2c/leaf3d",
+		devicechange.NewTypedValueUint64(13), events.EventItemUpdated)
+	select {
+	case response := <-device1Responses:
+		log.Error("Should not be receiving response ", response)
+		t.Fail()
+	case response := <-device2Responses:
+		log.Error("Should not be receiving response ", response)
+		t.Fail()
+	case <-time.After(50 * time.Millisecond):
+	}
+}
 
-	assert.NilError(t, err, "Unexpected error doing parsing")
+// Test_OpStateUpdatesDuringSync tests that the operational state events that come while the initial
+// values are being sent are taken from the dispatcher at once, and sent after the sync
+func Test_OpStateUpdatesDuringSync(t *testing.T) {
+	_, mgr, mocks := setUp(t)
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+	var wg sync.WaitGroup
+	defer tearDown(mgr, &wg)
 
-	path.Target = "Device1"
+	opStateChan := make(chan events.OperationalStateEvent)
+	responsesChan := make(chan *gnmi.SubscribeResponse, 2)
+	synced := make(chan struct{})
+	go listenForOpStateUpdates(opStateChan, gNMISubscribeServerFake{Responses: responsesChan}, newAliasTable(),
+		synced, make(chan result, 1), func() {})
 
-	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	for i := 1; i <= 2; i++ {
+		select {
+		case opStateChan <- events.NewOperationalStateEvent("Device1", "/cont1b-state/cont2c/leaf3c",
+			devicechange.NewTypedValueUint64(uint(i)), events.EventItemUpdated):
+		case <-time.After(time.Second):
+			t.Fatal("Operational state event was not taken during the sync")
+		}
+	}
+	select {
+	case response := <-responsesChan:
+		t.Fatalf("Unexpected response before the sync %v", response)
+	case <-time.After(50 * time.Millisecond):
+	}
 
-	responsesChan := make(chan *gnmi.SubscribeResponse, 1)
-	serverFake := gNMISubscribeServerFake{
-		Request:   request,
-		Responses: responsesChan,
-		Signal:    make(chan struct{}),
+	close(synced)
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1b-state", "cont2c", "leaf3c", 1, true)
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1b-state", "cont2c", "leaf3c", 2, true)
+	close(opStateChan)
+}
+
+// gNMISubscribeServerCancelFake is a stream that ends with a Canceled error once its context is done,
+// as a gRPC stream does
+type gNMISubscribeServerCancelFake struct {
+	gNMISubscribeServerSampleFake
+	received *bool
+}
+
+func (x gNMISubscribeServerCancelFake) Recv() (*gnmi.SubscribeRequest, error) {
+	if !*x.received {
+		*x.received = true
+		return x.Request, nil
 	}
+	<-x.ctx.Done()
+	return nil, status.Error(codes.Canceled, x.ctx.Err().Error())
+}
 
+// streamOpStateEvents sends operational state events for Device1 until it is stopped, pausing briefly
+// between them as a device does. The returned channel is closed once it has stopped
+func streamOpStateEvents(opStateCh chan events.OperationalStateEvent, stop chan struct{}) chan struct{} {
+	done := make(chan struct{})
 	go func() {
-		err = server.Subscribe(serverFake)
-		assert.NilError(t, err, "Unexpected error doing Subscribe")
-		var response *gnmi.SubscribeResponse
-		select {
-		case response = <-responsesChan:
-			log.Error("Should not be receiving response ", response)
-			t.Fail()
-		case <-time.After(50 * time.Millisecond):
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case opStateCh <- events.NewOperationalStateEvent("Device1", "/cont1b-state/cont2c/leaf3c",
+				devicechange.NewTypedValueUint64(uint(i)), events.EventItemUpdated):
+			case <-stop:
+				return
+			}
+			time.Sleep(time.Millisecond)
 		}
 	}()
-	//FIXME Waiting for subscribe to finish properly --> when event is issued assuring state consistency we can remove
+	return done
+}
+
+// Test_SubscribeEndWithOpStateUpdates tests that a subscription ended while operational state events are
+// being sent to it is unregistered from the dispatcher, which goes on sending the events of the others
+func Test_SubscribeEndWithOpStateUpdates(t *testing.T) {
+	server, mgr, mocks := setUp(t)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Version:  "1.0.0",
+			Type:     "Stratum",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+	var wg sync.WaitGroup
+	defer tearDown(mgr, &wg)
+
+	opStateCh := make(chan events.OperationalStateEvent)
+	defer close(opStateCh)
+	go mgr.Dispatcher.ListenOperationalState(opStateCh)
+
+	path, err := utils.ParseGNMIElements([]string{"cont1b-state", "cont2c", "leaf3c"})
+	assert.NilError(t, err, "Unexpected error doing parsing")
+	path.Target = "Device1"
+	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	request.GetSubscribe().UpdatesOnly = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	responsesChan := make(chan *gnmi.SubscribeResponse, 1)
+	received := false
+	serverFake := gNMISubscribeServerCancelFake{
+		gNMISubscribeServerSampleFake: gNMISubscribeServerSampleFake{
+			gNMISubscribeServerFake: gNMISubscribeServerFake{
+				Request:   request,
+				Responses: responsesChan,
+			},
+			ctx: ctx,
+		},
+		received: &received,
+	}
+	subscribeErr := make(chan error, 1)
+	go func() {
+		subscribeErr <- server.Subscribe(serverFake)
+	}()
+	assertSyncResponse(responsesChan, t)
+	assert.Equal(t, len(mgr.Dispatcher.GetListeners()), 1)
+
+	// The events keep coming, and are not read by the client, while the stream ends
+	stopEvents := make(chan struct{})
+	eventsDone := streamOpStateEvents(opStateCh, stopEvents)
 	time.Sleep(subscribeDelay)
+	cancel()
+
+	select {
+	case err := <-subscribeErr:
+		assert.NilError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe did not return when the stream ended")
+	}
+	for i := 0; len(mgr.Dispatcher.GetListeners()) > 0; i++ {
+		if i == 100 {
+			t.Fatal("Subscription was not unregistered when the stream ended")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The dispatcher is not held up by the subscription that ended
+	close(stopEvents)
+	<-eventsDone
+	select {
+	case opStateCh <- events.NewOperationalStateEvent("Device1", "/cont1b-state/cont2c/leaf3c",
+		devicechange.NewTypedValueUint64(0), events.EventItemUpdated):
+	case <-time.After(time.Second):
+		t.Fatal("Dispatcher is blocked on the subscription that ended")
+	}
+}
+
+// Test_SubscribeDisconnectWithOpStateUpdates tests that a subscription whose client does not keep up is
+// disconnected, and that its listener stops and is unregistered from the dispatcher without waiting for the
+// stream to end
+func Test_SubscribeDisconnectWithOpStateUpdates(t *testing.T) {
+	server, mgr, mocks := setUp(t)
+	server.subscribeQueueSize = 1
+	server.overflowPolicy = OverflowDisconnect
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Version:  "1.0.0",
+			Type:     "Stratum",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+	var wg sync.WaitGroup
+	defer tearDown(mgr, &wg)
+
+	opStateCh := make(chan events.OperationalStateEvent)
+	defer close(opStateCh)
+	go mgr.Dispatcher.ListenOperationalState(opStateCh)
+
+	path, err := utils.ParseGNMIElements([]string{"cont1b-state", "cont2c", "leaf3c"})
+	assert.NilError(t, err, "Unexpected error doing parsing")
+	path.Target = "Device1"
+	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	request.GetSubscribe().UpdatesOnly = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	responsesChan := make(chan *gnmi.SubscribeResponse)
+	received := false
+	serverFake := gNMISubscribeServerCancelFake{
+		gNMISubscribeServerSampleFake: gNMISubscribeServerSampleFake{
+			gNMISubscribeServerFake: gNMISubscribeServerFake{
+				Request:   request,
+				Responses: responsesChan,
+			},
+			ctx: ctx,
+		},
+		received: &received,
+	}
+	subscribeErr := make(chan error, 1)
+	go func() {
+		subscribeErr <- server.Subscribe(serverFake)
+	}()
+	assertSyncResponse(responsesChan, t)
+	assert.Equal(t, len(mgr.Dispatcher.GetListeners()), 1)
+
+	// The client stops reading while the events keep coming
+	stopEvents := make(chan struct{})
+	eventsDone := streamOpStateEvents(opStateCh, stopEvents)
+
+	select {
+	case err := <-subscribeErr:
+		assert.Equal(t, status.Code(err), codes.ResourceExhausted, "Unexpected error %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe did not return when the client did not keep up")
+	}
+	// The listener stops on the error, before the stream itself has ended
+	for i := 0; len(mgr.Dispatcher.GetListeners()) > 0; i++ {
+		if i == 100 {
+			t.Fatal("Subscription was not unregistered when it was disconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
 
-	err = server.Subscribe(serverFake)
-	assert.ErrorContains(t, err, "is already registered")
+	close(stopEvents)
+	<-eventsDone
+	select {
+	case opStateCh <- events.NewOperationalStateEvent("Device1", "/cont1b-state/cont2c/leaf3c",
+		devicechange.NewTypedValueUint64(0), events.EventItemUpdated):
+	case <-time.After(time.Second):
+		t.Fatal("Dispatcher is blocked on the subscription that was disconnected")
+	}
+
+	// The stream ends, and the response that was being sent when the queue overflowed is let go
+	cancel()
+	select {
+	case <-responsesChan:
+	case <-time.After(subscribeDelay):
+	}
 }
 
 func Test_Poll(t *testing.T) {
@@ -452,10 +708,11 @@ func Test_SubscribeLeafStreamDelete(t *testing.T) {
 	path2Stream := "cont2a"
 	path3Stream := "leaf2a"
 
+	//Expecting the initial value and a sync response
+	assertUpdateResponse(t, responsesChan, device1, path1Stream, path2Stream, path3Stream, 13, true)
+	assertSyncResponse(responsesChan, t)
 	//Expecting one delete response
 	assertDeleteResponse(t, responsesChan, device1, path1Stream, path2Stream, path3Stream)
-	// and one sync response
-	assertSyncResponse(responsesChan, t)
 
 }
 
@@ -526,7 +783,10 @@ func Test_SubscribeLeafStreamWithDeviceLoaded(t *testing.T) {
 	//	}()
 	//}()
 	//Sending set request
+	var wg sync.WaitGroup
+	wg.Add(1)
 	go func() {
+		defer wg.Done()
 		_, err = server.Set(context.Background(), setRequest)
 		assert.NilError(t, err, "Unexpected error doing Set")
 	}()
@@ -537,11 +797,11 @@ func Test_SubscribeLeafStreamWithDeviceLoaded(t *testing.T) {
 	path3Stream := "leaf4a"
 	valueReply := uint(14)
 
+	//Expecting only a sync response first, as there is no initial value
+	assertSyncResponse(responsesChan, t)
 	//Expecting 1 Update response
 	assertUpdateResponse(t, responsesChan, device1, path1Stream, path2Stream, path3Stream, valueReply, false)
-	//And one sync response
-	assertSyncResponse(responsesChan, t)
-	var wg sync.WaitGroup
+	// Wait for the Set to complete before the mocks go
 	tearDown(mgr, &wg)
 }
 
@@ -632,3 +892,201 @@ func assertDeleteResponse(t *testing.T, responsesChan chan *gnmi.SubscribeRespon
 		t.FailNow()
 	}
 }
+
+type gNMISubscribeServerSampleFake struct {
+	gNMISubscribeServerFake
+	ctx context.Context
+}
+
+func (x gNMISubscribeServerSampleFake) Context() context.Context {
+	return x.ctx
+}
+
+func buildSampleRequest(path *gnmi.Path, sampleInterval time.Duration, suppressRedundant bool,
+	heartbeatInterval time.Duration) *gnmi.SubscribeRequest {
+	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	subscription := request.GetSubscribe().Subscription[0]
+	subscription.Mode = gnmi.SubscriptionMode_SAMPLE
+	subscription.SampleInterval = uint64(sampleInterval)
+	subscription.SuppressRedundant = suppressRedundant
+	subscription.HeartbeatInterval = uint64(heartbeatInterval)
+	return request
+}
+
+func startSampleSubscription(t *testing.T, request *gnmi.SubscribeRequest) (chan *gnmi.SubscribeResponse, context.CancelFunc) {
+	server, mgr, mocks := setUp(t)
+	setUpChangesMock(mocks)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Version:  "1.0.0",
+			Type:     "Stratum",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	responsesChan := make(chan *gnmi.SubscribeResponse, 10)
+	serverFake := gNMISubscribeServerSampleFake{
+		gNMISubscribeServerFake: gNMISubscribeServerFake{
+			Request:   request,
+			Responses: responsesChan,
+			Signal:    make(chan struct{}),
+		},
+		ctx: ctx,
+	}
+	go func() {
+		_ = server.Subscribe(serverFake)
+	}()
+	serverFake.Signal <- struct{}{}
+	return responsesChan, func() {
+		cancel()
+		var wg sync.WaitGroup
+		tearDown(mgr, &wg)
+	}
+}
+
+// Test_SubscribeSample tests that a SAMPLE subscription sends the value on every interval
+func Test_SubscribeSample(t *testing.T) {
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	assert.NilError(t, err, "Unexpected error doing parsing")
+	path.Target = "Device1"
+
+	responsesChan, stop := startSampleSubscription(t, buildSampleRequest(path, 20*time.Millisecond, false, 0))
+	defer stop()
+
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf2a", 13, true)
+	assertSyncResponse(responsesChan, t)
+	for i := 0; i < 2; i++ {
+		assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf2a", 13, true)
+	}
+}
+
+// Test_SubscribeSampleSuppressRedundant tests that a SAMPLE subscription suppressing redundant samples
+// sends an unchanged value only on the heartbeat interval
+func Test_SubscribeSampleSuppressRedundant(t *testing.T) {
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	assert.NilError(t, err, "Unexpected error doing parsing")
+	path.Target = "Device1"
+
+	responsesChan, stop := startSampleSubscription(t, buildSampleRequest(path, 20*time.Millisecond, true, 300*time.Millisecond))
+	defer stop()
+
+	start := time.Now()
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf2a", 13, true)
+	assertSyncResponse(responsesChan, t)
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf2a", 13, true)
+	assert.Assert(t, time.Since(start) >= 300*time.Millisecond, "Unchanged value sent before the heartbeat interval")
+}
+
+// Test_SubscribeStreamInitialValues tests that a STREAM subscription first sends the current values and
+// a sync response, or only the sync response when updates only are requested
+func Test_SubscribeStreamInitialValues(t *testing.T) {
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	assert.NilError(t, err, "Unexpected error doing parsing")
+	path.Target = "Device1"
+
+	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	responsesChan, stop := startSampleSubscription(t, request)
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf2a", 13, true)
+	assertSyncResponse(responsesChan, t)
+	stop()
+
+	request = buildRequest(path, gnmi.SubscriptionList_STREAM)
+	request.GetSubscribe().UpdatesOnly = true
+	responsesChan, stop = startSampleSubscription(t, request)
+	defer stop()
+	assertSyncResponse(responsesChan, t)
+	// The change sent by the watch follows without the initial value
+	assertDeleteResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf2a")
+}
+
+// Test_SubscribeWildcardTarget tests that a STREAM subscription to the "*" target covers the devices
+// already known and those added later
+func Test_SubscribeWildcardTarget(t *testing.T) {
+	server, mgr, mocks := setUp(t)
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+	addDevice := make(chan struct{})
+	watchClosed := make(chan struct{})
+	mocks.MockDeviceCache.EXPECT().Watch(gomock.Any(), true).DoAndReturn(
+		func(ch chan<- stream.Event, replay bool) (stream.Context, error) {
+			// The known devices are replayed before returning
+			ch <- stream.Event{Type: stream.None, Object: &cache.Info{DeviceID: "Device1", Version: "1.0.0"}}
+			go func() {
+				<-addDevice
+				ch <- stream.Event{Type: stream.Created, Object: &cache.Info{DeviceID: "Device2", Version: "1.0.0"}}
+			}()
+			return stream.NewContext(func() {
+				close(watchClosed)
+			}), nil
+		})
+	var wg sync.WaitGroup
+	defer tearDown(mgr, &wg)
+
+	opStateCh := make(chan events.OperationalStateEvent)
+	defer close(opStateCh)
+	go mgr.Dispatcher.ListenOperationalState(opStateCh)
+
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf4a"})
+	assert.NilError(t, err, "Unexpected error doing parsing")
+	path.Target = "*"
+	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	request.GetSubscribe().UpdatesOnly = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	responsesChan := make(chan *gnmi.SubscribeResponse, 10)
+	serverFake := gNMISubscribeServerSampleFake{
+		gNMISubscribeServerFake: gNMISubscribeServerFake{
+			Request:   request,
+			Responses: responsesChan,
+			Signal:    make(chan struct{}),
+		},
+		ctx: ctx,
+	}
+	go func() {
+		_ = server.Subscribe(serverFake)
+	}()
+	serverFake.Signal <- struct{}{}
+
+	assertSyncResponse(responsesChan, t)
+	// The change sent by the watch of the known device
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf4a", 14, true)
+
+	// A device added later is watched too
+	close(addDevice)
+	assertUpdateResponse(t, responsesChan, "Device2", "cont1a", "cont2a", "leaf4a", 14, true)
+
+	// As is the state of any device
+	opStateCh <- events.NewOperationalStateEvent("Device3", "/cont1a/cont2a/leaf4a",
+		devicechange.NewTypedValueUint64(15), events.EventItemUpdated)
+	assertUpdateResponse(t, responsesChan, "Device3", "cont1a", "cont2a", "leaf4a", 15, true)
+
+	// The devices are no longer watched once the stream is done
+	cancel()
+	select {
+	case <-watchClosed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the device cache watch to be closed")
+	}
+}
+
+// Test_SubscribeMultiLevelWildcard tests that the paths of a STREAM subscription are matched element by
+// element, with "..." standing for any number of elements
+func Test_SubscribeMultiLevelWildcard(t *testing.T) {
+	path, err := utils.ParseGNMIElements([]string{"...", "leaf4a"})
+	assert.NilError(t, err, "Unexpected error doing parsing")
+	path.Target = "Device1"
+	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	request.GetSubscribe().UpdatesOnly = true
+
+	responsesChan, stop := startSampleSubscription(t, request)
+	defer stop()
+	assertSyncResponse(responsesChan, t)
+	// Of the change sent by the watch only the value of leaf4a is sent - not the removal of leaf2a
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf4a", 14, true)
+	select {
+	case response := <-responsesChan:
+		t.Fatalf("Unexpected response %v", response)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
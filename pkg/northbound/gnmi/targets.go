@@ -0,0 +1,121 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	devicetype "github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-config/pkg/manager"
+	"github.com/onosproject/onos-config/pkg/store/device/cache"
+	streams "github.com/onosproject/onos-config/pkg/store/stream"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"regexp"
+	"sort"
+)
+
+// expandTarget returns the paths of a subscription for each device it covers - a path with a target
+// glob such as "*" stands for each device in the device cache whose ID matches it
+func expandTarget(prefix *gnmi.Path, path *gnmi.Path) []*gnmi.Path {
+	target := subscriptionTarget(prefix, path)
+	if !utils.IsWildcardTarget(target) {
+		return []*gnmi.Path{path}
+	}
+	targetRegexp := utils.MatchWildcardTargetRegexp(target)
+	deviceIDs := make(map[string]bool)
+	for _, info := range manager.GetManager().DeviceCache.GetDevices() {
+		if targetRegexp.MatchString(string(info.DeviceID)) {
+			deviceIDs[string(info.DeviceID)] = true
+		}
+	}
+	targets := make([]string, 0, len(deviceIDs))
+	for deviceID := range deviceIDs {
+		targets = append(targets, deviceID)
+	}
+	sort.Strings(targets)
+
+	paths := make([]*gnmi.Path, 0, len(targets))
+	for _, deviceID := range targets {
+		paths = append(paths, &gnmi.Path{
+			Origin: path.GetOrigin(),
+			Elem:   path.GetElem(),
+			Target: deviceID,
+		})
+	}
+	return paths
+}
+
+// listenForTargetPatterns watches the changes of each device whose ID matches one of the target patterns,
+// both those in the device cache now and those added to it later, until the stream is done
+func listenForTargetPatterns(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manager, targetPatterns []*regexp.Regexp,
+	version devicetype.Version, subs []*utils.PathMatcher, aliases *aliasTable, synced chan struct{}, resChan chan result) {
+	deviceCh := make(chan streams.Event)
+	watched := make(map[devicetype.VersionedID]bool)
+	ready := make(chan streams.Context)
+	go func() {
+		// The cache replays its devices while Watch is called, so they have to be read from the start
+		var ctx streams.Context
+		for {
+			select {
+			case event := <-deviceCh:
+				info, ok := event.Object.(*cache.Info)
+				if !ok || (version != "" && info.Version != version) || !matchTarget(string(info.DeviceID), targetPatterns) {
+					continue
+				}
+				deviceID := devicetype.NewVersionedID(info.DeviceID, info.Version)
+				if watched[deviceID] {
+					continue
+				}
+				watched[deviceID] = true
+				eventCh := make(chan streams.Event)
+				changesCtx, err := mgr.DeviceChangesStore.Watch(deviceID, eventCh)
+				if err != nil {
+					log.Errorf("Cant watch for changes on device %s. error %s", info.DeviceID, err.Error())
+					continue
+				}
+				log.Infof("Subscription extended to device %s", info.DeviceID)
+				go listenForDeviceUpdates(stream, info.DeviceID, eventCh, changesCtx, subs, aliases, synced, resChan)
+			case ctx = <-ready:
+			case <-stream.Context().Done():
+				for ctx == nil {
+					select {
+					case <-deviceCh:
+					case ctx = <-ready:
+					}
+				}
+				// Keep reading until the watch is closed, so that the cache is never blocked on it
+				closeWatch(ctx, deviceCh)
+				return
+			}
+		}
+	}()
+
+	ctx, err := mgr.DeviceCache.Watch(deviceCh, true)
+	if err != nil {
+		log.Errorf("Cant watch for devices. error %s", err.Error())
+		sendResult(resChan, result{success: false, err: err})
+		ctx = streams.NewContext(func() {})
+	}
+	ready <- ctx
+}
+
+// matchTarget returns true if the device ID matches one of the target patterns
+func matchTarget(deviceID string, targetPatterns []*regexp.Regexp) bool {
+	for _, targetRegexp := range targetPatterns {
+		if targetRegexp.MatchString(deviceID) {
+			return true
+		}
+	}
+	return false
+}
@@ -24,6 +24,7 @@ import (
 	"io/ioutil"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/onosproject/onos-config/pkg/utils"
 	"github.com/onosproject/onos-lib-go/pkg/certs"
@@ -115,6 +116,7 @@ func (target *Target) ConnectTarget(ctx context.Context, device topodevice.Devic
 		target.clt.Close()
 	}
 
+	target.id = key
 	target.dest = *dest
 	target.clt = c
 	target.ctx = ctx
@@ -188,7 +190,9 @@ func (target *Target) GetWithString(ctx context.Context, request string) (*gpb.G
 
 // Get can make a get request according to a formatted request
 func (target *Target) Get(ctx context.Context, request *gpb.GetRequest) (*gpb.GetResponse, error) {
+	start := time.Now()
 	response, err := target.Client().Get(ctx, request)
+	observeRequest(target.deviceID(), "get", start, err)
 	if err != nil {
 		return nil, fmt.Errorf("target returned RPC error for Get(%q) : %v", request.String(), err)
 	}
@@ -210,7 +214,9 @@ func (target *Target) SetWithString(ctx context.Context, request string) (*gpb.S
 
 // Set can make a set request according to a formatted request
 func (target *Target) Set(ctx context.Context, request *gpb.SetRequest) (*gpb.SetResponse, error) {
+	start := time.Now()
 	response, err := target.Client().Set(ctx, request)
+	observeRequest(target.deviceID(), "set", start, err)
 	if err != nil {
 		return nil, fmt.Errorf("target returned RPC error for Set(%q) : %v", request.String(), err)
 	}
@@ -258,6 +264,13 @@ func (target *Target) Client() GnmiClient {
 	return target.clt
 }
 
+// deviceID returns the ID of the device the target is connected to
+func (target *Target) deviceID() topodevice.ID {
+	target.mu.RLock()
+	defer target.mu.RUnlock()
+	return target.id
+}
+
 // Close closes the target
 func (target *Target) Close() error {
 	return target.Client().Close()
@@ -21,6 +21,9 @@ import (
 	topodevice "github.com/onosproject/onos-topo/api/device"
 	"github.com/openconfig/gnmi/client"
 	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gotest.tools/assert"
 	"strconv"
 	"testing"
@@ -419,3 +422,39 @@ func Test_SetWithString(t *testing.T) {
 
 	tearDown()
 }
+
+// UnavailableClientImpl is a test client for a device that cannot be reached
+type UnavailableClientImpl struct {
+	TestClientImpl
+}
+
+func (UnavailableClientImpl) Set(ctx context.Context, r *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+	return nil, status.Error(codes.Unavailable, "device unavailable")
+}
+
+func Test_RequestMetrics(t *testing.T) {
+	setUp(t)
+	defer tearDown()
+
+	requests := func(operation string, code codes.Code) float64 {
+		return testutil.ToFloat64(requestsTotal.WithLabelValues("localhost-1", operation, code.String()))
+	}
+	gets, sets, failedSets := requests("get", codes.OK), requests("set", codes.OK), requests("set", codes.Unavailable)
+
+	target, _, ctx := getDevice1Target(t)
+	_, err := target.Get(ctx, &gnmi.GetRequest{})
+	assert.NilError(t, err)
+	_, err = target.Set(ctx, &gnmi.SetRequest{})
+	assert.NilError(t, err)
+
+	GnmiClientFactory = func(ctx context.Context, d client.Destination) (GnmiClient, error) {
+		return UnavailableClientImpl{}, nil
+	}
+	target, _, ctx = getDevice1Target(t)
+	_, err = target.Set(ctx, &gnmi.SetRequest{})
+	assert.ErrorContains(t, err, "device unavailable")
+
+	assert.Equal(t, requests("get", codes.OK), gets+1)
+	assert.Equal(t, requests("set", codes.OK), sets+1)
+	assert.Equal(t, requests("set", codes.Unavailable), failedSets+1)
+}
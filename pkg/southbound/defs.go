@@ -44,6 +44,7 @@ type TargetIf interface {
 
 // Target struct for connecting to gNMI
 type Target struct {
+	id   topodevice.ID
 	dest client.Destination
 	clt  GnmiClient
 	ctx  context.Context
@@ -0,0 +1,49 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package southbound
+
+import (
+	"time"
+
+	topodevice "github.com/onosproject/onos-topo/api/device"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "onos_config",
+		Subsystem: "southbound",
+		Name:      "request_duration_seconds",
+		Help:      "The duration of the gNMI requests to the devices",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"device", "operation"})
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onos_config",
+		Subsystem: "southbound",
+		Name:      "requests_total",
+		Help:      "The number of gNMI requests to the devices, by the gRPC code they returned",
+	}, []string{"device", "operation", "code"})
+)
+
+func init() {
+	prometheus.MustRegister(requestDuration, requestsTotal)
+}
+
+// observeRequest records the duration and the outcome of a request to a device started at the given time
+func observeRequest(deviceID topodevice.ID, operation string, start time.Time, err error) {
+	requestDuration.WithLabelValues(string(deviceID), operation).Observe(time.Since(start).Seconds())
+	requestsTotal.WithLabelValues(string(deviceID), operation, status.Code(err).String()).Inc()
+}
@@ -113,6 +113,13 @@ func (s *Session) updateConnectedDevice() error {
 	return err
 }
 
+// updateReplayFailedDevice marks the device as connected but unavailable, as its configuration
+// could not be replayed
+func (s *Session) updateReplayFailedDevice() error {
+	return s.updateDevice(topodevice.ConnectivityState_REACHABLE, topodevice.ChannelState_CONNECTED,
+		topodevice.ServiceState_UNAVAILABLE)
+}
+
 func (s *Session) updateDisconnectedDevice() error {
 	err := s.updateDevice(topodevice.ConnectivityState_UNREACHABLE, topodevice.ChannelState_DISCONNECTED,
 		topodevice.ServiceState_UNAVAILABLE)
@@ -125,6 +132,20 @@ func (s *Session) updateDeviceState() error {
 	for event := range s.deviceResponseChan {
 		switch event.EventType() {
 		case events.EventTypeDeviceConnected:
+			// The stored configuration is replayed before the device is made available, so that
+			// the device changes that follow apply on top of it. A device the configuration cannot
+			// be replayed to is left unavailable until it connects again, unless the replay could not
+			// even be built - the device has been left as it is then, and is made available
+			if err := backoff.Retry(s.replayConfig, newReplayBackOff()); err != nil {
+				if _, ok := err.(*replayRequestError); ok {
+					log.Errorf("Unable to build the replay of stored configuration to %s, leaving it as it is %v", s.device.ID, err)
+				} else {
+					log.Errorf("Unable to replay stored configuration to %s %v", s.device.ID, err)
+					// TODO: Retry only on write conflicts
+					_ = backoff.Retry(s.updateReplayFailedDevice, backoff.NewExponentialBackOff())
+					continue
+				}
+			}
 			// TODO: Retry only on write conflicts
 			_ = backoff.Retry(s.updateConnectedDevice, backoff.NewExponentialBackOff())
 		case events.EventTypeErrorDeviceConnect:
@@ -15,8 +15,13 @@
 package synchronizer
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/cenkalti/backoff"
+	"github.com/onosproject/onos-config/pkg/events"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
+
 	devicestore "github.com/onosproject/onos-config/pkg/store/device"
 	"github.com/onosproject/onos-config/pkg/store/mastership"
 	"github.com/onosproject/onos-config/pkg/test/mocks"
@@ -138,3 +143,79 @@ func TestUpdateConnectedDevice(t *testing.T) {
 	assert.Equal(t, updatedDevice.Protocols[0].ServiceState, topodevice.ServiceState_AVAILABLE)
 
 }
+
+func TestUpdateDeviceReplayFailed(t *testing.T) {
+	newReplayBackOff = func() backoff.BackOff {
+		return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, replayAttempts-1)
+	}
+	defer func() {
+		newReplayBackOff = func() backoff.BackOff {
+			return backoff.WithMaxRetries(backoff.NewExponentialBackOff(), replayAttempts-1)
+		}
+	}()
+	allMocks := setUp(t)
+
+	device1Connected := &topodevice.Device{
+		ID:         device1,
+		Revision:   1,
+		Address:    "device1:1234",
+		Version:    deviceVersion1,
+		Attributes: map[string]string{mastershipTermKey: "0"},
+	}
+	allMocks.DeviceClient.EXPECT().Get(gomock.Any(), gomock.Any()).Return(&topodevice.GetResponse{Device: device1Connected}, nil).AnyTimes()
+	allMocks.DeviceClient.EXPECT().Update(gomock.Any(), gomock.Any()).Return(&topodevice.UpdateResponse{Device: device1Connected}, nil).AnyTimes()
+	state, err := allMocks.MastershipStore.GetMastership(device1Connected.ID)
+	assert.NilError(t, err)
+
+	session, target := setUpReplay(t, ReplayMerge, nil, storedChanges())
+	session.deviceStore = allMocks.DeviceStore
+	session.mastershipState = state
+	target.EXPECT().Set(gomock.Any(), gomock.Any()).Return(nil, errors.New("device busy")).Times(replayAttempts)
+
+	// The device is left unavailable when the configuration cannot be replayed to it
+	session.deviceResponseChan = make(chan events.DeviceResponse, 1)
+	session.deviceResponseChan <- events.NewDeviceConnectedEvent(events.EventTypeDeviceConnected, device1)
+	close(session.deviceResponseChan)
+	assert.NilError(t, session.updateDeviceState())
+
+	updatedDevice, err := session.deviceStore.Get(device1)
+	assert.NilError(t, err)
+	assert.Equal(t, updatedDevice.Protocols[0].ConnectivityState, topodevice.ConnectivityState_REACHABLE)
+	assert.Equal(t, updatedDevice.Protocols[0].ChannelState, topodevice.ChannelState_CONNECTED)
+	assert.Equal(t, updatedDevice.Protocols[0].ServiceState, topodevice.ServiceState_UNAVAILABLE)
+}
+
+func TestUpdateDeviceReplayNotBuilt(t *testing.T) {
+	allMocks := setUp(t)
+
+	device1Connected := &topodevice.Device{
+		ID:         device1,
+		Revision:   1,
+		Address:    "device1:1234",
+		Version:    deviceVersion1,
+		Attributes: map[string]string{mastershipTermKey: "0"},
+	}
+	allMocks.DeviceClient.EXPECT().Get(gomock.Any(), gomock.Any()).Return(&topodevice.GetResponse{Device: device1Connected}, nil).AnyTimes()
+	allMocks.DeviceClient.EXPECT().Update(gomock.Any(), gomock.Any()).Return(&topodevice.UpdateResponse{Device: device1Connected}, nil).AnyTimes()
+	state, err := allMocks.MastershipStore.GetMastership(device1Connected.ID)
+	assert.NilError(t, err)
+
+	// The configuration cannot be replaced without the model plugin of the device, so the replay is
+	// not tried at all - the mock target fails the test on any call to Set
+	session, _ := setUpReplay(t, ReplayReplace, nil, storedChanges())
+	session.modelRegistry = new(modelregistry.ModelRegistry)
+	session.deviceStore = allMocks.DeviceStore
+	session.mastershipState = state
+
+	// The device, which has been left as it is, is made available
+	session.deviceResponseChan = make(chan events.DeviceResponse, 1)
+	session.deviceResponseChan <- events.NewDeviceConnectedEvent(events.EventTypeDeviceConnected, device1)
+	close(session.deviceResponseChan)
+	assert.NilError(t, session.updateDeviceState())
+
+	updatedDevice, err := session.deviceStore.Get(device1)
+	assert.NilError(t, err)
+	assert.Equal(t, updatedDevice.Protocols[0].ConnectivityState, topodevice.ConnectivityState_REACHABLE)
+	assert.Equal(t, updatedDevice.Protocols[0].ChannelState, topodevice.ChannelState_CONNECTED)
+	assert.Equal(t, updatedDevice.Protocols[0].ServiceState, topodevice.ServiceState_AVAILABLE)
+}
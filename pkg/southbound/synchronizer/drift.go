@@ -0,0 +1,337 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package synchronizer
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	devicechange "github.com/onosproject/onos-config/api/types/change/device"
+	devicetype "github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
+	"github.com/onosproject/onos-config/pkg/modelregistry/jsonvalues"
+	"github.com/onosproject/onos-config/pkg/southbound"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/onosproject/onos-config/pkg/utils/values"
+	topodevice "github.com/onosproject/onos-topo/api/device"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PathDrift is a path whose value on a device differs from the value intended for it
+type PathDrift struct {
+	Path     string
+	Intended *devicechange.TypedValue
+	// Actual is nil when the path is missing from the device
+	Actual *devicechange.TypedValue
+}
+
+// DeviceDrift is the outcome of an audit of the configuration of a device
+type DeviceDrift struct {
+	DeviceID   devicetype.VersionedID
+	DeviceType devicetype.Type
+	Audited    time.Time
+	// Drifts are the stored paths that differ ordered by path - empty when the device has not drifted
+	Drifts []*PathDrift
+	// Unmanaged are the paths on the device that are not stored ordered by path. They are not drift,
+	// as they may have been configured by other tools
+	Unmanaged []*devicechange.PathValue
+}
+
+// DriftMonitor holds the outcome of the latest configuration audit of each device. Its
+// listeners are told of every audit whose outcome differs from the one before it
+type DriftMonitor struct {
+	drifts    map[topodevice.ID]*DeviceDrift
+	sessions  map[topodevice.ID]*Session
+	listeners map[chan<- *DeviceDrift]struct{}
+	mu        sync.RWMutex
+}
+
+// NewDriftMonitor creates a new drift monitor
+func NewDriftMonitor() *DriftMonitor {
+	return &DriftMonitor{
+		drifts:    make(map[topodevice.ID]*DeviceDrift),
+		sessions:  make(map[topodevice.ID]*Session),
+		listeners: make(map[chan<- *DeviceDrift]struct{}),
+	}
+}
+
+// Get returns the outcome of the latest audit of the device, if it has been audited
+func (m *DriftMonitor) Get(id topodevice.ID) (*DeviceDrift, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	drift, ok := m.drifts[id]
+	return drift, ok
+}
+
+// Audit audits the configuration of the device now. The device must be connected to this node
+func (m *DriftMonitor) Audit(id topodevice.ID) (*DeviceDrift, error) {
+	m.mu.RLock()
+	session, ok := m.sessions[id]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, status.Errorf(codes.Unavailable, "device %s is not connected", id)
+	}
+	return session.audit()
+}
+
+// Watch registers the channel for the outcome of the audits that differ from the one before them.
+// An outcome is dropped rather than wait for a channel that is full
+func (m *DriftMonitor) Watch(ch chan<- *DeviceDrift) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners[ch] = struct{}{}
+}
+
+// Unwatch unregisters a channel given to Watch
+func (m *DriftMonitor) Unwatch(ch chan<- *DeviceDrift) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.listeners, ch)
+}
+
+func (m *DriftMonitor) register(session *Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.device.ID] = session
+}
+
+// unregister unregisters the session, unless a newer session has replaced it
+func (m *DriftMonitor) unregister(session *Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sessions[session.device.ID] == session {
+		delete(m.sessions, session.device.ID)
+	}
+}
+
+func (m *DriftMonitor) update(id topodevice.ID, drift *DeviceDrift) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	previous, ok := m.drifts[id]
+	m.drifts[id] = drift
+	if ok && driftsEqual(previous.Drifts, drift.Drifts) && pathValuesEqual(previous.Unmanaged, drift.Unmanaged) {
+		return
+	}
+	if len(drift.Drifts) > 0 {
+		log.Warnf("Configuration of %s has drifted on %d paths", drift.DeviceID, len(drift.Drifts))
+	}
+	for listener := range m.listeners {
+		select {
+		case listener <- drift:
+		default:
+			log.Warnf("Drift listener is full - dropping drift of %s", drift.DeviceID)
+		}
+	}
+}
+
+// audit compares the configuration of the device with the configuration made by its completed
+// changes, and gives the outcome to the drift monitor. The paths of the changes that have not
+// completed are left out - the device may or may not have them yet
+func (s *Session) audit() (*DeviceDrift, error) {
+	s.mu.RLock()
+	sync := s.synchronizer
+	modelName := utils.ToModelName(devicetype.Type(s.device.Type), devicetype.Version(s.device.Version))
+	var rwPaths modelregistry.ReadWritePathMap
+	ok := false
+	if s.modelRegistry != nil {
+		rwPaths, ok = s.modelRegistry.ModelReadWritePaths[modelName]
+	}
+	s.mu.RUnlock()
+	if sync == nil {
+		return nil, status.Errorf(codes.Unavailable, "device %s is not connected", s.device.ID)
+	}
+	if !ok || s.deviceChangeStore == nil {
+		return nil, status.Errorf(codes.FailedPrecondition,
+			"cannot audit %s because Model Plugin %s is not available", s.device.ID, modelName)
+	}
+
+	deviceID := devicetype.NewVersionedID(devicetype.ID(s.device.ID), devicetype.Version(s.device.Version))
+	intended, incomplete, err := s.completedConfig()
+	if err != nil {
+		return nil, err
+	}
+	actual, err := sync.getConfig(sync.Context, s.target, rwPaths)
+	if err != nil {
+		return nil, err
+	}
+	incompletePaths := make([]string, 0)
+	for _, deviceChange := range incomplete {
+		for _, value := range deviceChange.Change.Values {
+			incompletePaths = append(incompletePaths, value.Path)
+		}
+	}
+	drifts, unmanaged := computeDrift(leaveOutPaths(intended, incompletePaths), leaveOutPaths(actual, incompletePaths))
+	drift := &DeviceDrift{
+		DeviceID:   deviceID,
+		DeviceType: devicetype.Type(s.device.Type),
+		Audited:    time.Now(),
+		Drifts:     drifts,
+		Unmanaged:  unmanaged,
+	}
+	if s.driftMonitor != nil {
+		s.driftMonitor.update(s.device.ID, drift)
+	}
+	return drift, nil
+}
+
+// leaveOutPaths returns the values that are not at or under any of the paths
+func leaveOutPaths(pathValues []*devicechange.PathValue, paths []string) []*devicechange.PathValue {
+	if len(paths) == 0 {
+		return pathValues
+	}
+	kept := make([]*devicechange.PathValue, 0, len(pathValues))
+	for _, pathValue := range pathValues {
+		leftOut := false
+		for _, path := range paths {
+			if utils.IsPathUnder(pathValue.Path, path) {
+				leftOut = true
+				break
+			}
+		}
+		if !leftOut {
+			kept = append(kept, pathValue)
+		}
+	}
+	return kept
+}
+
+// auditPeriodically audits the device at every interval until the context is done
+func (s *Session) auditPeriodically(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.audit(); err != nil {
+				log.Warnf("Unable to audit configuration of %s %v", s.device.ID, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// getConfig gets the whole configuration of the device. JSON values are decomposed with the
+// read write paths of the model of the device - anything not in the model is left out
+func (sync Synchronizer) getConfig(ctx context.Context, target southbound.TargetIf,
+	rwPaths modelregistry.ReadWritePathMap) ([]*devicechange.PathValue, error) {
+	request := &gnmi.GetRequest{
+		Path:     []*gnmi.Path{{}},
+		Type:     gnmi.GetRequest_CONFIG,
+		Encoding: sync.encoding,
+	}
+	response, err := target.Get(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	configValues := make([]*devicechange.PathValue, 0)
+	for _, notification := range response.Notification {
+		prefix := ""
+		if notification.Prefix != nil {
+			prefix = utils.StrPathElem(notification.Prefix.Elem)
+		}
+		for _, update := range notification.Update {
+			path := prefix
+			if update.Path != nil {
+				path += utils.StrPathElem(update.Path.Elem)
+			}
+			jsonVal := update.Val.GetJsonVal()
+			if jsonVal == nil {
+				jsonVal = update.Val.GetJsonIetfVal()
+			}
+			if jsonVal != nil {
+				jsonValues, err := jsonvalues.DecomposeJSONWithPaths(path, jsonVal, nil, rwPaths)
+				if err != nil {
+					return nil, err
+				}
+				configValues = append(configValues, jsonValues...)
+				continue
+			}
+			typedValue, err := values.GnmiTypedValueToNativeType(update.Val)
+			if err != nil {
+				return nil, err
+			}
+			configValues = append(configValues, &devicechange.PathValue{Path: path, Value: typedValue})
+		}
+	}
+	return configValues, nil
+}
+
+// computeDrift returns the intended paths whose actual value differs or is missing, and apart from them
+// the actual paths that are not intended, both ordered by path. Values are compared in their string
+// form, as the type given by a device may differ in width or sign from the type that was stored
+func computeDrift(intended, actual []*devicechange.PathValue) ([]*PathDrift, []*devicechange.PathValue) {
+	actualValues := make(map[string]*devicechange.TypedValue)
+	for _, pathValue := range actual {
+		actualValues[pathValue.Path] = pathValue.Value
+	}
+
+	drifts := make([]*PathDrift, 0)
+	for _, pathValue := range intended {
+		actualValue, ok := actualValues[pathValue.Path]
+		if !ok || actualValue.ValueToString() != pathValue.Value.ValueToString() {
+			drifts = append(drifts, &PathDrift{Path: pathValue.Path, Intended: pathValue.Value, Actual: actualValue})
+		}
+		delete(actualValues, pathValue.Path)
+	}
+	unmanaged := make([]*devicechange.PathValue, 0, len(actualValues))
+	for path, actualValue := range actualValues {
+		unmanaged = append(unmanaged, &devicechange.PathValue{Path: path, Value: actualValue})
+	}
+	sort.Slice(drifts, func(i, j int) bool {
+		return drifts[i].Path < drifts[j].Path
+	})
+	sort.Slice(unmanaged, func(i, j int) bool {
+		return unmanaged[i].Path < unmanaged[j].Path
+	})
+	return drifts, unmanaged
+}
+
+func driftsEqual(a, b []*PathDrift) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Path != b[i].Path || valueString(a[i].Intended) != valueString(b[i].Intended) ||
+			valueString(a[i].Actual) != valueString(b[i].Actual) {
+			return false
+		}
+	}
+	return true
+}
+
+func pathValuesEqual(a, b []*devicechange.PathValue) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Path != b[i].Path || valueString(a[i].Value) != valueString(b[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func valueString(value *devicechange.TypedValue) string {
+	if value == nil {
+		return ""
+	}
+	return value.ValueToString()
+}
@@ -0,0 +1,199 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package synchronizer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	changetypes "github.com/onosproject/onos-config/api/types/change"
+	devicechange "github.com/onosproject/onos-config/api/types/change/device"
+	devicetype "github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
+	"github.com/onosproject/onos-config/pkg/test/mocks/southbound"
+	topodevice "github.com/onosproject/onos-topo/api/device"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+)
+
+const runningConfig = `{"cont1a":{"leaf1a":"out of band","cont2a":{"leaf2a":3},` +
+	`"list2a":[{"name":"txout1","tx-power":6}]}}`
+
+// auditedChanges are the completed changes the configuration of the device is audited against
+func auditedChanges() []*devicechange.DeviceChange {
+	return []*devicechange.DeviceChange{
+		newDeviceChange(1, cont1aCont2aLeaf2a, devicechange.NewTypedValueUint64(2),
+			changetypes.Phase_CHANGE, changetypes.State_COMPLETE),
+		newDeviceChange(2, cont1aCont2aLeaf2b, devicechange.NewTypedValueDecimal64(43, 2),
+			changetypes.Phase_CHANGE, changetypes.State_COMPLETE),
+		newDeviceChange(3, cont1aList2aTxout1Txpower, devicechange.NewTypedValueUint64(6),
+			changetypes.Phase_CHANGE, changetypes.State_COMPLETE),
+	}
+}
+
+func setUpAudit(t *testing.T, driftMonitor *DriftMonitor, deviceChanges ...*devicechange.DeviceChange) *Session {
+	ctrl := gomock.NewController(t)
+
+	target := southbound.NewMockTargetIf(ctrl)
+	target.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *gnmi.GetRequest) (*gnmi.GetResponse, error) {
+			assert.Equal(t, request.Type, gnmi.GetRequest_CONFIG)
+			assert.Equal(t, len(request.Path), 1)
+			assert.Equal(t, len(request.Path[0].Elem), 0)
+			return &gnmi.GetResponse{
+				Notification: []*gnmi.Notification{{
+					Update: []*gnmi.Update{{
+						Path: &gnmi.Path{},
+						Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonVal{JsonVal: []byte(runningConfig)}},
+					}},
+				}},
+			}, nil
+		}).AnyTimes()
+
+	modelRegistry := &modelregistry.ModelRegistry{
+		ModelReadWritePaths: map[string]modelregistry.ReadWritePathMap{
+			"TestDevice-1.0.0": {
+				cont1aLeaf1a:                      {ValueType: devicechange.ValueType_STRING},
+				cont1aCont2aLeaf2a:                {ValueType: devicechange.ValueType_UINT},
+				cont1aCont2aLeaf2b:                {ValueType: devicechange.ValueType_DECIMAL},
+				"/cont1a/list2a[name=*]/name":     {ValueType: devicechange.ValueType_STRING},
+				"/cont1a/list2a[name=*]/tx-power": {ValueType: devicechange.ValueType_UINT},
+			},
+		},
+	}
+
+	session := &Session{
+		device: &topodevice.Device{
+			ID:      device1,
+			Version: deviceVersion1,
+			Type:    "TestDevice",
+		},
+		target:            target,
+		modelRegistry:     modelRegistry,
+		deviceChangeStore: newDeviceChangeStore(ctrl, append(auditedChanges(), deviceChanges...)),
+		driftMonitor:      driftMonitor,
+		synchronizer: &Synchronizer{
+			Context:  context.Background(),
+			encoding: gnmi.Encoding_JSON,
+		},
+	}
+	return session
+}
+
+func Test_AuditConfig(t *testing.T) {
+	driftMonitor := NewDriftMonitor()
+	session := setUpAudit(t, driftMonitor)
+	driftMonitor.register(session)
+
+	driftCh := make(chan *DeviceDrift, 10)
+	driftMonitor.Watch(driftCh)
+	defer driftMonitor.Unwatch(driftCh)
+
+	drift, err := driftMonitor.Audit(device1)
+	assert.NilError(t, err)
+	assert.Equal(t, drift.DeviceID, devicetype.NewVersionedID(device1, deviceVersion1))
+	assert.Equal(t, len(drift.Drifts), 2)
+
+	assert.Equal(t, drift.Drifts[0].Path, cont1aCont2aLeaf2a)
+	assert.Equal(t, drift.Drifts[0].Intended.ValueToString(), "2")
+	assert.Equal(t, drift.Drifts[0].Actual.ValueToString(), "3")
+
+	// Missing from the device
+	assert.Equal(t, drift.Drifts[1].Path, cont1aCont2aLeaf2b)
+	assert.Assert(t, drift.Drifts[1].Actual == nil)
+
+	// Not intended for the device, which is not drift
+	assert.Equal(t, len(drift.Unmanaged), 1)
+	assert.Equal(t, drift.Unmanaged[0].Path, cont1aLeaf1a)
+	assert.Equal(t, drift.Unmanaged[0].Value.ValueToString(), "out of band")
+
+	latest, ok := driftMonitor.Get(device1)
+	assert.Assert(t, ok)
+	assert.Equal(t, latest, drift)
+	assert.Equal(t, <-driftCh, drift)
+
+	// The same drift again is not an event
+	_, err = driftMonitor.Audit(device1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(driftCh), 0)
+
+	driftMonitor.unregister(session)
+	_, err = driftMonitor.Audit(device1)
+	assert.Equal(t, status.Code(err), codes.Unavailable)
+}
+
+// Test_AuditConfigIncompleteChanges tests that the paths of the changes that have not completed are not audited
+func Test_AuditConfigIncompleteChanges(t *testing.T) {
+	session := setUpAudit(t, nil,
+		// Waiting to set the value the device has already
+		newDeviceChange(4, cont1aCont2aLeaf2a, devicechange.NewTypedValueUint64(3),
+			changetypes.Phase_CHANGE, changetypes.State_PENDING),
+		// Waiting to remove the list
+		newDeviceChange(5, "/cont1a/list2a", nil, changetypes.Phase_CHANGE, changetypes.State_PENDING))
+
+	drift, err := session.audit()
+	assert.NilError(t, err)
+	assert.Equal(t, len(drift.Drifts), 1)
+	assert.Equal(t, drift.Drifts[0].Path, cont1aCont2aLeaf2b)
+	assert.Equal(t, len(drift.Unmanaged), 1)
+	assert.Equal(t, drift.Unmanaged[0].Path, cont1aLeaf1a)
+}
+
+func Test_AuditConfigNoModel(t *testing.T) {
+	session := setUpAudit(t, nil)
+	session.modelRegistry = new(modelregistry.ModelRegistry)
+	_, err := session.audit()
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition)
+
+	session.modelRegistry = nil
+	_, err = session.audit()
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition)
+
+	session.synchronizer = nil
+	_, err = session.audit()
+	assert.Equal(t, status.Code(err), codes.Unavailable)
+}
+
+func Test_ComputeDrift(t *testing.T) {
+	intended := []*devicechange.PathValue{
+		{Path: "/a/b", Value: devicechange.NewTypedValueUint64(10)},
+		{Path: "/a/c", Value: devicechange.NewTypedValueString("x")},
+	}
+	// Compared in string form - the device gives a different width
+	actual := []*devicechange.PathValue{
+		{Path: "/a/b", Value: devicechange.NewTypedValueUint64(10)},
+		{Path: "/a/c", Value: devicechange.NewTypedValueString("x")},
+	}
+	actual[0].Value.TypeOpts = []int32{32}
+	drifts, unmanaged := computeDrift(intended, actual)
+	assert.Equal(t, len(drifts), 0)
+	assert.Equal(t, len(unmanaged), 0)
+
+	drifts, unmanaged = computeDrift(intended, actual[:1])
+	assert.Equal(t, len(drifts), 1)
+	assert.Equal(t, drifts[0].Path, "/a/c")
+	assert.Assert(t, drifts[0].Actual == nil)
+	assert.Equal(t, len(unmanaged), 0)
+
+	// Paths that are not intended are given apart from the drift
+	drifts, unmanaged = computeDrift(intended[:1], actual)
+	assert.Equal(t, len(drifts), 0)
+	assert.Equal(t, len(unmanaged), 1)
+	assert.Equal(t, unmanaged[0].Path, "/a/c")
+	assert.Equal(t, unmanaged[0].Value.ValueToString(), "x")
+}
@@ -0,0 +1,205 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package synchronizer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cenkalti/backoff"
+	changetypes "github.com/onosproject/onos-config/api/types/change"
+	devicechange "github.com/onosproject/onos-config/api/types/change/device"
+	devicetype "github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
+	"github.com/onosproject/onos-config/pkg/store"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/onosproject/onos-config/pkg/utils/values"
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// ReplayMode is how the configuration stored for a device is given to it when it (re)connects
+type ReplayMode string
+
+const (
+	// ReplayMerge sets every stored path on the device, leaving its other configuration as it is
+	ReplayMerge ReplayMode = "merge"
+	// ReplayReplace replaces the whole configuration of the device with the stored configuration.
+	// Anything on the device that onos-config does not hold is removed - including configuration
+	// that is not in the model of the device, such as its management access
+	ReplayReplace ReplayMode = "replace"
+	// ReplayNone leaves the configuration of the device as it is
+	ReplayNone ReplayMode = "none"
+)
+
+// replayAttempts is the number of times the replay to a device is tried before the device is
+// left unavailable
+const replayAttempts = 5
+
+// newReplayBackOff returns the backoff between the attempts to replay the configuration to a device
+var newReplayBackOff = func() backoff.BackOff {
+	return backoff.WithMaxRetries(backoff.NewExponentialBackOff(), replayAttempts-1)
+}
+
+// replayRequestError is an error building the request that replays the configuration to a device, such
+// as a configuration the model plugin of the device cannot encode. It is not retried, as it would fail
+// the same way again, and it does not leave the device unavailable, as nothing has been set on it
+type replayRequestError struct {
+	err error
+}
+
+func (e *replayRequestError) Error() string {
+	return e.err.Error()
+}
+
+// ParseReplayMode returns the replay mode of the given name - none if the name is empty
+func ParseReplayMode(name string) (ReplayMode, error) {
+	switch ReplayMode(name) {
+	case "", ReplayNone:
+		return ReplayNone, nil
+	case ReplayMerge, ReplayReplace:
+		return ReplayMode(name), nil
+	default:
+		return "", fmt.Errorf("unknown config replay mode %s - expected merge, replace or none", name)
+	}
+}
+
+// replayConfig sets the configuration made by the completed changes of the device (its last
+// snapshot plus the changes since) on the device. The changes that have not completed are
+// left to be applied by the device change controller. A device with no stored configuration is
+// left as it is, in any mode
+func (s *Session) replayConfig() error {
+	if s.replayMode == ReplayNone || s.deviceChangeStore == nil {
+		return nil
+	}
+	deviceID := devicetype.NewVersionedID(devicetype.ID(s.device.ID), devicetype.Version(s.device.Version))
+	configValues, _, err := s.completedConfig()
+	if err != nil {
+		return err
+	}
+	if len(configValues) == 0 {
+		log.Infof("No stored configuration to replay to %s", deviceID)
+		return nil
+	}
+
+	var setRequest *gnmi.SetRequest
+	if s.replayMode == ReplayReplace {
+		setRequest, err = s.replaceRequest(configValues)
+	} else {
+		setRequest, err = values.PathValuesToGnmiChange(configValues)
+	}
+	if err != nil {
+		return backoff.Permanent(&replayRequestError{err: err})
+	}
+	log.Infof("Replaying %d paths of stored configuration to %s (%s)", len(configValues), deviceID, s.replayMode)
+	_, err = s.target.Set(*s.target.Context(), setRequest)
+	return err
+}
+
+// replaceRequest builds a request replacing the root of the device with the tree of the configuration,
+// in the JSON_IETF encoding. The names of the tree are qualified with the schema of the model plugin of
+// the device, which must be loaded
+func (s *Session) replaceRequest(configValues []*devicechange.PathValue) (*gnmi.SetRequest, error) {
+	modelName := utils.ToModelName(devicetype.Type(s.device.Type), devicetype.Version(s.device.Version))
+	var modelPlugin modelregistry.ModelPlugin
+	if s.modelRegistry != nil {
+		modelPlugin = s.modelRegistry.ModelPlugins[modelName]
+	}
+	if modelPlugin == nil {
+		return nil, fmt.Errorf("cannot replace configuration of %s because Model Plugin %s is not available",
+			s.device.ID, modelName)
+	}
+	schema, err := modelPlugin.Schema()
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC 7951 gives decimal64 values as strings
+	tree, err := store.BuildTree(configValues, true)
+	if err != nil {
+		return nil, err
+	}
+	tree, err = modelregistry.QualifyJSONIETF(schema["Device"], tree)
+	if err != nil {
+		return nil, err
+	}
+	return &gnmi.SetRequest{
+		Replace: []*gnmi.Update{{
+			Path: &gnmi.Path{},
+			Val:  &gnmi.TypedValue{Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: tree}},
+		}},
+	}, nil
+}
+
+// completedConfig returns the configuration made by the changes of the device that have completed,
+// sorted by path, along with the changes that have not. It is the last snapshot of the device with
+// the completed changes since applied on top of it
+func (s *Session) completedConfig() ([]*devicechange.PathValue, []*devicechange.DeviceChange, error) {
+	deviceID := devicetype.NewVersionedID(devicetype.ID(s.device.ID), devicetype.Version(s.device.Version))
+	config := make(map[string]*devicechange.TypedValue)
+	var snapshotIndex devicechange.Index
+	if s.deviceSnapshotStore != nil {
+		snapshot, err := s.deviceSnapshotStore.Load(deviceID)
+		if err != nil {
+			return nil, nil, err
+		} else if snapshot != nil {
+			for _, value := range snapshot.Values {
+				config[value.Path] = value.Value
+			}
+			snapshotIndex = snapshot.ChangeIndex
+		}
+	}
+
+	changeCh := make(chan *devicechange.DeviceChange)
+	ctx, err := s.deviceChangeStore.List(deviceID, changeCh)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer ctx.Close()
+
+	incomplete := make([]*devicechange.DeviceChange, 0)
+	for deviceChange := range changeCh {
+		if deviceChange.Index <= snapshotIndex {
+			continue
+		}
+		if deviceChange.Status.State != changetypes.State_COMPLETE {
+			incomplete = append(incomplete, deviceChange)
+			continue
+		}
+		// A change that has been rolled back is no longer part of the configuration
+		if deviceChange.Status.Phase != changetypes.Phase_CHANGE {
+			continue
+		}
+		for _, value := range deviceChange.Change.Values {
+			if value.Removed {
+				for path := range config {
+					if utils.IsPathUnder(path, value.Path) {
+						delete(config, path)
+					}
+				}
+			} else {
+				config[value.Path] = value.Value
+			}
+		}
+	}
+
+	configValues := make([]*devicechange.PathValue, 0, len(config))
+	for path, value := range config {
+		configValues = append(configValues, &devicechange.PathValue{Path: path, Value: value})
+	}
+	sort.Slice(configValues, func(i, j int) bool {
+		return configValues[i].Path < configValues[j].Path
+	})
+	return configValues, incomplete, nil
+}
@@ -0,0 +1,313 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package synchronizer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cenkalti/backoff"
+	"github.com/golang/mock/gomock"
+	ds1 "github.com/onosproject/config-models/modelplugin/devicesim-1.0.0/devicesim_1_0_0"
+	td1 "github.com/onosproject/config-models/modelplugin/testdevice-1.0.0/testdevice_1_0_0"
+	changetypes "github.com/onosproject/onos-config/api/types/change"
+	devicechange "github.com/onosproject/onos-config/api/types/change/device"
+	devicetype "github.com/onosproject/onos-config/api/types/device"
+	devicesnapshot "github.com/onosproject/onos-config/api/types/snapshot/device"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
+	"github.com/onosproject/onos-config/pkg/store/change/device"
+	"github.com/onosproject/onos-config/pkg/store/stream"
+	"github.com/onosproject/onos-config/pkg/test/mocks/southbound"
+	storemock "github.com/onosproject/onos-config/pkg/test/mocks/store"
+	topodevice "github.com/onosproject/onos-topo/api/device"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+	"gotest.tools/assert"
+)
+
+// replayModelPlugin is a model plugin with the schema of the test device
+type replayModelPlugin struct {
+	modelregistry.ModelPlugin
+}
+
+func (m replayModelPlugin) Schema() (map[string]*yang.Entry, error) {
+	return td1.UnzipSchema()
+}
+
+// deviceSimModelPlugin is a model plugin with the schema of the device simulator, made of several modules
+type deviceSimModelPlugin struct {
+	modelregistry.ModelPlugin
+}
+
+func (m deviceSimModelPlugin) Schema() (map[string]*yang.Entry, error) {
+	return ds1.UnzipSchema()
+}
+
+func newDeviceChange(index devicechange.Index, path string, value *devicechange.TypedValue,
+	phase changetypes.Phase, state changetypes.State) *devicechange.DeviceChange {
+	return &devicechange.DeviceChange{
+		Index: index,
+		Change: &devicechange.Change{
+			DeviceID:      device1,
+			DeviceVersion: deviceVersion1,
+			Values:        []*devicechange.ChangeValue{{Path: path, Value: value, Removed: value == nil}},
+		},
+		Status: changetypes.Status{Phase: phase, State: state},
+	}
+}
+
+// newDeviceChangeStore returns a device change store holding the changes of the device
+func newDeviceChangeStore(ctrl *gomock.Controller, deviceChanges []*devicechange.DeviceChange) device.Store {
+	deviceChangeStore := storemock.NewMockDeviceChangesStore(ctrl)
+	deviceChangeStore.EXPECT().List(devicetype.NewVersionedID(device1, deviceVersion1), gomock.Any()).DoAndReturn(
+		func(id devicetype.VersionedID, ch chan<- *devicechange.DeviceChange) (stream.Context, error) {
+			go func() {
+				for _, deviceChange := range deviceChanges {
+					ch <- deviceChange
+				}
+				close(ch)
+			}()
+			return stream.NewContext(func() {}), nil
+		}).AnyTimes()
+	return deviceChangeStore
+}
+
+func setUpReplay(t *testing.T, replayMode ReplayMode, snapshot *devicesnapshot.Snapshot,
+	deviceChanges []*devicechange.DeviceChange) (*Session, *southbound.MockTargetIf) {
+	ctrl := gomock.NewController(t)
+	deviceSnapshotStore := storemock.NewMockDeviceSnapshotStore(ctrl)
+	deviceSnapshotStore.EXPECT().Load(devicetype.NewVersionedID(device1, deviceVersion1)).Return(snapshot, nil).AnyTimes()
+	target := southbound.NewMockTargetIf(ctrl)
+	ctx := context.Background()
+	target.EXPECT().Context().Return(&ctx).AnyTimes()
+
+	session := &Session{
+		device: &topodevice.Device{
+			ID:      device1,
+			Version: deviceVersion1,
+			Type:    "TestDevice",
+		},
+		target:              target,
+		deviceChangeStore:   newDeviceChangeStore(ctrl, deviceChanges),
+		deviceSnapshotStore: deviceSnapshotStore,
+		modelRegistry: &modelregistry.ModelRegistry{
+			ModelPlugins: map[string]modelregistry.ModelPlugin{"TestDevice-1.0.0": replayModelPlugin{}},
+		},
+		replayMode: replayMode,
+	}
+	return session, target
+}
+
+// storedChanges are the changes stored for the device - only those that completed are replayed
+func storedChanges() []*devicechange.DeviceChange {
+	return []*devicechange.DeviceChange{
+		newDeviceChange(1, cont1aCont2aLeaf2a, devicechange.NewTypedValueUint64(2),
+			changetypes.Phase_CHANGE, changetypes.State_COMPLETE),
+		newDeviceChange(2, cont1aList2aTxout1Txpower, devicechange.NewTypedValueUint64(6),
+			changetypes.Phase_CHANGE, changetypes.State_COMPLETE),
+		newDeviceChange(3, cont1aCont2aLeaf2a, devicechange.NewTypedValueUint64(3),
+			changetypes.Phase_CHANGE, changetypes.State_PENDING),
+	}
+}
+
+func Test_ReplayConfigMerge(t *testing.T) {
+	session, target := setUpReplay(t, ReplayMerge, nil, storedChanges())
+
+	target.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+			assert.Equal(t, len(request.Delete), 0)
+			assert.Equal(t, len(request.Replace), 0)
+			assert.Equal(t, len(request.Update), 2)
+			assert.Equal(t, request.Update[0].Path.Elem[2].Name, "leaf2a")
+			assert.Equal(t, request.Update[0].Val.GetUintVal(), uint64(2))
+			assert.Equal(t, request.Update[1].Path.Elem[1].Key["name"], "txout1")
+			assert.Equal(t, request.Update[1].Val.GetUintVal(), uint64(6))
+			return &gnmi.SetResponse{}, nil
+		})
+
+	assert.NilError(t, session.replayConfig())
+}
+
+func Test_ReplayConfigReplace(t *testing.T) {
+	session, target := setUpReplay(t, ReplayReplace, nil, storedChanges())
+
+	target.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+			// The root is replaced with the whole tree
+			assert.Equal(t, len(request.Delete), 0)
+			assert.Equal(t, len(request.Update), 0)
+			assert.Equal(t, len(request.Replace), 1)
+			assert.Equal(t, len(request.Replace[0].Path.Elem), 0)
+			assert.Equal(t, string(request.Replace[0].Val.GetJsonIetfVal()), `{
+  "test1:cont1a": {
+    "cont2a": {
+      "leaf2a": 2
+    },
+    "list2a": [
+      {
+        "name": "txout1",
+        "tx-power": 6
+      }
+    ]
+  }
+}`)
+			return &gnmi.SetResponse{}, nil
+		})
+
+	assert.NilError(t, session.replayConfig())
+
+	// The tree cannot be built without the schema of the device
+	session.modelRegistry = new(modelregistry.ModelRegistry)
+	assert.ErrorContains(t, session.replayConfig(), "Model Plugin TestDevice-1.0.0 is not available")
+}
+
+func Test_ReplayConfigReplaceDeviceSim(t *testing.T) {
+	deviceChanges := []*devicechange.DeviceChange{
+		newDeviceChange(1, "/system/config/hostname", devicechange.NewTypedValueString("sim"),
+			changetypes.Phase_CHANGE, changetypes.State_COMPLETE),
+		newDeviceChange(2, "/system/clock/config/timezone-name", devicechange.NewTypedValueString("Europe/Dublin"),
+			changetypes.Phase_CHANGE, changetypes.State_COMPLETE),
+		newDeviceChange(3, "/interfaces/interface[name=eth1]/config/mtu", devicechange.NewTypedValueUint64(1500),
+			changetypes.Phase_CHANGE, changetypes.State_COMPLETE),
+	}
+	session, target := setUpReplay(t, ReplayReplace, nil, deviceChanges)
+	session.device.Type = "Devicesim"
+	session.modelRegistry = &modelregistry.ModelRegistry{
+		ModelPlugins: map[string]modelregistry.ModelPlugin{"Devicesim-1.0.0": deviceSimModelPlugin{}},
+	}
+
+	target.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+			// The top level members are qualified with the module each of them comes from
+			assert.Equal(t, len(request.Replace), 1)
+			assert.Equal(t, string(request.Replace[0].Val.GetJsonIetfVal()), `{
+  "openconfig-interfaces:interfaces": {
+    "interface": [
+      {
+        "config": {
+          "mtu": 1500
+        },
+        "name": "eth1"
+      }
+    ]
+  },
+  "openconfig-system:system": {
+    "clock": {
+      "config": {
+        "timezone-name": "Europe/Dublin"
+      }
+    },
+    "config": {
+      "hostname": "sim"
+    }
+  }
+}`)
+			return &gnmi.SetResponse{}, nil
+		})
+
+	assert.NilError(t, session.replayConfig())
+}
+
+func Test_ReplayConfigFromSnapshot(t *testing.T) {
+	snapshot := &devicesnapshot.Snapshot{
+		DeviceID:      device1,
+		DeviceVersion: deviceVersion1,
+		ChangeIndex:   1,
+		Values: []*devicechange.PathValue{
+			{Path: cont1aCont2aLeaf2a, Value: devicechange.NewTypedValueUint64(1)},
+		},
+	}
+	deviceChanges := []*devicechange.DeviceChange{
+		// Already in the snapshot
+		newDeviceChange(1, cont1aCont2aLeaf2a, devicechange.NewTypedValueUint64(1),
+			changetypes.Phase_CHANGE, changetypes.State_COMPLETE),
+		// Rolled back
+		newDeviceChange(2, cont1aCont2aLeaf2a, devicechange.NewTypedValueUint64(5),
+			changetypes.Phase_ROLLBACK, changetypes.State_COMPLETE),
+		newDeviceChange(3, cont1aList2aTxout1Txpower, devicechange.NewTypedValueUint64(6),
+			changetypes.Phase_CHANGE, changetypes.State_COMPLETE),
+		// Failed
+		newDeviceChange(4, cont1aCont2aLeaf2a, nil, changetypes.Phase_CHANGE, changetypes.State_FAILED),
+	}
+	session, _ := setUpReplay(t, ReplayMerge, snapshot, deviceChanges)
+
+	configValues, incomplete, err := session.completedConfig()
+	assert.NilError(t, err)
+	assert.Equal(t, len(configValues), 2)
+	assert.Equal(t, configValues[0].Path, cont1aCont2aLeaf2a)
+	assert.Equal(t, configValues[0].Value.ValueToString(), "1")
+	assert.Equal(t, configValues[1].Path, cont1aList2aTxout1Txpower)
+	assert.Equal(t, len(incomplete), 1)
+	assert.Equal(t, incomplete[0].Index, devicechange.Index(4))
+}
+
+func Test_ReplayConfigNothingToReplay(t *testing.T) {
+	// The mock target fails the test on any call to Set
+	session, _ := setUpReplay(t, ReplayReplace, nil, []*devicechange.DeviceChange{})
+	assert.NilError(t, session.replayConfig())
+
+	session, _ = setUpReplay(t, ReplayNone, nil, storedChanges())
+	assert.NilError(t, session.replayConfig())
+}
+
+func Test_ReplayConfigRetried(t *testing.T) {
+	newReplayBackOff = func() backoff.BackOff {
+		return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, replayAttempts-1)
+	}
+	defer func() {
+		newReplayBackOff = func() backoff.BackOff {
+			return backoff.WithMaxRetries(backoff.NewExponentialBackOff(), replayAttempts-1)
+		}
+	}()
+	session, target := setUpReplay(t, ReplayMerge, nil, storedChanges())
+
+	// The replay succeeds on the last attempt
+	calls := 0
+	target.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+			calls++
+			if calls < replayAttempts {
+				return nil, errors.New("device busy")
+			}
+			return &gnmi.SetResponse{}, nil
+		}).Times(replayAttempts)
+	assert.NilError(t, backoff.Retry(session.replayConfig, newReplayBackOff()))
+
+	// And is given up after that
+	target.EXPECT().Set(gomock.Any(), gomock.Any()).Return(nil, errors.New("device busy")).Times(replayAttempts)
+	assert.ErrorContains(t, backoff.Retry(session.replayConfig, newReplayBackOff()), "device busy")
+}
+
+func Test_ParseReplayMode(t *testing.T) {
+	mode, err := ParseReplayMode("")
+	assert.NilError(t, err)
+	assert.Equal(t, mode, ReplayNone)
+
+	mode, err = ParseReplayMode("merge")
+	assert.NilError(t, err)
+	assert.Equal(t, mode, ReplayMerge)
+
+	mode, err = ParseReplayMode("replace")
+	assert.NilError(t, err)
+	assert.Equal(t, mode, ReplayReplace)
+
+	mode, err = ParseReplayMode("none")
+	assert.NilError(t, err)
+	assert.Equal(t, mode, ReplayNone)
+
+	_, err = ParseReplayMode("overwrite")
+	assert.ErrorContains(t, err, "unknown config replay mode overwrite")
+}
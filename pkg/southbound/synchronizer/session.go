@@ -37,6 +37,7 @@ import (
 
 	devicetype "github.com/onosproject/onos-config/api/types/device"
 	"github.com/onosproject/onos-config/pkg/store/change/device"
+	devicesnapshotstore "github.com/onosproject/onos-config/pkg/store/snapshot/device"
 	topodevice "github.com/onosproject/onos-topo/api/device"
 )
 
@@ -60,6 +61,11 @@ type Session struct {
 	operationalStateCache     map[topodevice.ID]devicechange.TypedValueMap
 	operationalStateCacheLock *sync.RWMutex
 	deviceChangeStore         device.Store
+	deviceSnapshotStore       devicesnapshotstore.Store
+	replayMode                ReplayMode
+	driftMonitor              *DriftMonitor
+	auditInterval             time.Duration
+	synchronizer              *Synchronizer
 	device                    *topodevice.Device
 	target                    southbound.TargetIf
 	cancel                    context.CancelFunc
@@ -182,7 +188,16 @@ func (s *Session) synchronize() error {
 
 	//spawning two go routines to propagate changes and to get operational state
 	//go sync.syncConfigEventsToDevice(target, respChan)
+	s.mu.Lock()
+	s.synchronizer = sync
+	s.mu.Unlock()
+	if s.driftMonitor != nil {
+		s.driftMonitor.register(s)
+	}
 	s.deviceResponseChan <- events.NewDeviceConnectedEvent(events.EventTypeDeviceConnected, string(s.device.ID))
+	if s.auditInterval > 0 {
+		go s.auditPeriodically(ctx, s.auditInterval)
+	}
 	if sync.getStateMode == modelregistry.GetStateOpState {
 		go sync.syncOperationalStateByPartition(ctx, s.target, s.deviceResponseChan)
 	} else if sync.getStateMode == modelregistry.GetStateExplicitRoPaths ||
@@ -201,7 +216,11 @@ func (s *Session) disconnect() error {
 		s.cancel()
 		s.cancel = nil
 	}
+	s.synchronizer = nil
 	s.mu.Unlock()
+	if s.driftMonitor != nil {
+		s.driftMonitor.unregister(s)
+	}
 	s.operationalStateCacheLock.Lock()
 	delete(s.operationalStateCache, s.device.ID)
 	s.operationalStateCacheLock.Unlock()
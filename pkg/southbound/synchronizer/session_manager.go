@@ -16,6 +16,7 @@ package synchronizer
 
 import (
 	"sync"
+	"time"
 
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
 	"github.com/onosproject/onos-config/pkg/dispatcher"
@@ -25,6 +26,7 @@ import (
 	"github.com/onosproject/onos-config/pkg/store/change/device"
 	devicestore "github.com/onosproject/onos-config/pkg/store/device"
 	"github.com/onosproject/onos-config/pkg/store/mastership"
+	devicesnapshotstore "github.com/onosproject/onos-config/pkg/store/snapshot/device"
 	topodevice "github.com/onosproject/onos-topo/api/device"
 )
 
@@ -41,6 +43,10 @@ type SessionManager struct {
 	newTargetFn               func() southbound.TargetIf
 	operationalStateCacheLock *sync.RWMutex
 	deviceChangeStore         device.Store
+	deviceSnapshotStore       devicesnapshotstore.Store
+	replayMode                ReplayMode
+	driftMonitor              *DriftMonitor
+	auditInterval             time.Duration
 	mastershipStore           mastership.Store
 	mu                        sync.RWMutex
 }
@@ -134,6 +140,34 @@ func WithDeviceChangeStore(deviceChangeStore device.Store) func(*SessionManager)
 	}
 }
 
+// WithDeviceSnapshotStore sets the device snapshot store the configuration replayed to and audited on devices starts from
+func WithDeviceSnapshotStore(deviceSnapshotStore devicesnapshotstore.Store) func(*SessionManager) {
+	return func(sessionManager *SessionManager) {
+		sessionManager.deviceSnapshotStore = deviceSnapshotStore
+	}
+}
+
+// WithReplayMode sets how the stored configuration is replayed to devices when they connect
+func WithReplayMode(replayMode ReplayMode) func(*SessionManager) {
+	return func(sessionManager *SessionManager) {
+		sessionManager.replayMode = replayMode
+	}
+}
+
+// WithDriftMonitor sets the drift monitor given the outcome of the configuration audits of devices
+func WithDriftMonitor(driftMonitor *DriftMonitor) func(*SessionManager) {
+	return func(sessionManager *SessionManager) {
+		sessionManager.driftMonitor = driftMonitor
+	}
+}
+
+// WithAuditInterval sets how often the configuration of connected devices is audited - never if zero
+func WithAuditInterval(auditInterval time.Duration) func(*SessionManager) {
+	return func(sessionManager *SessionManager) {
+		sessionManager.auditInterval = auditInterval
+	}
+}
+
 // Start starts session manager
 func (sm *SessionManager) Start() error {
 	log.Info("Session manager started")
@@ -260,6 +294,10 @@ func (sm *SessionManager) createSession(device *topodevice.Device) error {
 		operationalStateCache:     sm.operationalStateCache,
 		operationalStateCacheLock: sm.operationalStateCacheLock,
 		deviceChangeStore:         sm.deviceChangeStore,
+		deviceSnapshotStore:       sm.deviceSnapshotStore,
+		replayMode:                sm.replayMode,
+		driftMonitor:              sm.driftMonitor,
+		auditInterval:             sm.auditInterval,
 		device:                    device,
 		target:                    sm.newTargetFn(),
 		deviceStore:               sm.deviceStore,
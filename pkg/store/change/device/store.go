@@ -29,6 +29,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	devicechange "github.com/onosproject/onos-config/api/types/change/device"
 	"github.com/onosproject/onos-config/api/types/device"
+	"github.com/onosproject/onos-config/pkg/store"
 	"github.com/onosproject/onos-config/pkg/store/stream"
 	"github.com/onosproject/onos-lib-go/pkg/atomix"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
@@ -36,9 +37,12 @@ import (
 
 var log = logging.GetLogger("store", "change", "device")
 
+// deviceChangesName is the name the operations on the changes of all devices are measured under
+const deviceChangesName = "device-changes"
+
 // getDeviceChangesName returns the name of the changes map for the given device ID
 func getDeviceChangesName(deviceID device.VersionedID) string {
-	return fmt.Sprintf("device-changes-%s", deviceID)
+	return fmt.Sprintf("%s-%s", deviceChangesName, deviceID)
 }
 
 // NewAtomixStore returns a new persistent Store
@@ -187,7 +191,8 @@ func (s *atomixStore) Get(id devicechange.ID) (*devicechange.DeviceChange, error
 	return decodeChange(entry)
 }
 
-func (s *atomixStore) Create(change *devicechange.DeviceChange) error {
+func (s *atomixStore) Create(change *devicechange.DeviceChange) (err error) {
+	defer store.ObserveOperation(deviceChangesName, "create", time.Now(), &err)
 	if change.Index == 0 {
 		return errors.New("no change index specified")
 	}
@@ -239,7 +244,8 @@ func (s *atomixStore) Create(change *devicechange.DeviceChange) error {
 	return nil
 }
 
-func (s *atomixStore) Update(change *devicechange.DeviceChange) error {
+func (s *atomixStore) Update(change *devicechange.DeviceChange) (err error) {
+	defer store.ObserveOperation(deviceChangesName, "update", time.Now(), &err)
 	if change.ID == "" {
 		return errors.New("no change ID configured")
 	}
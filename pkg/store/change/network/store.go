@@ -27,6 +27,7 @@ import (
 	"github.com/google/uuid"
 	networkchange "github.com/onosproject/onos-config/api/types/change/network"
 	"github.com/onosproject/onos-config/pkg/config"
+	"github.com/onosproject/onos-config/pkg/store"
 	"github.com/onosproject/onos-config/pkg/store/stream"
 	"github.com/onosproject/onos-lib-go/pkg/atomix"
 	"github.com/onosproject/onos-lib-go/pkg/cluster"
@@ -207,7 +208,8 @@ func (s *atomixStore) GetNext(index networkchange.Index) (*networkchange.Network
 	return decodeChange(entry)
 }
 
-func (s *atomixStore) Create(change *networkchange.NetworkChange) error {
+func (s *atomixStore) Create(change *networkchange.NetworkChange) (err error) {
+	defer store.ObserveOperation(changesName, "create", time.Now(), &err)
 	if change.ID == "" {
 		change.ID = newChangeID()
 	}
@@ -235,7 +237,8 @@ func (s *atomixStore) Create(change *networkchange.NetworkChange) error {
 	return nil
 }
 
-func (s *atomixStore) Update(change *networkchange.NetworkChange) error {
+func (s *atomixStore) Update(change *networkchange.NetworkChange) (err error) {
+	defer store.ObserveOperation(changesName, "update", time.Now(), &err)
 	if change.Revision == 0 {
 		return errors.New("not a stored object")
 	}
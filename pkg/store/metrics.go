@@ -0,0 +1,51 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	operationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "onos_config",
+		Subsystem: "store",
+		Name:      "operation_duration_seconds",
+		Help:      "The duration of the operations on the stores",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"store", "operation"})
+	operationErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onos_config",
+		Subsystem: "store",
+		Name:      "operation_errors_total",
+		Help:      "The number of operations on the stores that failed",
+	}, []string{"store", "operation"})
+)
+
+func init() {
+	prometheus.MustRegister(operationDuration, operationErrorsTotal)
+}
+
+// ObserveOperation records the duration of an operation on a store started at the given time, and
+// counts the operation as failed if the error it returned is not nil. It is meant to be deferred
+// with the address of the named error result of the operation
+func ObserveOperation(store string, operation string, start time.Time, err *error) {
+	operationDuration.WithLabelValues(store, operation).Observe(time.Since(start).Seconds())
+	if err != nil && *err != nil {
+		operationErrorsTotal.WithLabelValues(store, operation).Inc()
+	}
+}
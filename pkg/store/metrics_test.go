@@ -0,0 +1,40 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gotest.tools/assert"
+	"testing"
+	"time"
+)
+
+func Test_ObserveOperation(t *testing.T) {
+	failures := testutil.ToFloat64(operationErrorsTotal.WithLabelValues("test-store", "update"))
+
+	operation := func(fail bool) (err error) {
+		defer ObserveOperation("test-store", "update", time.Now(), &err)
+		if fail {
+			return errors.New("update failed")
+		}
+		return nil
+	}
+
+	assert.NilError(t, operation(false))
+	assert.Equal(t, testutil.ToFloat64(operationErrorsTotal.WithLabelValues("test-store", "update")), failures)
+	assert.ErrorContains(t, operation(true), "update failed")
+	assert.Equal(t, testutil.ToFloat64(operationErrorsTotal.WithLabelValues("test-store", "update")), failures+1)
+}
@@ -165,6 +165,15 @@ func SplitPath(path string) []string {
 	return result
 }
 
+// IsPathUnder checks if the path is the root path or any path below it, including
+// any instance of a list when the root is given without keys
+func IsPathUnder(path string, root string) bool {
+	if root == "/" || path == root {
+		return true
+	}
+	return strings.HasPrefix(path, root+"/") || strings.HasPrefix(path, root+"[")
+}
+
 // nextTokenIndex returns the end index of the first token.
 func nextTokenIndex(path string) int {
 	var inBrackets bool
@@ -344,3 +344,13 @@ func Test_StrVal_Any(t *testing.T) {
 	result := StrVal(typedValue)
 	assert.Equal(t, expected, result)
 }
+
+func Test_IsPathUnder(t *testing.T) {
+	assert.Assert(t, IsPathUnder("/cont1a/leaf1a", "/"))
+	assert.Assert(t, IsPathUnder("/cont1a/leaf1a", "/cont1a/leaf1a"))
+	assert.Assert(t, IsPathUnder("/cont1a/leaf1a", "/cont1a"))
+	assert.Assert(t, IsPathUnder("/cont1a/list2a[name=first]/name", "/cont1a/list2a"))
+	assert.Assert(t, IsPathUnder("/cont1a/list2a[name=first]/name", "/cont1a/list2a[name=first]"))
+	assert.Assert(t, !IsPathUnder("/cont1a/list2a[name=second]/name", "/cont1a/list2a[name=first]"))
+	assert.Assert(t, !IsPathUnder("/cont1a/list2ab", "/cont1a/list2a"))
+}
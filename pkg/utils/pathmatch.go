@@ -0,0 +1,138 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"strings"
+
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+const (
+	// wildcardElem matches any single element, or any value of a key
+	wildcardElem = "*"
+	// multiLevelWildcardElem matches any number of elements, none included
+	multiLevelWildcardElem = "..."
+)
+
+// PathMatcher matches paths against a path that may contain wildcards, element by element as set
+// out in the gNMI path conventions. An element named "*" matches any single element, an element
+// named "..." matches any number of elements and a key value of "*" matches any value. Keys left
+// out of an element match all the entries of a list. A path matches if it is the path of the
+// matcher or a path under it
+type PathMatcher struct {
+	elems []*pb.PathElem
+}
+
+// NewPathMatcher creates a matcher for the elements of the path. A nil path matches every path
+func NewPathMatcher(path *pb.Path) *PathMatcher {
+	return &PathMatcher{elems: path.GetElem()}
+}
+
+// ParsePathMatcher creates a matcher for a path given in its string form, e.g. /a/*/c[k=*]/...
+func ParsePathMatcher(path string) (*PathMatcher, error) {
+	gnmiPath, err := ParseGNMIElements(SplitPath(path))
+	if err != nil {
+		return nil, err
+	}
+	return NewPathMatcher(gnmiPath), nil
+}
+
+// Match returns true if the path is matched
+func (m *PathMatcher) Match(path *pb.Path) bool {
+	return matchElems(m.elems, path.GetElem())
+}
+
+// MatchString returns true if the path given in its string form is matched. A path that cannot
+// be parsed is not matched
+func (m *PathMatcher) MatchString(path string) bool {
+	gnmiPath, err := ParseGNMIElements(SplitPath(path))
+	if err != nil {
+		return false
+	}
+	return m.Match(gnmiPath)
+}
+
+// String returns the string form of the path of the matcher
+func (m *PathMatcher) String() string {
+	return StrPathElem(m.elems)
+}
+
+// PathsOverlap returns true if the two paths are the same or one of them is under the other,
+// comparing them element by element. Two elements overlap if they have the same name and their
+// common keys have the same values. A key left out of an element, or a wildcard, overlaps any value
+func PathsOverlap(a *pb.Path, b *pb.Path) bool {
+	aElems, bElems := a.GetElem(), b.GetElem()
+	for i := 0; i < len(aElems) && i < len(bElems); i++ {
+		if !overlapElem(aElems[i], bElems[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func overlapElem(a *pb.PathElem, b *pb.PathElem) bool {
+	if a.GetName() != wildcardElem && b.GetName() != wildcardElem &&
+		localName(a.GetName()) != localName(b.GetName()) {
+		return false
+	}
+	for key, aValue := range a.GetKey() {
+		bValue, ok := b.GetKey()[key]
+		if ok && aValue != bValue && aValue != wildcardElem && bValue != wildcardElem {
+			return false
+		}
+	}
+	return true
+}
+
+func matchElems(pattern []*pb.PathElem, elems []*pb.PathElem) bool {
+	if len(pattern) == 0 {
+		// Everything under the path is matched too
+		return true
+	}
+	if pattern[0].GetName() == multiLevelWildcardElem {
+		for i := 0; i <= len(elems); i++ {
+			if matchElems(pattern[1:], elems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elems) == 0 || !matchElem(pattern[0], elems[0]) {
+		return false
+	}
+	return matchElems(pattern[1:], elems[1:])
+}
+
+func matchElem(pattern *pb.PathElem, elem *pb.PathElem) bool {
+	if pattern.GetName() != wildcardElem && localName(pattern.GetName()) != localName(elem.GetName()) {
+		return false
+	}
+	for key, value := range pattern.GetKey() {
+		elemValue, ok := elem.GetKey()[key]
+		if !ok || (value != wildcardElem && value != elemValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// localName strips the module prefix from the name of an element, as the stored paths leave it out
+func localName(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,106 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	pb "github.com/openconfig/gnmi/proto/gnmi"
+	"gotest.tools/assert"
+	"testing"
+)
+
+func Test_PathMatcher(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"/", "/aa/bb", true},
+		{"/aa/bb", "/aa/bb", true},
+		{"/aa/bb", "/aa/bb/cc", true},
+		{"/aa/bb", "/aa/bbb", false},
+		{"/aa/bb/cc", "/aa/bb", false},
+		{"/aa/*/cc/*/ee", "/aa/bb/cc/dd/ee", true},
+		{"/aa/*/cc/*/ee", "/aa/bb/cc/dd/ee/ff", true},
+		{"/aa/*/cc/*/ee", "/aa/qq/rr/dd/ee", false},
+		{"/aa/*/cc/*/ee", "/aa/bb/cc/dd", false},
+		{"/ww/*/xx[name=*]/yy", "/ww/aa/xx[name=eth1]/yy", true},
+		{"/ww/*/xx[name=*]/yy", "/ww/aa/xx[name1=eth1]/yy", false},
+		{"/ww/xx/yy", "/ww/xx[name=eth1]/yy", true},
+		{"/ww/xx[name=eth1]/yy", "/ww/xx[name=eth1]/yy", true},
+		{"/ww/xx[name=eth1]/yy", "/ww/xx[name=eth2]/yy", false},
+		{"/ww/.../yy/*/bb", "/ww/aa/xx[name=eth1]/yy/aa/bb", true},
+		{"/ww/.../yy/*/bb", "/ww/yy/aa/bb", true},
+		{"/ww/.../yy/*/bb", "/ww/aa/yy/zz", false},
+		{"/.../bb", "/aa/bb/cc", true},
+		{"/.../bb", "/aa/cc", false},
+		// Key values are compared as they are, not as patterns
+		{"/ww/xx[name=eth1/1]/yy", "/ww/xx[name=eth1/1]/yy", true},
+		{"/ww/xx[name=eth1/1]/yy", "/ww/xx[name=eth1]/1/yy", false},
+		{"/ww/xx[name=eth.1]/yy", "/ww/xx[name=ethX1]/yy", false},
+		{`/ww/xx[name=a\]b]/yy`, `/ww/xx[name=a\]b]/yy`, true},
+		{`/ww/xx[name=a\]b]/yy`, `/ww/xx[name=a]/yy`, false},
+	}
+	for _, test := range tests {
+		matcher, err := ParsePathMatcher(test.pattern)
+		assert.NilError(t, err)
+		assert.Equal(t, matcher.MatchString(test.path), test.match, "%s matching %s", test.pattern, test.path)
+	}
+}
+
+func Test_PathMatcherElems(t *testing.T) {
+	matcher := NewPathMatcher(&pb.Path{Elem: []*pb.PathElem{
+		{Name: "openconfig-interfaces:interfaces"},
+		{Name: "interface", Key: map[string]string{"name": "*"}},
+	}})
+	assert.Assert(t, matcher.Match(&pb.Path{Elem: []*pb.PathElem{
+		{Name: "interfaces"},
+		{Name: "interface", Key: map[string]string{"name": "eth1"}},
+		{Name: "config"},
+	}}))
+	assert.Assert(t, !matcher.Match(&pb.Path{Elem: []*pb.PathElem{
+		{Name: "interfaces"},
+	}}))
+	assert.Assert(t, NewPathMatcher(nil).Match(&pb.Path{Elem: []*pb.PathElem{{Name: "interfaces"}}}))
+}
+
+func Test_PathsOverlap(t *testing.T) {
+	tests := []struct {
+		a       string
+		b       string
+		overlap bool
+	}{
+		{"/aa/bb", "/aa/bb", true},
+		{"/aa/bb", "/aa/bb/cc", true},
+		{"/aa/bb/cc", "/aa/bb", true},
+		{"/aa/bb/cc", "/aa/bb/dd", false},
+		{"/aa/bb", "/aa/bbb", false},
+		{"/", "/aa/bb", true},
+		{"/if/interface[name=eth1]/mtu", "/if/interface[name=eth2]/mtu", false},
+		{"/if/interface[name=eth1]/mtu", "/if/interface[name=eth1]/mtu", true},
+		{"/if/interface[name=eth1]", "/if/interface[name=eth1]/config/mtu", true},
+		{"/if/interface", "/if/interface[name=eth2]/mtu", true},
+		{"/if/interface[name=eth2]/mtu", "/if/interface", true},
+		{"/if/interface[name=eth1][unit=0]/mtu", "/if/interface[name=eth1][unit=1]/mtu", false},
+		{"/if/interface[name=eth1]/mtu", "/if/interface[unit=1]/mtu", true},
+		{"/if/interface[name=*]/mtu", "/if/interface[name=eth1]/mtu", true},
+	}
+	for _, test := range tests {
+		a, err := ParseGNMIElements(SplitPath(test.a))
+		assert.NilError(t, err)
+		b, err := ParseGNMIElements(SplitPath(test.b))
+		assert.NilError(t, err)
+		assert.Equal(t, PathsOverlap(a, b), test.overlap, "%s overlapping %s", test.a, test.b)
+	}
+}
@@ -35,3 +35,17 @@ func MatchWildcardChNameRegexp(query string) *regexp.Regexp {
 	regexpQuery = strings.ReplaceAll(regexpQuery, `*`, `[`+legalChars+`]*?`) // Not greedy
 	return regexp.MustCompile(regexpQuery)
 }
+
+// IsWildcardTarget returns true if the target is a glob matching several devices
+func IsWildcardTarget(target string) bool {
+	return strings.ContainsAny(target, "*?")
+}
+
+// MatchWildcardTargetRegexp creates a Regular Expression matching the whole of a device ID from a
+// target glob, where * matches any characters and ? a single character
+func MatchWildcardTargetRegexp(target string) *regexp.Regexp {
+	regexpQuery := regexp.QuoteMeta(target)
+	regexpQuery = strings.ReplaceAll(regexpQuery, `\*`, `.*`)
+	regexpQuery = strings.ReplaceAll(regexpQuery, `\?`, `.`)
+	return regexp.MustCompile(`^` + regexpQuery + `$`)
+}
@@ -129,3 +129,19 @@ func Test_Wildcard_Change2(t *testing.T) {
 	const chID5 = "channge-++"
 	assert.Assert(t, !pathRegexp2.MatchString(chID5), "Expect not match "+chID5)
 }
+
+func Test_Wildcard_Target(t *testing.T) {
+	assert.Assert(t, IsWildcardTarget("*"))
+	assert.Assert(t, IsWildcardTarget("device-?"))
+	assert.Assert(t, !IsWildcardTarget("device-1"))
+
+	allRegexp := MatchWildcardTargetRegexp("*")
+	assert.Assert(t, allRegexp.MatchString("device-1"))
+	assert.Assert(t, allRegexp.MatchString("10.0.0.1:10161"))
+
+	targetRegexp := MatchWildcardTargetRegexp("stratum.?-*")
+	assert.Assert(t, targetRegexp.MatchString("stratum.1-leaf"))
+	assert.Assert(t, !targetRegexp.MatchString("stratumX1-leaf"), "Expect . to be literal")
+	assert.Assert(t, !targetRegexp.MatchString("stratum.12-leaf"))
+	assert.Assert(t, !targetRegexp.MatchString("my-stratum.1-leaf"), "Expect whole ID to match")
+}